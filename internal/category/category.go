@@ -0,0 +1,266 @@
+// Package category validates the AI taxonomy (data/categories.json) and
+// classifies projects against it with explainable keyword matches.
+package category
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// FallbackSlug is the catch-all category used when nothing else matches.
+const FallbackSlug = "other"
+
+// Confidence levels of the keyword matcher, shared with the scraper.
+const (
+	ConfidenceTopic       = 1.0 // GitHub topic exact match
+	ConfidenceDescription = 0.8 // description keyword substring match
+	ConfidenceName        = 0.6 // repo name contains a topic keyword
+)
+
+// Issue is a single validation finding.
+type Issue struct {
+	Level   string // error | warning
+	Slug    string // offending category, empty for global issues
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Slug == "" {
+		return fmt.Sprintf("[%s] %s", i.Level, i.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", i.Level, i.Slug, i.Message)
+}
+
+// HasErrors reports whether any issue is at error level.
+func HasErrors(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Level == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the taxonomy for duplicate slugs, sort_order conflicts,
+// empty keyword lists and keywords shared between categories.
+func Validate(cats []datastore.Category) []Issue {
+	var issues []Issue
+	slugs := make(map[string]bool)
+	orders := make(map[int]string)
+	topicOwner := make(map[string]string)
+	descOwner := make(map[string]string)
+
+	for _, c := range cats {
+		if c.Slug == "" {
+			issues = append(issues, Issue{Level: "error", Message: fmt.Sprintf("分类 %q 缺少 slug", c.Name)})
+			continue
+		}
+		if slugs[c.Slug] {
+			issues = append(issues, Issue{Level: "error", Slug: c.Slug, Message: "slug 重复"})
+		}
+		slugs[c.Slug] = true
+
+		if c.Name == "" {
+			issues = append(issues, Issue{Level: "error", Slug: c.Slug, Message: "name 为空"})
+		}
+
+		if c.SortOrder <= 0 {
+			issues = append(issues, Issue{Level: "warning", Slug: c.Slug, Message: fmt.Sprintf("sort_order=%d 应为正数", c.SortOrder)})
+		} else if other, ok := orders[c.SortOrder]; ok {
+			issues = append(issues, Issue{Level: "error", Slug: c.Slug, Message: fmt.Sprintf("sort_order=%d 与 %s 重复", c.SortOrder, other)})
+		} else {
+			orders[c.SortOrder] = c.Slug
+		}
+
+		if c.Slug == FallbackSlug {
+			continue // fallback category has no keywords by design
+		}
+
+		if len(c.Keywords.Topics) == 0 && len(c.Keywords.Description) == 0 {
+			issues = append(issues, Issue{Level: "error", Slug: c.Slug, Message: "topics 与 description 关键词均为空，无法匹配任何项目"})
+		} else if len(c.Keywords.Topics) == 0 {
+			issues = append(issues, Issue{Level: "warning", Slug: c.Slug, Message: "topics 关键词为空"})
+		} else if len(c.Keywords.Description) == 0 {
+			issues = append(issues, Issue{Level: "warning", Slug: c.Slug, Message: "description 关键词为空"})
+		}
+
+		issues = append(issues, checkKeywords(c.Slug, "topics", c.Keywords.Topics, topicOwner)...)
+		issues = append(issues, checkKeywords(c.Slug, "description", c.Keywords.Description, descOwner)...)
+	}
+
+	if !slugs[FallbackSlug] {
+		issues = append(issues, Issue{Level: "warning", Message: fmt.Sprintf("缺少兜底分类 %q", FallbackSlug)})
+	}
+
+	return issues
+}
+
+// checkKeywords flags empty, duplicated and cross-category keywords.
+// owner tracks which category first claimed each lowercased keyword.
+func checkKeywords(slug, field string, keywords []string, owner map[string]string) []Issue {
+	var issues []Issue
+	seen := make(map[string]bool)
+	for _, kw := range keywords {
+		k := strings.ToLower(strings.TrimSpace(kw))
+		if k == "" {
+			issues = append(issues, Issue{Level: "error", Slug: slug, Message: fmt.Sprintf("%s 含空关键词", field)})
+			continue
+		}
+		if seen[k] {
+			issues = append(issues, Issue{Level: "warning", Slug: slug, Message: fmt.Sprintf("%s 关键词 %q 重复", field, kw)})
+			continue
+		}
+		seen[k] = true
+		if other, ok := owner[k]; ok && other != slug {
+			issues = append(issues, Issue{Level: "warning", Slug: slug, Message: fmt.Sprintf("%s 关键词 %q 与 %s 重叠", field, kw, other)})
+			continue
+		}
+		owner[k] = slug
+	}
+	return issues
+}
+
+// Input is the project data used for classification.
+type Input struct {
+	FullName    string // owner/repo, optional
+	Description string
+	Topics      []string
+}
+
+// InputFromProject builds an Input from a stored project.
+func InputFromProject(p *datastore.Project) Input {
+	in := Input{FullName: p.FullName, Topics: p.Topics}
+	if p.Description != nil {
+		in.Description = *p.Description
+	}
+	return in
+}
+
+// Match is a matched category with the keyword hits that produced it.
+type Match struct {
+	Slug       string
+	Confidence float64
+	Reasons    []string
+}
+
+// Classify matches input against every non-fallback category.
+// Results are sorted by confidence descending, then by taxonomy order.
+func Classify(in Input, cats []datastore.Category) []Match {
+	descLower := strings.ToLower(in.Description)
+	nameLower := strings.ToLower(in.FullName)
+	topicSet := make(map[string]bool, len(in.Topics))
+	for _, t := range in.Topics {
+		topicSet[strings.ToLower(t)] = true
+	}
+
+	var matches []Match
+	for _, cat := range cats {
+		if cat.Slug == FallbackSlug {
+			continue
+		}
+
+		m := Match{Slug: cat.Slug}
+		for _, kw := range cat.Keywords.Topics {
+			kwLower := strings.ToLower(kw)
+			if topicSet[kwLower] {
+				m.Reasons = append(m.Reasons, fmt.Sprintf("topic %q 命中", kw))
+				m.Confidence = max(m.Confidence, ConfidenceTopic)
+			}
+			if nameLower != "" && strings.Contains(nameLower, kwLower) {
+				m.Reasons = append(m.Reasons, fmt.Sprintf("仓库名包含 %q", kw))
+				m.Confidence = max(m.Confidence, ConfidenceName)
+			}
+		}
+		for _, kw := range cat.Keywords.Description {
+			if strings.Contains(descLower, strings.ToLower(kw)) {
+				m.Reasons = append(m.Reasons, fmt.Sprintf("描述包含 %q", kw))
+				m.Confidence = max(m.Confidence, ConfidenceDescription)
+			}
+		}
+
+		if m.Confidence > 0 {
+			matches = append(matches, m)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Confidence > matches[j].Confidence
+	})
+	return matches
+}
+
+// Matches is Classify reduced to the category matches stored on a project.
+// The scraper uses it for both its Trending pre-filter and topic matching,
+// so stored categories and `tishi categories test` always agree.
+func Matches(in Input, cats []datastore.Category) []datastore.CategoryMatch {
+	var out []datastore.CategoryMatch
+	for _, m := range Classify(in, cats) {
+		out = append(out, datastore.CategoryMatch{Slug: m.Slug, Confidence: m.Confidence})
+	}
+	return out
+}
+
+// Reclassify recomputes a project's categories against cats and reports
+// whether anything changed. Projects that no longer match any category
+// fall back to FallbackSlug when it exists in the taxonomy.
+func Reclassify(p *datastore.Project, cats []datastore.Category) bool {
+	next := Matches(InputFromProject(p), cats)
+
+	var primary *string
+	if len(next) > 0 {
+		slug := next[0].Slug
+		primary = &slug
+	} else {
+		for _, c := range cats {
+			if c.Slug == FallbackSlug {
+				slug := FallbackSlug
+				primary = &slug
+				break
+			}
+		}
+	}
+
+	changed := !sameMatches(p.Categories, next) || !sameSlug(p.Category, primary)
+	p.Categories = next
+	p.Category = primary
+	return changed
+}
+
+// References reports whether a project points at the given category slug.
+func References(p *datastore.Project, slug string) bool {
+	if p.Category != nil && *p.Category == slug {
+		return true
+	}
+	for _, m := range p.Categories {
+		if m.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+func sameMatches(a, b []datastore.CategoryMatch) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	conf := make(map[string]float64, len(a))
+	for _, m := range a {
+		conf[m.Slug] = m.Confidence
+	}
+	for _, m := range b {
+		if c, ok := conf[m.Slug]; !ok || c != m.Confidence {
+			return false
+		}
+	}
+	return true
+}
+
+func sameSlug(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,141 @@
+package category
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func testCategories() []datastore.Category {
+	return []datastore.Category{
+		{
+			Slug: "llm", Name: "大语言模型", SortOrder: 1,
+			Keywords: datastore.CategoryKeywords{
+				Topics:      []string{"llm", "chatbot"},
+				Description: []string{"language model"},
+			},
+		},
+		{
+			Slug: "rag", Name: "RAG", SortOrder: 2,
+			Keywords: datastore.CategoryKeywords{
+				Topics:      []string{"rag"},
+				Description: []string{"retrieval"},
+			},
+		},
+		{Slug: "other", Name: "其他", SortOrder: 99},
+	}
+}
+
+func TestValidate_Clean(t *testing.T) {
+	if issues := Validate(testCategories()); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
+
+func TestValidate_Problems(t *testing.T) {
+	cats := testCategories()
+	cats = append(cats,
+		datastore.Category{Slug: "llm", Name: "dup", SortOrder: 3,
+			Keywords: datastore.CategoryKeywords{Topics: []string{"x"}, Description: []string{"y"}}},
+		datastore.Category{Slug: "empty", Name: "Empty", SortOrder: 2},
+		datastore.Category{Slug: "overlap", Name: "Overlap", SortOrder: 5,
+			Keywords: datastore.CategoryKeywords{Topics: []string{"RAG"}, Description: []string{"z"}}},
+	)
+
+	issues := Validate(cats)
+	if !HasErrors(issues) {
+		t.Fatal("expected errors")
+	}
+
+	want := []string{"slug 重复", "sort_order=2 与 rag 重复", "均为空", `"RAG" 与 rag 重叠`}
+	for _, w := range want {
+		found := false
+		for _, i := range issues {
+			if strings.Contains(i.Message, w) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing issue containing %q in %v", w, issues)
+		}
+	}
+}
+
+func TestValidate_MissingFallback(t *testing.T) {
+	cats := testCategories()[:2]
+	issues := Validate(cats)
+	if HasErrors(issues) {
+		t.Errorf("missing fallback should only warn: %v", issues)
+	}
+	if len(issues) != 1 {
+		t.Errorf("got %d issues, want 1", len(issues))
+	}
+}
+
+func TestClassify(t *testing.T) {
+	cats := testCategories()
+
+	matches := Classify(Input{
+		FullName:    "acme/rag-kit",
+		Description: "A retrieval toolkit for large language model apps",
+		Topics:      []string{"LLM"},
+	}, cats)
+
+	if len(matches) != 2 {
+		t.Fatalf("got %d matches, want 2: %+v", len(matches), matches)
+	}
+	if matches[0].Slug != "llm" || matches[0].Confidence != ConfidenceTopic {
+		t.Errorf("matches[0] = %+v, want llm@1.0", matches[0])
+	}
+	if matches[1].Slug != "rag" || matches[1].Confidence != ConfidenceDescription {
+		t.Errorf("matches[1] = %+v, want rag@0.8", matches[1])
+	}
+	if len(matches[1].Reasons) != 2 {
+		t.Errorf("rag reasons = %v, want name + description hits", matches[1].Reasons)
+	}
+}
+
+func TestClassify_NoMatch(t *testing.T) {
+	if m := Classify(Input{Description: "a web framework"}, testCategories()); len(m) != 0 {
+		t.Errorf("expected no matches, got %+v", m)
+	}
+}
+
+func TestReclassify_FallsBackToOther(t *testing.T) {
+	llm := "llm"
+	p := &datastore.Project{
+		FullName:   "a/b",
+		Topics:     []string{"chatbot"},
+		Category:   &llm,
+		Categories: []datastore.CategoryMatch{{Slug: "llm", Confidence: 1.0}},
+	}
+
+	// Remove llm from the taxonomy
+	cats := testCategories()[1:]
+	if !Reclassify(p, cats) {
+		t.Fatal("expected change")
+	}
+	if p.Category == nil || *p.Category != "other" {
+		t.Errorf("Category = %v, want other", p.Category)
+	}
+	if len(p.Categories) != 0 {
+		t.Errorf("Categories = %+v, want empty", p.Categories)
+	}
+	if References(p, "llm") {
+		t.Error("project still references removed category")
+	}
+}
+
+func TestReclassify_Unchanged(t *testing.T) {
+	llm := "llm"
+	p := &datastore.Project{
+		FullName:   "a/b",
+		Topics:     []string{"llm"},
+		Category:   &llm,
+		Categories: []datastore.CategoryMatch{{Slug: "llm", Confidence: 1.0}},
+	}
+	if Reclassify(p, testCategories()) {
+		t.Error("expected no change")
+	}
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/llm"
+	"github.com/zbb88888/tishi/internal/review"
+)
+
+var analysisCmd = &cobra.Command{
+	Use:   "analysis",
+	Short: "查看与回滚项目分析的历史版本，解析对比项目",
+	Long:  "每次 LLM 分析都保存为 data/analyses/{id}/{version}.json 中的一个版本。",
+}
+
+var analysisHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "列出项目分析的所有版本",
+	Args:  cobra.NoArgs,
+	RunE:  runAnalysisHistory,
+}
+
+var analysisRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "将指定版本重新发布为当前分析",
+	Args:  cobra.NoArgs,
+	RunE:  runAnalysisRollback,
+}
+
+var analysisResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "将分析中的对比项目关联到已追踪项目或 GitHub 仓库",
+	Long: `为已有分析（各语言的当前版本与草稿）重新解析 comparison 条目：
+匹配已追踪项目写入 project_id，否则在 GitHub 上查找仓库；
+找不到或可疑的条目记为警告，在 tishi review 中列出。
+新生成的分析在 tishi analyze 时自动解析。`,
+	Args: cobra.NoArgs,
+	RunE: runAnalysisResolve,
+}
+
+var (
+	analysisID      string
+	analysisVersion string
+	analysisLocale  string
+)
+
+func init() {
+	for _, c := range []*cobra.Command{analysisHistoryCmd, analysisRollbackCmd} {
+		c.Flags().StringVar(&analysisID, "id", "", "项目 ID (owner__repo)")
+		c.Flags().StringVar(&analysisLocale, "locale", datastore.DefaultLocale, "分析语言，如 zh、en")
+		_ = c.MarkFlagRequired("id")
+	}
+	analysisRollbackCmd.Flags().StringVar(&analysisVersion, "version", "", "要发布的版本号 (见 analysis history)")
+	_ = analysisRollbackCmd.MarkFlagRequired("version")
+
+	analysisResolveCmd.Flags().StringVar(&analysisID, "id", "", "仅解析指定项目 (owner__repo)，默认全部")
+
+	analysisCmd.AddCommand(analysisHistoryCmd)
+	analysisCmd.AddCommand(analysisRollbackCmd)
+	analysisCmd.AddCommand(analysisResolveCmd)
+}
+
+func runAnalysisHistory(cmd *cobra.Command, args []string) error {
+	store := datastore.NewStore(config.Get().DataDir, logger.Named("analysis"))
+
+	p, err := store.LoadProject(analysisID)
+	if err != nil {
+		return fmt.Errorf("loading project %s: %w", analysisID, err)
+	}
+	locale := datastore.NormalizeLocale(analysisLocale)
+	versions, err := store.ListAnalysisVersions(p.ID, locale)
+	if err != nil {
+		return err
+	}
+
+	live, draft := p.AnalysisFor(locale), p.DraftFor(locale)
+	if len(versions) == 0 {
+		if live != nil {
+			fmt.Printf("%s 的分析早于版本化存储，尚无历史版本（当前状态 %s）。\n", p.FullName, live.Status)
+		} else {
+			fmt.Printf("%s 没有 %s 分析结果。\n", p.FullName, locale)
+		}
+		return nil
+	}
+
+	for _, a := range versions {
+		mark := " "
+		switch {
+		case live != nil && a.Version == live.Version:
+			mark = "*"
+		case draft != nil && a.Version == draft.Version:
+			mark = "+"
+		}
+		tokens := "-"
+		if a.TokenUsage != nil {
+			tokens = fmt.Sprintf("%d", *a.TokenUsage)
+		}
+		prompt := a.PromptVersion
+		if prompt == "" {
+			prompt = "-"
+		}
+		fmt.Printf("%s %-20s %-10s %-16s prompt=%-4s tokens=%-6s %s\n",
+			mark, a.Version, a.Status, a.Model, prompt, tokens, a.Summary)
+	}
+	fmt.Printf("\n共 %d 个版本。* 当前版本，+ 待审核草稿。\n", len(versions))
+	return nil
+}
+
+func runAnalysisRollback(cmd *cobra.Command, args []string) error {
+	log := logger.Named("analysis")
+	store := datastore.NewStore(config.Get().DataDir, log)
+
+	p, err := store.LoadProject(analysisID)
+	if err != nil {
+		return fmt.Errorf("loading project %s: %w", analysisID, err)
+	}
+
+	locale := datastore.NormalizeLocale(analysisLocale)
+	from := ""
+	if live := p.AnalysisFor(locale); live != nil {
+		from = live.Version
+	}
+	rv := datastore.Review{Reviewer: review.Reviewer(config.Get().Review.Reviewer), Time: time.Now().UTC()}
+	a, err := store.RollbackAnalysis(p, locale, analysisVersion, rv)
+	if err != nil {
+		return err
+	}
+
+	log.Info("分析已回滚",
+		zap.String("project", p.FullName),
+		zap.String("locale", locale),
+		zap.String("from", from),
+		zap.String("to", a.Version),
+		zap.String("reviewer", rv.Reviewer),
+	)
+	fmt.Printf("✓ %s: 当前发布版本 → %s\n", p.FullName, a.Version)
+	return nil
+}
+
+func runAnalysisResolve(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+	log := logger.Named("analysis")
+	store := datastore.NewStore(cfg.DataDir, log)
+
+	all, err := store.ListProjects()
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	projects := all
+	if analysisID != "" {
+		p, err := store.LoadProject(analysisID)
+		if err != nil {
+			return fmt.Errorf("loading project %s: %w", analysisID, err)
+		}
+		projects = []*datastore.Project{p}
+	}
+
+	ghToken := ""
+	if len(cfg.GitHub.Tokens) > 0 {
+		ghToken = cfg.GitHub.Tokens[0]
+	}
+	resolver := llm.NewComparisonResolver(all, ghToken, log)
+
+	ctx := cmd.Context()
+	var entries, unverified, updated int
+	for _, p := range projects {
+		changed := false
+		for _, locale := range p.Locales() {
+			for _, a := range []*datastore.Analysis{p.AnalysisFor(locale), p.DraftFor(locale)} {
+				if a == nil || len(a.Comparison) == 0 {
+					continue
+				}
+				comparison, issues := slices.Clone(a.Comparison), validationIssues(a)
+				n := resolver.Resolve(ctx, p, a)
+				entries += len(a.Comparison)
+				unverified += n
+				if !slices.Equal(comparison, a.Comparison) || !slices.Equal(issues, validationIssues(a)) {
+					changed = true
+					updated++
+					if a.Version != "" {
+						if err := store.SaveAnalysisVersion(p.ID, a); err != nil {
+							return err
+						}
+					}
+				}
+				for _, c := range a.Comparison {
+					if c.Status == datastore.ComparisonSuspicious || c.Status == datastore.ComparisonUnresolved {
+						fmt.Printf("⚠ %s [%s] %s: %s\n", p.FullName, locale, c.Project, c.Note)
+					}
+				}
+			}
+		}
+		if changed {
+			if err := store.SaveProject(p); err != nil {
+				return fmt.Errorf("saving project: %w", err)
+			}
+		}
+	}
+
+	log.Info("对比项目解析完成",
+		zap.Int("projects", len(projects)),
+		zap.Int("entries", entries),
+		zap.Int("unverified", unverified),
+		zap.Int("updated", updated),
+	)
+	fmt.Printf("✓ 解析 %d 个对比条目，%d 个未验证，更新 %d 个分析。\n", entries, unverified, updated)
+	return nil
+}
+
+// validationIssues returns a copy of a's validation issues.
+func validationIssues(a *datastore.Analysis) []datastore.ValidationIssue {
+	if a.Validation == nil {
+		return nil
+	}
+	return slices.Clone(a.Validation.Issues)
+}
@@ -1,31 +1,51 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
 	"github.com/zbb88888/tishi/internal/config"
 	"github.com/zbb88888/tishi/internal/datastore"
 	"github.com/zbb88888/tishi/internal/llm"
+	"github.com/zbb88888/tishi/internal/review"
 )
 
 var analyzeCmd = &cobra.Command{
 	Use:   "analyze",
 	Short: "使用 LLM 对 AI 项目生成中文分析报告",
-	Long:  "扫描 data/projects/，对未分析或过期的项目调用 DeepSeek/Qwen API 生成结构化中文分析。",
+	Long:  "扫描 data/projects/，对未分析或过期的项目调用 LLM（DeepSeek/Qwen/OpenAI 兼容端点）生成结构化分析。默认输出中文，--locale zh,en 同时生成英文（每种语言单独审核）。",
 	RunE:  runAnalyze,
 }
 
 var (
-	analyzeID    string
-	analyzeForce bool
-	analyzeDry   bool
+	analyzeID           string
+	analyzeForce        bool
+	analyzeDry          bool
+	analyzeConcurrency  int
+	analyzeBudgetTokens int
+	analyzeBudgetCNY    float64
+	analyzeLocales      []string
+	analyzeRecord       string
+	analyzeReplay       string
+	analyzeFeedback     bool
+	analyzeNoAuto       bool
 )
 
 func init() {
 	analyzeCmd.Flags().StringVar(&analyzeID, "id", "", "指定项目 ID (owner__repo)")
 	analyzeCmd.Flags().BoolVar(&analyzeForce, "force", false, "强制重新分析所有项目")
-	analyzeCmd.Flags().BoolVar(&analyzeDry, "dry-run", false, "仅打印待分析项目，不调用 LLM")
+	analyzeCmd.Flags().BoolVar(&analyzeDry, "dry-run", false, "仅打印分析决策，不调用 LLM（配合 --id 输出完整 prompt）")
+	analyzeCmd.Flags().IntVar(&analyzeConcurrency, "concurrency", 0, "并发分析数（默认取 llm.concurrency）")
+	analyzeCmd.Flags().IntVar(&analyzeBudgetTokens, "budget-tokens", 0, "本次运行 token 上限（默认取 llm.budget.max_tokens）")
+	analyzeCmd.Flags().StringSliceVar(&analyzeLocales, "locale", nil, "输出语言，如 zh,en（默认取 llm.locales）")
+	analyzeCmd.Flags().Float64Var(&analyzeBudgetCNY, "budget-cny", 0, "本次运行预估费用上限，单位元（默认取 llm.budget.max_cost_cny）")
+	analyzeCmd.Flags().StringVar(&analyzeRecord, "record", "", "将 LLM 与 GitHub 请求的响应录制到该目录")
+	analyzeCmd.Flags().StringVar(&analyzeReplay, "replay", "", "从该目录回放录制的响应，离线运行（无需 API key，不写入数据目录，分析结果输出到标准输出）")
+	analyzeCmd.Flags().BoolVar(&analyzeFeedback, "feedback-from-review", false, "配合 --id：带着审核拒绝原因重新生成被拒绝的分析")
+	analyzeCmd.Flags().BoolVar(&analyzeNoAuto, "no-auto-publish", false, "本次不应用 review.auto_publish 自动发布策略")
+	analyzeCmd.MarkFlagsMutuallyExclusive("record", "replay")
 }
 
 func runAnalyze(cmd *cobra.Command, args []string) error {
@@ -40,7 +60,24 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		ghToken = cfg.GitHub.Tokens[0]
 	}
 
-	analyzer, err := llm.NewAnalyzer(store, cfg.LLM, ghToken, log)
+	var llmOpts []llm.Option
+	if analyzeRecord != "" || analyzeReplay != "" {
+		dir, mode := analyzeRecord, llm.RecordMode
+		if analyzeReplay != "" {
+			dir, mode = analyzeReplay, llm.ReplayMode
+		}
+		rec, err := llm.NewRecorder(dir, mode)
+		if err != nil {
+			return err
+		}
+		log.Info("LLM 请求录制/回放", zap.String("mode", mode), zap.String("dir", dir), zap.Time("clock", rec.Now()))
+		if rec.Replaying() {
+			log.Info("回放模式：不写入数据目录，不应用自动发布，分析结果输出到标准输出")
+		}
+		llmOpts = append(llmOpts, llm.WithRecorder(rec))
+	}
+
+	analyzer, err := llm.NewAnalyzer(store, cfg.LLM, ghToken, log, llmOpts...)
 	if err != nil {
 		return err
 	}
@@ -49,6 +86,32 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		ProjectID: analyzeID,
 		Force:     analyzeForce,
 		DryRun:    analyzeDry,
+		Locales:   analyzeLocales,
+
+		FeedbackFromReview: analyzeFeedback,
+
+		Concurrency:  analyzeConcurrency,
+		BudgetTokens: analyzeBudgetTokens,
+		BudgetCNY:    analyzeBudgetCNY,
+	}
+	if cfg.Review.AutoPublish.Enabled && !analyzeNoAuto {
+		policy, err := review.NewPolicy(cfg.Review.AutoPublish)
+		if err != nil {
+			return err
+		}
+		opts.AfterRun = func(generated []llm.Generated) error {
+			items := make([]review.Item, 0, len(generated))
+			for _, g := range generated {
+				items = append(items, review.Item{Project: g.Project, Locale: g.Locale})
+			}
+			published, err := review.AutoPublish(store, policy, items, time.Now().UTC(), log.Named("auto-publish"))
+			log.Info("自动发布策略已应用",
+				zap.Int("generated", len(items)),
+				zap.Int("published", len(published)),
+				zap.Int("drafts", len(items)-len(published)),
+			)
+			return err
+		}
 	}
 
 	if err := analyzer.Run(cmd.Context(), opts); err != nil {
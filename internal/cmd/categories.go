@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/category"
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+var categoriesCmd = &cobra.Command{
+	Use:   "categories",
+	Short: "管理与校验 AI 分类体系",
+	Long:  "查看、增删改 data/categories.json 中的分类，校验关键词配置，并测试分类匹配结果。",
+}
+
+var categoriesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有分类",
+	Args:  cobra.NoArgs,
+	RunE:  runCategoriesList,
+}
+
+var categoriesAddCmd = &cobra.Command{
+	Use:   "add <slug>",
+	Short: "新增分类",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCategoriesAdd,
+}
+
+var categoriesEditCmd = &cobra.Command{
+	Use:   "edit <slug>",
+	Short: "修改分类（仅更新显式指定的字段）",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCategoriesEdit,
+}
+
+var categoriesRemoveCmd = &cobra.Command{
+	Use:   "remove <slug>",
+	Short: "删除分类并重新归类受影响的项目",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCategoriesRemove,
+}
+
+var categoriesValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "校验 slug、sort_order 与关键词配置",
+	Args:  cobra.NoArgs,
+	RunE:  runCategoriesValidate,
+}
+
+var categoriesTestCmd = &cobra.Command{
+	Use:   "test <description>",
+	Short: "测试假设项目会匹配哪些分类",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCategoriesTest,
+}
+
+var (
+	catName        string
+	catDescription string
+	catSortOrder   int
+	catTopics      []string
+	catKeywords    []string
+	catTestTopics  []string
+	catTestName    string
+	catRemoveDry   bool
+)
+
+func init() {
+	for _, c := range []*cobra.Command{categoriesAddCmd, categoriesEditCmd} {
+		c.Flags().StringVar(&catName, "name", "", "分类中文名")
+		c.Flags().StringVar(&catDescription, "description", "", "分类描述")
+		c.Flags().IntVar(&catSortOrder, "sort-order", 0, "排序序号（add 时默认取当前最大值 +1）")
+		c.Flags().StringSliceVar(&catTopics, "topics", nil, "topics 关键词（逗号分隔）")
+		c.Flags().StringSliceVar(&catKeywords, "keywords", nil, "description 关键词（逗号分隔）")
+	}
+	categoriesRemoveCmd.Flags().BoolVar(&catRemoveDry, "dry-run", false, "仅打印受影响项目，不写文件")
+	categoriesTestCmd.Flags().StringSliceVar(&catTestTopics, "topics", nil, "GitHub topics（逗号分隔）")
+	categoriesTestCmd.Flags().StringVar(&catTestName, "name", "", "仓库名 owner/repo（可选）")
+
+	categoriesCmd.AddCommand(categoriesListCmd)
+	categoriesCmd.AddCommand(categoriesAddCmd)
+	categoriesCmd.AddCommand(categoriesEditCmd)
+	categoriesCmd.AddCommand(categoriesRemoveCmd)
+	categoriesCmd.AddCommand(categoriesValidateCmd)
+	categoriesCmd.AddCommand(categoriesTestCmd)
+}
+
+func runCategoriesList(cmd *cobra.Command, args []string) error {
+	store := datastore.NewStore(config.Get().DataDir, logger.Named("categories"))
+
+	cats, err := store.LoadCategories()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(cats, func(i, j int) bool { return cats[i].SortOrder < cats[j].SortOrder })
+
+	// Count projects per primary category from the project files, since
+	// project_ids in categories.json is maintained by the site build.
+	counts := make(map[string]int)
+	projects, err := store.ListProjects()
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	for _, p := range projects {
+		if p.Category != nil {
+			counts[*p.Category]++
+		}
+	}
+
+	for _, c := range cats {
+		fmt.Printf("%3d  %-12s %-12s  %3d 个项目  topics=%d description=%d\n",
+			c.SortOrder, c.Slug, c.Name, counts[c.Slug],
+			len(c.Keywords.Topics), len(c.Keywords.Description))
+	}
+	fmt.Printf("\n共 %d 个分类。\n", len(cats))
+	return nil
+}
+
+func runCategoriesAdd(cmd *cobra.Command, args []string) error {
+	log := logger.Named("categories")
+	store := datastore.NewStore(config.Get().DataDir, log)
+
+	cats, err := store.LoadCategories()
+	if err != nil {
+		return err
+	}
+
+	slug := args[0]
+	for _, c := range cats {
+		if c.Slug == slug {
+			return fmt.Errorf("分类 %s 已存在，请使用 categories edit", slug)
+		}
+	}
+
+	c := datastore.Category{
+		Slug:        slug,
+		Name:        catName,
+		Description: catDescription,
+		SortOrder:   catSortOrder,
+		Keywords: datastore.CategoryKeywords{
+			Topics:      catTopics,
+			Description: catKeywords,
+		},
+	}
+	if c.SortOrder == 0 {
+		c.SortOrder = nextSortOrder(cats)
+	}
+	cats = append(cats, c)
+
+	if err := saveValidCategories(store, cats); err != nil {
+		return err
+	}
+
+	log.Info("分类已新增", zap.String("slug", slug))
+	fmt.Printf("✓ 已新增分类 %s（sort_order=%d）\n", slug, c.SortOrder)
+	return nil
+}
+
+func runCategoriesEdit(cmd *cobra.Command, args []string) error {
+	log := logger.Named("categories")
+	store := datastore.NewStore(config.Get().DataDir, log)
+
+	cats, err := store.LoadCategories()
+	if err != nil {
+		return err
+	}
+
+	slug := args[0]
+	idx := findCategory(cats, slug)
+	if idx < 0 {
+		return fmt.Errorf("分类 %s 不存在", slug)
+	}
+
+	flags := cmd.Flags()
+	c := &cats[idx]
+	if flags.Changed("name") {
+		c.Name = catName
+	}
+	if flags.Changed("description") {
+		c.Description = catDescription
+	}
+	if flags.Changed("sort-order") {
+		c.SortOrder = catSortOrder
+	}
+	if flags.Changed("topics") {
+		c.Keywords.Topics = catTopics
+	}
+	if flags.Changed("keywords") {
+		c.Keywords.Description = catKeywords
+	}
+
+	if err := saveValidCategories(store, cats); err != nil {
+		return err
+	}
+
+	log.Info("分类已更新", zap.String("slug", slug))
+	fmt.Printf("✓ 已更新分类 %s\n", slug)
+	return nil
+}
+
+func runCategoriesRemove(cmd *cobra.Command, args []string) error {
+	log := logger.Named("categories")
+	store := datastore.NewStore(config.Get().DataDir, log)
+
+	cats, err := store.LoadCategories()
+	if err != nil {
+		return err
+	}
+
+	slug := args[0]
+	idx := findCategory(cats, slug)
+	if idx < 0 {
+		return fmt.Errorf("分类 %s 不存在", slug)
+	}
+	if slug == category.FallbackSlug {
+		return fmt.Errorf("不能删除兜底分类 %s", slug)
+	}
+	remaining := append(cats[:idx:idx], cats[idx+1:]...)
+
+	projects, err := store.ListProjects()
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+
+	var affected []*datastore.Project
+	for _, p := range projects {
+		if category.References(p, slug) {
+			affected = append(affected, p)
+		}
+	}
+
+	if catRemoveDry {
+		for _, p := range affected {
+			category.Reclassify(p, remaining)
+			fmt.Printf("%-40s → %s\n", p.FullName, derefOr(p.Category, "(无)"))
+		}
+		fmt.Printf("\ndry-run: 删除 %s 将影响 %d 个项目。\n", slug, len(affected))
+		return nil
+	}
+
+	if err := saveValidCategories(store, remaining); err != nil {
+		return err
+	}
+
+	var updated int
+	now := time.Now().UTC()
+	for _, p := range affected {
+		category.Reclassify(p, remaining)
+		p.UpdatedAt = now
+		if err := store.SaveProject(p); err != nil {
+			log.Warn("重新归类失败", zap.String("project", p.FullName), zap.Error(err))
+			continue
+		}
+		updated++
+		fmt.Printf("%-40s → %s\n", p.FullName, derefOr(p.Category, "(无)"))
+	}
+
+	log.Info("分类已删除",
+		zap.String("slug", slug),
+		zap.Int("affected", len(affected)),
+		zap.Int("reclassified", updated),
+	)
+	fmt.Printf("\n✓ 已删除分类 %s，重新归类 %d/%d 个项目\n", slug, updated, len(affected))
+	return nil
+}
+
+func runCategoriesValidate(cmd *cobra.Command, args []string) error {
+	store := datastore.NewStore(config.Get().DataDir, logger.Named("categories"))
+
+	cats, err := store.LoadCategories()
+	if err != nil {
+		return err
+	}
+
+	issues := category.Validate(cats)
+	for _, i := range issues {
+		fmt.Println(i)
+	}
+	if category.HasErrors(issues) {
+		return fmt.Errorf("categories.json 校验失败（%d 个问题）", len(issues))
+	}
+	if len(issues) == 0 {
+		fmt.Printf("✓ %d 个分类校验通过\n", len(cats))
+	} else {
+		fmt.Printf("\n校验通过，但有 %d 个警告。\n", len(issues))
+	}
+	return nil
+}
+
+func runCategoriesTest(cmd *cobra.Command, args []string) error {
+	store := datastore.NewStore(config.Get().DataDir, logger.Named("categories"))
+
+	cats, err := store.LoadCategories()
+	if err != nil {
+		return err
+	}
+
+	in := category.Input{
+		FullName:    catTestName,
+		Description: args[0],
+		Topics:      catTestTopics,
+	}
+	matches := category.Classify(in, cats)
+	if len(matches) == 0 {
+		fmt.Printf("未匹配任何分类，将归入 %s。\n", category.FallbackSlug)
+		return nil
+	}
+
+	for i, m := range matches {
+		marker := " "
+		if i == 0 {
+			marker = "*"
+		}
+		fmt.Printf("%s %-12s confidence=%.1f\n", marker, m.Slug, m.Confidence)
+		for _, r := range m.Reasons {
+			fmt.Printf("    - %s\n", r)
+		}
+	}
+	fmt.Printf("\n主分类: %s\n", matches[0].Slug)
+	return nil
+}
+
+// saveValidCategories refuses to write a taxonomy with validation errors.
+func saveValidCategories(store *datastore.Store, cats []datastore.Category) error {
+	issues := category.Validate(cats)
+	for _, i := range issues {
+		fmt.Println(i)
+	}
+	if category.HasErrors(issues) {
+		return fmt.Errorf("分类校验失败，未保存")
+	}
+	return store.SaveCategories(cats)
+}
+
+func findCategory(cats []datastore.Category, slug string) int {
+	for i, c := range cats {
+		if c.Slug == slug {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextSortOrder returns max(sort_order)+1, ignoring the fallback category.
+func nextSortOrder(cats []datastore.Category) int {
+	next := 1
+	for _, c := range cats {
+		if c.Slug != category.FallbackSlug && c.SortOrder >= next {
+			next = c.SortOrder + 1
+		}
+	}
+	return next
+}
+
+func derefOr(s *string, fallback string) string {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return fallback
+	}
+	return *s
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/llm"
+)
+
+var embedCmd = &cobra.Command{
+	Use:   "embed",
+	Short: "生成项目 embedding 并计算相似项目",
+	Long:  "对描述、topics 与已发布摘要调用 embeddings 端点（llm.embedding），向量保存在 data/embeddings/；只为新增或内容变化的项目重新生成。随后按余弦相似度为每个项目写入 top-k 相似项目（project.similar）。",
+	RunE:  runEmbed,
+}
+
+var (
+	embedForce bool
+	embedDry   bool
+)
+
+func init() {
+	embedCmd.Flags().BoolVar(&embedForce, "force", false, "重新生成所有项目的 embedding")
+	embedCmd.Flags().BoolVar(&embedDry, "dry-run", false, "仅列出需要生成 embedding 的项目，不调用 API")
+}
+
+func runEmbed(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+	log := logger.Named("embed")
+
+	store := datastore.NewStore(cfg.DataDir, log)
+	embedder, err := llm.NewEmbedder(store, cfg.LLM, log)
+	if err != nil {
+		return err
+	}
+
+	if err := embedder.Run(cmd.Context(), llm.EmbedOptions{Force: embedForce, DryRun: embedDry}); err != nil {
+		log.Error("embedding 失败", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -7,24 +7,52 @@ import (
 	"github.com/zbb88888/tishi/internal/config"
 	"github.com/zbb88888/tishi/internal/datastore"
 	"github.com/zbb88888/tishi/internal/generator"
+	"github.com/zbb88888/tishi/internal/llm"
 )
 
 var generateCmd = &cobra.Command{
-	Use:   "generate [weekly|spotlight]",
+	Use:   "generate [weekly|monthly|spotlight|newcomers]",
 	Short: "从 data/ 数据生成博客文章",
-	Long:  "基于排行榜和项目分析数据，自动生成周报或项目深度解读文章。",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runGenerate,
+	Long: "基于排行榜和项目分析数据，自动生成周报、月报、项目深度解读或新项目速递文章。\n\n" +
+		"周报汇总 --week 指定 ISO 周（默认本周）的全部排行和快照：7 天 Star 增长、排名变动、新入榜和跌出榜单。\n" +
+		"月报汇总 --month 指定月份（默认上个月）的全部排行和快照：Star 增长、排名上升、分类占比变化、新入榜、跌出榜单以及当月发布的深度解读。\n" +
+		"新项目速递收录 --since 时间窗口内首次收录且已发布分析的项目，按评分排序；" +
+		"不足 generator.newcomers.min_projects 个时跳过。\n\n" +
+		"文章内容使用 generator.templates_dir 下的 {类型}.tmpl（其他语言为 {语言}/{类型}.tmpl），没有时使用内置模板；" +
+		"--template 指定单个模板文件，--check 用示例数据渲染模板以校验。\n\n" +
+		"--narrative（或 generator.narrative.enabled）让 LLM 根据周报/月报数据撰写 300–500 字的中文编辑导语和重点项目点评。" +
+		"导语与项目分析一样先保存为草稿，文章在 tishi review posts --approve 之前不发布；" +
+		"待审核的导语草稿在再次生成时保留，--regenerate-narrative 才重新撰写；" +
+		"被拒绝后再次运行 --narrative 会带着拒绝原因重新生成，不带 --narrative 重新生成则发布不含导语的文章。" +
+		"导语生成失败只记录警告，文章照常生成。",
+	Args: cobra.ExactArgs(1),
+	RunE: runGenerate,
 }
 
 var (
-	generateID  string
-	generateDry bool
+	generateID      string
+	generateWeek    string
+	generateMonth   string
+	generateSince   string
+	generateDry     bool
+	generateTpl     string
+	generateCheck   bool
+	generateNarr    bool
+	generateLocales []string
+	generateRenarr  bool
 )
 
 func init() {
 	generateCmd.Flags().StringVar(&generateID, "id", "", "项目 ID（spotlight 类型必填）")
+	generateCmd.Flags().StringVar(&generateWeek, "week", "", "周报的 ISO 周，如 2026-W41（weekly 类型，默认本周）")
+	generateCmd.Flags().StringVar(&generateMonth, "month", "", "月报月份 YYYY-MM（monthly 类型，默认上个月）")
+	generateCmd.Flags().StringVar(&generateSince, "since", generator.DefaultNewcomersSince, "新项目速递的时间窗口，如 3d、12h、1w（newcomers 类型）")
 	generateCmd.Flags().BoolVar(&generateDry, "dry-run", false, "仅打印内容，不写文件")
+	generateCmd.Flags().StringVar(&generateTpl, "template", "", "使用指定模板文件（覆盖 generator.templates_dir 与内置模板）")
+	generateCmd.Flags().BoolVar(&generateCheck, "check", false, "用示例数据渲染模板并打印，校验模板后退出（不读取 data/，不写文件）")
+	generateCmd.Flags().BoolVar(&generateNarr, "narrative", false, "用 LLM 撰写中文编辑导语草稿（weekly/monthly 类型，默认取 generator.narrative.enabled）")
+	generateCmd.Flags().BoolVar(&generateRenarr, "regenerate-narrative", false, "配合 --narrative：重新撰写仍待审核的导语草稿")
+	generateCmd.Flags().StringSliceVar(&generateLocales, "locale", nil, "文章语言，如 zh,en，每种语言生成一篇（默认 zh）")
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
@@ -38,10 +66,36 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 
 	opts := generator.RunOptions{
 		ProjectID: generateID,
+		Week:      generateWeek,
+		Month:     generateMonth,
 		DryRun:    generateDry,
+		Locales:   generateLocales,
+
+		Since:        generateSince,
+		MinNewcomers: cfg.Generator.Newcomers.MinProjects,
+
+		TemplatesDir: cfg.Generator.TemplatesDir,
+		Template:     generateTpl,
+	}
+
+	if generateCheck {
+		return g.Check(postType, opts)
+	}
+
+	narrative := cfg.Generator.Narrative.Enabled
+	if cmd.Flags().Changed("narrative") {
+		narrative = generateNarr
+	}
+	if narrative && (postType == "weekly" || postType == "monthly") {
+		narrator, err := llm.NewNarrator(store, cfg.LLM, log)
+		if err != nil {
+			return err
+		}
+		opts.Narrator = narrator
+		opts.RegenerateNarrative = generateRenarr
 	}
 
-	if err := g.Run(postType, opts); err != nil {
+	if err := g.Run(cmd.Context(), postType, opts); err != nil {
 		log.Error("内容生成失败", zap.Error(err))
 		return err
 	}
@@ -1,7 +1,13 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -9,23 +15,143 @@ import (
 
 	"github.com/zbb88888/tishi/internal/config"
 	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/review"
 )
 
 var reviewCmd = &cobra.Command{
 	Use:   "review",
 	Short: "审核 LLM 生成的项目分析",
-	Long:  "列出所有 draft 状态的分析，或批准/拒绝指定项目的分析结果。",
-	RunE:  runReview,
+	Long: `列出所有 draft 状态的分析，或批准/拒绝指定项目的分析结果。
+
+已发布分析旁的新草稿标记为 [draft*]：批准后替换已发布版本（旧版本标记为
+superseded），拒绝则保留已发布版本不变。
+
+每种语言的分析分别审核：--approve/--reject 默认作用于中文分析，
+英文等其他语言用 --locale 指定；列表默认显示所有语言。
+
+-i 进入交互模式：逐个显示草稿的全部分析字段、项目描述、star 数和
+README 摘录，单键操作（a 批准 / r 拒绝 / s 跳过 / b 上一个 / e 用
+$EDITOR 编辑 / q 退出）。进度保存在 data/cache/review-session.json，
+中断后再次运行 -i 会继续，跳过的草稿排在最后。
+
+--edit ID 把分析（待审核草稿，没有时为已发布版本）导出为 YAML 文档并用
+$EDITOR 打开；保存后按长度限制校验并显示修改，确认后写回并标记为
+人工修改（human_edited）。
+
+每次批准、拒绝、编辑和回滚都会记录审核人（review.reviewer，未配置时取
+git user.name，再退回系统用户名）并追加到 data/reviews.jsonl。--reason
+附带原因或备注；交互模式下按 r 拒绝时会询问原因。被拒绝的分析可用
+tishi analyze --id ID --feedback-from-review 带着拒绝原因重新生成。
+
+启用 review.auto_publish 后，tishi analyze 结束时会自动发布低风险草稿
+（校验通过、对比项目均已解析、排名在前 N 等），标记 auto_published。
+--auto-published 列出尚未人工抽查的自动发布分析；与 --approve=ID 或
+--reject=ID 一起使用时抽查该项目当前发布的版本（即使另有待审核草稿）：
+--approve 确认，--reject 撤下并恢复它替换掉的上一个发布版本。
+
+周报和月报的 LLM 编辑导语用 tishi review posts 审核。`,
+	RunE: runReview,
+}
+
+var reviewBulkApproveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "批量批准符合过滤条件的草稿",
+	Long:  reviewBulkLong,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReviewBulk("published")
+	},
+}
+
+var reviewBulkRejectCmd = &cobra.Command{
+	Use:   "reject",
+	Short: "批量拒绝符合过滤条件的草稿",
+	Long:  reviewBulkLong,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReviewBulk("rejected")
+	},
 }
 
+var reviewPostsCmd = &cobra.Command{
+	Use:   "posts",
+	Short: "审核周报/月报的 LLM 编辑导语",
+	Long: `列出编辑导语待审核的文章，或批准/拒绝指定文章的导语。
+
+tishi generate weekly|monthly --narrative 生成的导语先保存为草稿，文章在
+批准前不发布。只能审核草稿状态的导语。批准后文章随即发布；拒绝后文章
+保持未发布，可用 tishi generate --narrative 带着拒绝原因重新生成导语，
+或不带 --narrative 重新生成以发布不含导语的文章。决定与项目分析一样记入 data/reviews.jsonl。`,
+	Args: cobra.NoArgs,
+	RunE: runReviewPosts,
+}
+
+const reviewBulkLong = `按 --filter 过滤待审核草稿并批量批准或拒绝。过滤条件以空格分隔，全部满足才选中：
+
+  category=rag,agent   主分类为其中之一
+  model=deepseek-chat  生成草稿的模型
+  language!=python     GitHub 主语言
+  score>=60 stars<500  数值比较（= != < <= > >=）
+  age<30d              仓库创建不到 30 天（单位 h、d、w）
+  archived / !archived 是否已归档（也可写 archived=false）
+  validation=ok        无校验问题；warning 仅有警告；error 有错误
+
+字符串比较不区分大小写，= 和 != 可用逗号列出多个值。--dry-run 只列出
+匹配的草稿；否则列出后需确认（--yes 跳过确认）。每个决定都写入
+data/reviews.jsonl，记录所用的过滤条件。
+
+示例：
+  tishi review approve --filter "model=deepseek-chat validation=ok category=rag"
+  tishi review reject --filter "archived" --reason "仓库已归档"`
+
 var (
 	reviewApprove string
 	reviewReject  string
+	reviewLocale  string
+	reviewTUI     bool
+	reviewRestart bool
+	reviewEdit    string
+	reviewReason  string
+	reviewAuto    bool
+
+	postsApprove string
+	postsReject  string
+	postsReason  string
+
+	bulkFilter string
+	bulkLocale string
+	bulkReason string
+	bulkDryRun bool
+	bulkYes    bool
 )
 
 func init() {
 	reviewCmd.Flags().StringVar(&reviewApprove, "approve", "", "批准指定项目 ID 的分析 (owner__repo)")
 	reviewCmd.Flags().StringVar(&reviewReject, "reject", "", "拒绝指定项目 ID 的分析 (owner__repo)")
+	reviewCmd.Flags().StringVar(&reviewLocale, "locale", "", "分析语言，如 zh、en（审核默认 zh，列表默认全部）")
+	reviewCmd.Flags().BoolVarP(&reviewTUI, "interactive", "i", false, "交互式逐个审核草稿（可中断后继续）")
+	reviewCmd.Flags().BoolVar(&reviewRestart, "restart", false, "配合 -i：丢弃上次的审核进度重新开始")
+	reviewCmd.Flags().StringVar(&reviewEdit, "edit", "", "用 $EDITOR 编辑指定项目 ID 的分析 (owner__repo)")
+	reviewCmd.Flags().StringVar(&reviewReason, "reason", "", "审核原因或备注，记入审核日志（配合 --approve/--reject/--edit）")
+	reviewCmd.Flags().BoolVar(&reviewAuto, "auto-published", false, "列出自动发布、尚未人工抽查的分析")
+	reviewCmd.MarkFlagsMutuallyExclusive("approve", "reject", "edit", "interactive")
+	reviewCmd.MarkFlagsMutuallyExclusive("auto-published", "edit", "interactive")
+
+	for _, c := range []*cobra.Command{reviewBulkApproveCmd, reviewBulkRejectCmd} {
+		c.Flags().StringVar(&bulkFilter, "filter", "", "过滤条件，如 \"model=deepseek-chat validation=ok\"")
+		c.Flags().StringVar(&bulkLocale, "locale", "", "只处理该语言的草稿（默认全部语言）")
+		c.Flags().StringVar(&bulkReason, "reason", "", "审核原因或备注，记入审核日志")
+		c.Flags().BoolVar(&bulkDryRun, "dry-run", false, "只列出匹配的草稿，不做修改")
+		c.Flags().BoolVarP(&bulkYes, "yes", "y", false, "不询问确认")
+		_ = c.MarkFlagRequired("filter")
+		reviewCmd.AddCommand(c)
+	}
+
+	reviewPostsCmd.Flags().StringVar(&postsApprove, "approve", "", "批准指定文章的编辑导语并发布文章 (slug)")
+	reviewPostsCmd.Flags().StringVar(&postsReject, "reject", "", "拒绝指定文章的编辑导语 (slug)")
+	reviewPostsCmd.Flags().StringVar(&postsReason, "reason", "", "审核原因或备注，记入审核日志；拒绝原因会用于重新生成")
+	reviewPostsCmd.MarkFlagsMutuallyExclusive("approve", "reject")
+	reviewCmd.AddCommand(reviewPostsCmd)
 }
 
 func runReview(cmd *cobra.Command, args []string) error {
@@ -33,65 +159,446 @@ func runReview(cmd *cobra.Command, args []string) error {
 	log := logger.Named("review")
 
 	store := datastore.NewStore(cfg.DataDir, log)
+	reviewer := review.Reviewer(cfg.Review.Reviewer)
+
+	if reviewReason != "" && reviewApprove == "" && reviewReject == "" && reviewEdit == "" {
+		return fmt.Errorf("--reason 需要配合 --approve、--reject 或 --edit 使用")
+	}
+
+	// Spot-check an auto-published analysis
+	if reviewAuto && (reviewApprove != "" || reviewReject != "") {
+		if reviewApprove != "" {
+			return spotCheck(store, log, reviewApprove, reviewLocale, true, reviewer)
+		}
+		return spotCheck(store, log, reviewReject, reviewLocale, false, reviewer)
+	}
 
 	// Handle approve
 	if reviewApprove != "" {
-		return setAnalysisStatus(store, log, reviewApprove, "published")
+		return setAnalysisStatus(store, log, reviewApprove, reviewLocale, "published", reviewer)
 	}
 
 	// Handle reject
 	if reviewReject != "" {
-		return setAnalysisStatus(store, log, reviewReject, "rejected")
+		return setAnalysisStatus(store, log, reviewReject, reviewLocale, "rejected", reviewer)
+	}
+
+	if reviewEdit != "" {
+		return editAnalysis(store, log, reviewEdit, reviewLocale, reviewer)
 	}
 
-	// Default: list all draft analyses
 	projects, err := store.ListProjects()
 	if err != nil {
 		return fmt.Errorf("listing projects: %w", err)
 	}
+	if reviewAuto {
+		listAutoPublished(projects)
+		return nil
+	}
+	items := review.Pending(projects, reviewLocale)
 
-	var drafts int
-	for _, p := range projects {
-		if p.Analysis != nil && p.Analysis.Status == "draft" {
-			drafts++
-			fmt.Printf("[draft] %-40s  %s\n", p.FullName, p.Analysis.Summary)
+	if reviewTUI {
+		return runReviewInteractive(store, log, cfg.DataDir, items, reviewer)
+	}
+
+	// Default: list all draft analyses
+	for _, it := range items {
+		p, a := it.Project, it.Analysis()
+		tag := "[draft]"
+		if a == p.DraftFor(it.Locale) {
+			tag = "[draft*]" // a published version is still live
 		}
+		fmt.Printf("%-8s %-3s %-40s  %s\n", tag, it.Locale, p.FullName, a.Summary)
+		printValidation(a.Validation)
 	}
 
-	if drafts == 0 {
+	if len(items) == 0 {
 		fmt.Println("没有待审核的分析。")
 	} else {
-		fmt.Printf("\n共 %d 个待审核分析。使用 --approve=ID 或 --reject=ID 审核。\n", drafts)
+		fmt.Printf("\n共 %d 个待审核分析。使用 --approve=ID 或 --reject=ID 审核（非中文加 --locale），或 -i 交互审核。\n", len(items))
 	}
 
 	return nil
 }
 
-func setAnalysisStatus(store *datastore.Store, log *zap.Logger, projectID, status string) error {
+// listAutoPublished prints the auto-published analyses awaiting a
+// spot-check, oldest first.
+func listAutoPublished(projects []*datastore.Project) {
+	items := review.AutoPublished(projects, reviewLocale)
+	sort.SliceStable(items, func(i, j int) bool {
+		return reviewedAt(items[i]).Before(reviewedAt(items[j]))
+	})
+	for _, it := range items {
+		p, a := it.Project, it.Project.PublishedAnalysis(it.Locale)
+		fmt.Printf("[auto]   %-3s %-40s  %s  %s\n", it.Locale, p.FullName, reviewedAt(it).Format("2006-01-02"), a.Summary)
+		printValidation(a.Validation)
+	}
+	if len(items) == 0 {
+		fmt.Println("没有待抽查的自动发布分析。")
+		return
+	}
+	fmt.Printf("\n共 %d 个自动发布分析待抽查。使用 --auto-published --approve=ID 确认，或 --auto-published --reject=ID --reason=... 撤下（非中文加 --locale）。\n", len(items))
+}
+
+func reviewedAt(it review.Item) time.Time {
+	if a := it.Project.PublishedAnalysis(it.Locale); a != nil && a.ReviewedAt != nil {
+		return *a.ReviewedAt
+	}
+	return time.Time{}
+}
+
+// runReviewInteractive pages through items in the terminal. Without a
+// terminal (or single-key support) keys are read line by line.
+func runReviewInteractive(store *datastore.Store, log *zap.Logger, dataDir string, items []review.Item, reviewer string) error {
+	path := review.SessionPath(dataDir)
+	if reviewRestart {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing review session: %w", err)
+		}
+	}
+	session, err := review.LoadSession(path, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println("没有待审核的分析。")
+		return session.Clear()
+	}
+
+	restore, err := review.RawMode(os.Stdin)
+	if err != nil {
+		log.Debug("无法进入单键模式，按键后需回车", zap.Error(err))
+		restore = nil
+	}
+	if restore != nil {
+		defer func() { _ = restore() }()
+	}
+
+	ui := &review.UI{
+		Store:    store,
+		Session:  session,
+		In:       os.Stdin,
+		Out:      os.Stdout,
+		Log:      log,
+		Reviewer: reviewer,
+		Clear:    restore != nil,
+		Raw:      restore != nil,
+		Editor: &review.Editor{
+			Command: review.DefaultEditor(),
+			Out:     os.Stdout,
+			// The editor needs the normal terminal mode.
+			Wrap: func(run func() error) error {
+				if restore == nil {
+					return run()
+				}
+				_ = restore()
+				defer func() {
+					if r, err := review.RawMode(os.Stdin); err == nil {
+						restore = r
+					}
+				}()
+				return run()
+			},
+		},
+	}
+	return ui.Run(items)
+}
+
+// editAnalysis opens a project's pending analysis in locale, or the live
+// one when nothing is pending, in the reviewer's editor and saves the
+// confirmed result as a human edit of the same version.
+func editAnalysis(store *datastore.Store, log *zap.Logger, projectID, locale, reviewer string) error {
 	p, err := store.LoadProject(projectID)
 	if err != nil {
 		return fmt.Errorf("loading project %s: %w", projectID, err)
 	}
+	locale = datastore.NormalizeLocale(locale)
+	a := p.PendingAnalysis(locale)
+	if a == nil {
+		a = p.AnalysisFor(locale)
+	}
+	if a == nil {
+		return fmt.Errorf("项目 %s 没有 %s 分析结果", p.FullName, locale)
+	}
 
-	if p.Analysis == nil {
-		return fmt.Errorf("项目 %s 没有分析结果", p.FullName)
+	in := bufio.NewReader(os.Stdin)
+	ask := func(question string) (rune, error) {
+		fmt.Print(question)
+		line, err := in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			return []rune(line)[0], nil
+		}
+		return 0, nil // Enter or end of input: the safe choice
+	}
+
+	editor := &review.Editor{Command: review.DefaultEditor(), Out: os.Stdout}
+	changed, err := editor.Edit(p, a, ask)
+	if err != nil || !changed {
+		return err
+	}
+
+	rv := datastore.Review{Reviewer: reviewer, Reason: reviewReason, Time: time.Now().UTC()}
+	if err := store.SaveEditedAnalysis(p, a, rv); err != nil {
+		return err
 	}
+	log.Info("分析已人工修改",
+		zap.String("project", p.FullName),
+		zap.String("locale", locale),
+		zap.String("version", a.Version),
+		zap.String("status", a.Status),
+		zap.String("reviewer", reviewer),
+	)
+	fmt.Printf("✓ %s [%s]: 已保存修改（版本 %s，状态 %s）\n", p.FullName, locale, a.Version, a.Status)
+	return nil
+}
 
-	oldStatus := p.Analysis.Status
-	p.Analysis.Status = status
+// runReviewBulk sets status on every pending draft matching --filter,
+// after listing them and asking for confirmation.
+func runReviewBulk(status string) error {
+	cfg := config.Get()
+	log := logger.Named("review")
+	store := datastore.NewStore(cfg.DataDir, log)
+
+	filter, err := review.ParseFilter(bulkFilter)
+	if err != nil {
+		return err
+	}
+	projects, err := store.ListProjects()
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
 	now := time.Now().UTC()
-	p.Analysis.ReviewedAt = &now
-	p.UpdatedAt = now
+	items := filter.Select(review.Pending(projects, bulkLocale), now)
 
-	if err := store.SaveProject(p); err != nil {
-		return fmt.Errorf("saving project: %w", err)
+	verb := "批准"
+	if status == "rejected" {
+		verb = "拒绝"
+	}
+	for _, it := range items {
+		p, a := it.Project, it.Analysis()
+		fmt.Printf("%-3s %-40s %-16s score=%-6.1f %-7s %s\n",
+			it.Locale, p.FullName, a.Model, p.Score, review.ValidationState(a.Validation), a.Summary)
+	}
+	if len(items) == 0 {
+		fmt.Println("没有符合条件的待审核草稿。")
+		return nil
+	}
+	if bulkDryRun {
+		fmt.Printf("\n共 %d 个草稿符合条件（dry-run，未%s）。\n", len(items), verb)
+		return nil
+	}
+	if !bulkYes && !confirm(fmt.Sprintf("\n%s以上 %d 个草稿？[y/N] ", verb, len(items))) {
+		fmt.Println("已取消。")
+		return nil
+	}
+
+	rv := datastore.Review{Reviewer: review.Reviewer(cfg.Review.Reviewer), Reason: bulkReason, Filter: filter.String(), Time: now}
+	done := 0
+	for _, it := range items {
+		a, oldStatus, err := store.SetAnalysisStatus(it.Project, it.Locale, status, rv)
+		if err != nil {
+			return fmt.Errorf("已%s %d 个，%s 失败: %w", verb, done, it.Project.FullName, err)
+		}
+		done++
+		log.Info("分析状态已更新",
+			zap.String("project", it.Project.FullName),
+			zap.String("locale", it.Locale),
+			zap.String("version", a.Version),
+			zap.String("from", oldStatus),
+			zap.String("to", status),
+			zap.String("reviewer", rv.Reviewer),
+			zap.String("filter", rv.Filter),
+		)
+	}
+	fmt.Printf("✓ 已%s %d 个草稿。\n", verb, done)
+	return nil
+}
+
+// runReviewPosts lists the posts whose narrative awaits review, or
+// approves or rejects one post's narrative.
+func runReviewPosts(cmd *cobra.Command, args []string) error {
+	cfg := config.Get()
+	log := logger.Named("review")
+	store := datastore.NewStore(cfg.DataDir, log)
+
+	if postsReason != "" && postsApprove == "" && postsReject == "" {
+		return fmt.Errorf("--reason 需要配合 --approve 或 --reject 使用")
+	}
+	reviewer := review.Reviewer(cfg.Review.Reviewer)
+	if postsApprove != "" {
+		return setNarrativeStatus(store, log, postsApprove, "published", reviewer)
+	}
+	if postsReject != "" {
+		return setNarrativeStatus(store, log, postsReject, "rejected", reviewer)
+	}
+
+	posts, err := store.ListPosts()
+	if err != nil {
+		return fmt.Errorf("listing posts: %w", err)
+	}
+	pending := 0
+	for _, p := range posts {
+		n := p.Narrative
+		if n == nil || n.Status != "draft" {
+			continue
+		}
+		pending++
+		fmt.Printf("[draft]  %-28s %s\n", p.Slug, p.Title)
+		fmt.Printf("         %s，%s 生成\n", n.Model, n.GeneratedAt.Local().Format("2006-01-02 15:04"))
+		if n.ReviewFeedback != "" {
+			fmt.Printf("         针对拒绝意见: %s\n", n.ReviewFeedback)
+		}
+		fmt.Printf("\n%s\n\n", n.Intro)
+		for _, h := range n.Highlights {
+			fmt.Printf("  - %s：%s\n", h.Project, h.Take)
+		}
+		printValidation(n.Validation)
+		fmt.Println()
+	}
+	if pending == 0 {
+		fmt.Println("没有待审核的编辑导语。")
+	} else {
+		fmt.Printf("共 %d 篇文章的编辑导语待审核。使用 --approve=SLUG 发布，或 --reject=SLUG --reason=... 拒绝。\n", pending)
+	}
+	return nil
+}
+
+func setNarrativeStatus(store *datastore.Store, log *zap.Logger, slug, status, reviewer string) error {
+	post, err := store.LoadPost(slug)
+	if err != nil {
+		return fmt.Errorf("loading post %s: %w", slug, err)
+	}
+	if post == nil {
+		return fmt.Errorf("文章 %s 不存在", slug)
+	}
+
+	rv := datastore.Review{Reviewer: reviewer, Reason: postsReason, Time: time.Now().UTC()}
+	oldStatus, err := store.SetNarrativeStatus(post, status, rv)
+	if err != nil {
+		return err
+	}
+
+	log.Info("编辑导语状态已更新",
+		zap.String("post", slug),
+		zap.String("from", oldStatus),
+		zap.String("to", status),
+		zap.String("reviewer", reviewer),
+		zap.String("reason", postsReason),
+	)
+	fmt.Printf("✓ %s: 编辑导语 %s → %s", slug, oldStatus, status)
+	if post.PublishedAt != nil {
+		fmt.Print("，文章已发布")
+	}
+	fmt.Println()
+	return nil
+}
+
+// confirm asks a yes/no question on stdin; anything but y means no.
+func confirm(question string) bool {
+	fmt.Print(question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+// printValidation lists the validator's findings under a review entry.
+func printValidation(v *datastore.Validation) {
+	if v == nil {
+		return
+	}
+	for _, is := range v.Issues {
+		mark := "!"
+		if is.Level == "error" {
+			mark = "✗"
+		}
+		fmt.Printf("         %s %s: %s\n", mark, is.Field, is.Message)
+	}
+	if v.Repairs > 0 {
+		fmt.Printf("         (已自动修复 %d 次)\n", v.Repairs)
+	}
+}
+
+func setAnalysisStatus(store *datastore.Store, log *zap.Logger, projectID, locale, status, reviewer string) error {
+	p, err := store.LoadProject(projectID)
+	if err != nil {
+		return fmt.Errorf("loading project %s: %w", projectID, err)
+	}
+
+	locale = datastore.NormalizeLocale(locale)
+	rv := datastore.Review{Reviewer: reviewer, Reason: reviewReason, Time: time.Now().UTC()}
+	a, oldStatus, err := store.SetAnalysisStatus(p, locale, status, rv)
+	if err != nil {
+		return err
 	}
 
 	log.Info("分析状态已更新",
 		zap.String("project", p.FullName),
+		zap.String("locale", locale),
+		zap.String("version", a.Version),
 		zap.String("from", oldStatus),
 		zap.String("to", status),
+		zap.String("reviewer", reviewer),
+		zap.String("reason", reviewReason),
 	)
-	fmt.Printf("✓ %s: %s → %s\n", p.FullName, oldStatus, status)
+	fmt.Printf("✓ %s [%s]: %s → %s\n", p.FullName, locale, oldStatus, status)
+	return nil
+}
+
+// spotCheck confirms or takes down the live auto-published analysis of a
+// project in locale. Taking it down republishes the version it replaced.
+func spotCheck(store *datastore.Store, log *zap.Logger, projectID, locale string, approve bool, reviewer string) error {
+	p, err := store.LoadProject(projectID)
+	if err != nil {
+		return fmt.Errorf("loading project %s: %w", projectID, err)
+	}
+	locale = datastore.NormalizeLocale(locale)
+	if a := p.PublishedAnalysis(locale); a == nil || !a.AutoPublished {
+		return fmt.Errorf("项目 %s 当前发布的 %s 分析不是自动发布的", p.FullName, locale)
+	}
+
+	rv := datastore.Review{Reviewer: reviewer, Reason: reviewReason, Time: time.Now().UTC()}
+	if approve {
+		a, err := store.ConfirmAnalysis(p, locale, rv)
+		if err != nil {
+			return err
+		}
+		log.Info("自动发布分析已确认",
+			zap.String("project", p.FullName),
+			zap.String("locale", locale),
+			zap.String("version", a.Version),
+			zap.String("reviewer", reviewer),
+			zap.String("reason", reviewReason),
+		)
+		fmt.Printf("✓ %s [%s]: 已确认自动发布版本 %s\n", p.FullName, locale, a.Version)
+		return nil
+	}
+
+	a, restored, err := store.TakeDownAnalysis(p, locale, rv)
+	if err != nil {
+		return err
+	}
+	to := ""
+	if restored != nil {
+		to = restored.Version
+	}
+	log.Info("自动发布分析已撤下",
+		zap.String("project", p.FullName),
+		zap.String("locale", locale),
+		zap.String("version", a.Version),
+		zap.String("restored", to),
+		zap.String("reviewer", reviewer),
+		zap.String("reason", reviewReason),
+	)
+	if restored != nil {
+		fmt.Printf("✓ %s [%s]: 已撤下 %s，恢复发布版本 %s\n", p.FullName, locale, a.Version, restored.Version)
+	} else {
+		fmt.Printf("✓ %s [%s]: 已撤下 %s，没有可恢复的上一个发布版本\n", p.FullName, locale, a.Version)
+	}
 	return nil
 }
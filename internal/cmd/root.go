@@ -38,9 +38,13 @@ func init() {
 	rootCmd.AddCommand(scrapeCmd)
 	rootCmd.AddCommand(scoreCmd)
 	rootCmd.AddCommand(analyzeCmd)
+	rootCmd.AddCommand(embedCmd)
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(reviewCmd)
+	rootCmd.AddCommand(analysisCmd)
 	rootCmd.AddCommand(pushCmd)
+	rootCmd.AddCommand(categoriesCmd)
+	rootCmd.AddCommand(usageCmd)
 
 	// 信息子命令
 	rootCmd.AddCommand(versionCmd)
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/llm"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "LLM 用量与费用统计",
+}
+
+var usageReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "按模型、命令与项目汇总某月的 LLM 用量与费用",
+	Long:  "读取 data/usage/{月份}.jsonl（analyze 与 embed 每次 API 调用追加一行，费用按调用时的 llm.prices 计算），按模型、命令和项目汇总 token 与预估费用（元）。",
+	RunE:  runUsageReport,
+}
+
+var (
+	usageMonth string
+	usageTop   int
+)
+
+func init() {
+	usageReportCmd.Flags().StringVar(&usageMonth, "month", "", "统计月份 YYYY-MM（默认本月，UTC）")
+	usageReportCmd.Flags().IntVar(&usageTop, "top", 20, "按项目列出的最大条数（0 = 全部）")
+
+	usageCmd.AddCommand(usageReportCmd)
+}
+
+func runUsageReport(cmd *cobra.Command, args []string) error {
+	month := usageMonth
+	if month == "" {
+		month = time.Now().UTC().Format("2006-01")
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return fmt.Errorf("invalid --month %q, want YYYY-MM", month)
+	}
+
+	store := datastore.NewStore(config.Get().DataDir, logger.Named("usage"))
+	records, err := store.LoadUsage(month)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Printf("%s 没有 LLM 用量记录。\n", month)
+		return nil
+	}
+
+	rep := llm.BuildUsageReport(month, records)
+	fmt.Printf("%s LLM 用量：%d 次调用，%d tokens（输入 %d / 输出 %d），预估 ¥%.2f\n",
+		month, rep.Total.Calls, rep.Total.TotalTokens, rep.Total.PromptTokens, rep.Total.CompletionTokens, rep.Total.CostCNY)
+
+	printUsageLines("按模型", rep.ByModel, 0)
+	printUsageLines("按命令", rep.ByCommand, 0)
+	printUsageLines("按项目", rep.ByProject, usageTop)
+
+	if len(rep.Unpriced) > 0 {
+		fmt.Printf("\n以下模型没有价格配置，费用按 0 计算（可在 llm.prices 中添加）：%v\n", rep.Unpriced)
+	}
+	return nil
+}
+
+func printUsageLines(title string, lines []llm.UsageLine, top int) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Printf("\n%s\n", title)
+	shown := lines
+	if top > 0 && len(shown) > top {
+		shown = shown[:top]
+	}
+	for _, l := range shown {
+		fmt.Printf("  %-36s %6d 次 %10d tokens（输入 %d / 输出 %d） ¥%8.2f\n",
+			l.Key, l.Calls, l.TotalTokens, l.PromptTokens, l.CompletionTokens, l.CostCNY)
+	}
+	if len(shown) < len(lines) {
+		fmt.Printf("  …… 另有 %d 项（--top 0 查看全部）\n", len(lines)-len(shown))
+	}
+}
@@ -20,6 +20,9 @@ type Config struct {
 	LLM     LLMConfig     `mapstructure:"llm"`
 	Logging LoggingConfig `mapstructure:"logging"`
 	Site    SiteConfig    `mapstructure:"site"`
+	Review  ReviewConfig  `mapstructure:"review"`
+
+	Generator GeneratorConfig `mapstructure:"generator"`
 }
 
 // GitHubConfig holds GitHub API settings.
@@ -46,12 +49,79 @@ type ScorerConfig struct {
 
 // LLMConfig holds LLM provider settings for project analysis.
 type LLMConfig struct {
-	Provider    string  `mapstructure:"provider"` // deepseek or qwen
-	APIKey      string  `mapstructure:"api_key"`
-	Model       string  `mapstructure:"model"` // empty = provider default
-	MaxTokens   int     `mapstructure:"max_tokens"`
-	Temperature float64 `mapstructure:"temperature"`
-	RetryMax    int     `mapstructure:"retry_max"`
+	Provider    string                       `mapstructure:"provider"` // deepseek, qwen, openai, ollama, vllm or a custom name
+	APIKey      string                       `mapstructure:"api_key"`
+	Model       string                       `mapstructure:"model"`     // empty = provider default
+	BaseURL     string                       `mapstructure:"base_url"`  // overrides the provider endpoint
+	Headers     map[string]string            `mapstructure:"headers"`   // extra HTTP headers (e.g. gateway auth)
+	JSONMode    string                       `mapstructure:"json_mode"` // native or prompt; empty = provider default
+	Providers   map[string]LLMProviderConfig `mapstructure:"providers"` // additional OpenAI-compatible providers
+	MaxTokens   int                          `mapstructure:"max_tokens"`
+	Temperature float64                      `mapstructure:"temperature"`
+	Timeout     time.Duration                `mapstructure:"timeout"` // per-request HTTP timeout
+
+	RetryMax       int                 `mapstructure:"retry_max"`        // retries per provider for 429/5xx/timeouts/bad JSON
+	RepairMax      int                 `mapstructure:"repair_max"`       // repair round-trips when output fails validation
+	RetryBaseDelay time.Duration       `mapstructure:"retry_base_delay"` // first backoff step, doubled per retry
+	RetryMaxDelay  time.Duration       `mapstructure:"retry_max_delay"`  // backoff cap, also caps Retry-After
+	Fallbacks      []LLMFallbackConfig `mapstructure:"fallbacks"`        // tried in order when the primary fails
+
+	Concurrency       int             `mapstructure:"concurrency"`         // parallel analyze workers
+	RequestsPerMinute int             `mapstructure:"requests_per_minute"` // 0 = unlimited
+	Budget            LLMBudgetConfig `mapstructure:"budget"`
+	ReanalyzeMaxAge   time.Duration   `mapstructure:"reanalyze_max_age"` // refresh drafts / flag published older than this
+	Prices            []LLMPrice      `mapstructure:"prices"`            // overrides built-in model prices
+
+	PromptsDir      string            `mapstructure:"prompts_dir"`       // analysis/{variant}/vN.tmpl templates
+	READMEMaxTokens int               `mapstructure:"readme_max_tokens"` // preprocessed README budget per prompt
+	PromptVariants  map[string]string `mapstructure:"prompt_variants"`   // category slug -> prompt variant
+	Locales         []string          `mapstructure:"locales"`           // analysis output locales, e.g. [zh, en]
+
+	Embedding LLMEmbeddingConfig `mapstructure:"embedding"` // similar-project embeddings (tishi embed)
+}
+
+// LLMEmbeddingConfig configures the embeddings endpoint. Empty provider,
+// api_key and base_url reuse the llm.* values; empty model uses the
+// provider's default embedding model.
+type LLMEmbeddingConfig struct {
+	Provider  string  `mapstructure:"provider"`
+	Model     string  `mapstructure:"model"`
+	APIKey    string  `mapstructure:"api_key"`
+	BaseURL   string  `mapstructure:"base_url"`
+	BatchSize int     `mapstructure:"batch_size"` // inputs per request
+	TopK      int     `mapstructure:"top_k"`      // similar projects kept per project
+	MinScore  float64 `mapstructure:"min_score"`  // cosine similarity threshold
+}
+
+// LLMBudgetConfig caps the spend of a single analyze run. Zero = unlimited.
+type LLMBudgetConfig struct {
+	MaxTokens  int     `mapstructure:"max_tokens"`
+	MaxCostCNY float64 `mapstructure:"max_cost_cny"`
+}
+
+// LLMPrice is a model's price in CNY per million tokens.
+type LLMPrice struct {
+	Model  string  `mapstructure:"model"`
+	Input  float64 `mapstructure:"input"`  // prompt tokens
+	Output float64 `mapstructure:"output"` // completion tokens
+}
+
+// LLMFallbackConfig is a failover target. Empty provider means the primary
+// provider with a different model; empty api_key reuses llm.api_key.
+type LLMFallbackConfig struct {
+	Provider string `mapstructure:"provider"`
+	Model    string `mapstructure:"model"`
+	APIKey   string `mapstructure:"api_key"`
+	BaseURL  string `mapstructure:"base_url"`
+}
+
+// LLMProviderConfig defines or overrides a provider in the LLM registry.
+type LLMProviderConfig struct {
+	BaseURL  string            `mapstructure:"base_url"`
+	Model    string            `mapstructure:"model"`      // default model for this provider
+	NoAPIKey bool              `mapstructure:"no_api_key"` // allow requests without llm.api_key
+	JSONMode string            `mapstructure:"json_mode"`  // native or prompt
+	Headers  map[string]string `mapstructure:"headers"`
 }
 
 // LoggingConfig holds logging settings.
@@ -67,6 +137,41 @@ type SiteConfig struct {
 	Description string `mapstructure:"description"`
 }
 
+// GeneratorConfig holds blog post generation settings.
+type GeneratorConfig struct {
+	TemplatesDir string          `mapstructure:"templates_dir"` // {type}.tmpl overrides of the built-in post templates
+	Newcomers    NewcomersConfig `mapstructure:"newcomers"`
+	Narrative    NarrativeConfig `mapstructure:"narrative"`
+}
+
+// NarrativeConfig configures the LLM-written editorial intro of weekly
+// and monthly reports.
+type NarrativeConfig struct {
+	Enabled bool `mapstructure:"enabled"` // write a draft narrative on every weekly/monthly run
+}
+
+// NewcomersConfig configures the new-project flash post.
+type NewcomersConfig struct {
+	MinProjects int `mapstructure:"min_projects"` // skip the post when fewer projects qualify
+}
+
+// ReviewConfig holds analysis review settings.
+type ReviewConfig struct {
+	Reviewer    string            `mapstructure:"reviewer"` // name in the review log; empty = git user.name, then OS user
+	AutoPublish AutoPublishConfig `mapstructure:"auto_publish"`
+}
+
+// AutoPublishConfig is the policy tishi analyze applies to the drafts it
+// just generated. A draft is published without review only if every
+// condition holds; anything else stays a draft.
+type AutoPublishConfig struct {
+	Enabled          bool   `mapstructure:"enabled"`
+	MaxRank          int    `mapstructure:"max_rank"`          // only projects ranked 1..N; 0 = any rank
+	AllowWarnings    bool   `mapstructure:"allow_warnings"`    // validator warnings don't block (errors always do)
+	ReplacePublished bool   `mapstructure:"replace_published"` // also replace an analysis that is already published
+	Filter           string `mapstructure:"filter"`            // extra conditions in tishi review --filter syntax
+}
+
 // global holds the singleton config instance.
 var global *Config
 
@@ -98,6 +203,7 @@ func Load(cfgFile string) (*Config, error) {
 	_ = viper.BindEnv("llm.provider", "TISHI_LLM_PROVIDER")
 	_ = viper.BindEnv("llm.api_key", "TISHI_LLM_API_KEY")
 	_ = viper.BindEnv("llm.model", "TISHI_LLM_MODEL")
+	_ = viper.BindEnv("llm.base_url", "TISHI_LLM_BASE_URL")
 
 	// Read config file (optional)
 	if err := viper.ReadInConfig(); err != nil {
@@ -157,12 +263,35 @@ func setDefaults() {
 	viper.SetDefault("llm.provider", "deepseek")
 	viper.SetDefault("llm.api_key", "")
 	viper.SetDefault("llm.model", "")
+	viper.SetDefault("llm.base_url", "")
+	viper.SetDefault("llm.json_mode", "")
 	viper.SetDefault("llm.max_tokens", 2000)
 	viper.SetDefault("llm.temperature", 0.3)
+	viper.SetDefault("llm.timeout", "60s")
 	viper.SetDefault("llm.retry_max", 3)
+	viper.SetDefault("llm.repair_max", 1)
+	viper.SetDefault("llm.retry_base_delay", "2s")
+	viper.SetDefault("llm.retry_max_delay", "60s")
+	viper.SetDefault("llm.concurrency", 4)
+	viper.SetDefault("llm.reanalyze_max_age", "720h")
+	viper.SetDefault("llm.prompts_dir", "./prompts")
+	viper.SetDefault("llm.readme_max_tokens", 1500)
+	viper.SetDefault("llm.locales", []string{"zh"})
+	viper.SetDefault("llm.requests_per_minute", 30)
+	viper.SetDefault("llm.embedding.batch_size", 32)
+	viper.SetDefault("llm.embedding.top_k", 5)
+	viper.SetDefault("llm.embedding.min_score", 0.5)
 
 	viper.SetDefault("site.domain", "localhost")
 	viper.SetDefault("site.title", "tishi — AI 开源项目深度分析")
 	viper.SetDefault("site.description", "追踪 GitHub AI 热门开源项目趋势，提供中文深度分析报告")
 
+	viper.SetDefault("review.reviewer", "")
+	viper.SetDefault("review.auto_publish.enabled", false)
+	viper.SetDefault("review.auto_publish.max_rank", 50)
+
+	viper.SetDefault("generator.templates_dir", "./templates")
+	viper.SetDefault("generator.newcomers.min_projects", 3)
+	viper.SetDefault("generator.narrative.enabled", false)
+
 }
@@ -0,0 +1,460 @@
+package datastore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Analysis versions live in data/analyses/{project_id}/{version}.json, and
+// for other locales in data/analyses/{project_id}/{locale}/{version}.json.
+// For each locale the live copy is the published version, or the latest
+// one when nothing has been published yet; the draft is a newer, unreviewed
+// version generated while a published one is live. The DefaultLocale pair
+// is Project.Analysis/Draft, other locales are in Project.Localized.
+//
+// Version statuses: draft | published | rejected | superseded.
+
+// DefaultLocale is the locale of Project.Analysis and of analyses and
+// posts with an empty Locale.
+const DefaultLocale = "zh"
+
+// versionLayout formats Analysis.Version from GeneratedAt.
+const versionLayout = "20060102T150405Z"
+
+// NormalizeLocale lowercases a locale and maps "" and zh-* variants to
+// DefaultLocale, e.g. "EN" -> "en", "zh-CN" -> "zh".
+func NormalizeLocale(locale string) string {
+	l := strings.ToLower(strings.TrimSpace(locale))
+	if l == "" || l == DefaultLocale || strings.HasPrefix(l, DefaultLocale+"-") {
+		return DefaultLocale
+	}
+	return l
+}
+
+// EffectiveLocale returns a's locale, DefaultLocale when unset.
+func (a *Analysis) EffectiveLocale() string {
+	return NormalizeLocale(a.Locale)
+}
+
+// AnalysisFor returns the live analysis in locale, or nil.
+func (p *Project) AnalysisFor(locale string) *Analysis {
+	locale = NormalizeLocale(locale)
+	if locale == DefaultLocale {
+		return p.Analysis
+	}
+	if la := p.Localized[locale]; la != nil {
+		return la.Analysis
+	}
+	return nil
+}
+
+// DraftFor returns the draft parked next to the published analysis in
+// locale, or nil.
+func (p *Project) DraftFor(locale string) *Analysis {
+	locale = NormalizeLocale(locale)
+	if locale == DefaultLocale {
+		return p.Draft
+	}
+	if la := p.Localized[locale]; la != nil {
+		return la.Draft
+	}
+	return nil
+}
+
+// PublishedAnalysis returns the published analysis in locale, or nil.
+func (p *Project) PublishedAnalysis(locale string) *Analysis {
+	if a := p.AnalysisFor(locale); a != nil && a.Status == "published" {
+		return a
+	}
+	return nil
+}
+
+// Locales returns the locales the project has analyses in, DefaultLocale
+// first and the rest sorted.
+func (p *Project) Locales() []string {
+	var out []string
+	if p.Analysis != nil || p.Draft != nil {
+		out = append(out, DefaultLocale)
+	}
+	var other []string
+	for l, la := range p.Localized {
+		if la != nil && (la.Analysis != nil || la.Draft != nil) {
+			other = append(other, l)
+		}
+	}
+	sort.Strings(other)
+	return append(out, other...)
+}
+
+// setAnalyses replaces the live/draft pair for locale.
+func (p *Project) setAnalyses(locale string, live, draft *Analysis) {
+	locale = NormalizeLocale(locale)
+	if locale == DefaultLocale {
+		p.Analysis, p.Draft = live, draft
+		return
+	}
+	if live == nil && draft == nil {
+		delete(p.Localized, locale)
+		return
+	}
+	if p.Localized == nil {
+		p.Localized = make(map[string]*LocalizedAnalysis)
+	}
+	p.Localized[locale] = &LocalizedAnalysis{Analysis: live, Draft: draft}
+}
+
+// PendingAnalysis returns the analysis in locale awaiting review, or nil.
+func (p *Project) PendingAnalysis(locale string) *Analysis {
+	if d := p.DraftFor(locale); d != nil {
+		return d
+	}
+	if a := p.AnalysisFor(locale); a != nil && a.Status == "draft" {
+		return a
+	}
+	return nil
+}
+
+// AttachAnalysis installs a newly generated analysis in its locale. While
+// a published version is live the new one is parked as the draft so it
+// cannot unpublish reviewed text. It returns the unreviewed draft it
+// replaces, if any.
+func (p *Project) AttachAnalysis(a *Analysis) (replaced *Analysis) {
+	locale := a.EffectiveLocale()
+	live, draft := p.AnalysisFor(locale), p.DraftFor(locale)
+	if live != nil && live.Status == "published" {
+		p.setAnalyses(locale, live, a)
+		return draft
+	}
+	if live != nil && live.Status == "draft" {
+		replaced = live
+	}
+	p.setAnalyses(locale, a, nil)
+	return replaced
+}
+
+func (s *Store) analysesDir(projectID, locale string) string {
+	locale = NormalizeLocale(locale)
+	if locale == DefaultLocale {
+		return filepath.Join(s.dataDir, "analyses", projectID)
+	}
+	return filepath.Join(s.dataDir, "analyses", projectID, locale)
+}
+
+// SaveAnalysisVersion writes an analysis version file in a's locale,
+// assigning a.Version from GeneratedAt on first save.
+func (s *Store) SaveAnalysisVersion(projectID string, a *Analysis) error {
+	dir := s.analysesDir(projectID, a.Locale)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating analyses dir: %w", err)
+	}
+
+	if a.Version == "" {
+		base := a.GeneratedAt.UTC().Format(versionLayout)
+		a.Version = base
+		for i := 2; ; i++ {
+			if _, err := os.Stat(filepath.Join(dir, a.Version+".json")); os.IsNotExist(err) {
+				break
+			}
+			a.Version = fmt.Sprintf("%s-%d", base, i)
+		}
+	}
+
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling analysis %s/%s: %w", projectID, a.Version, err)
+	}
+	data = append(data, '\n')
+
+	path := filepath.Join(dir, a.Version+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadAnalysisVersion reads a single analysis version in locale.
+func (s *Store) LoadAnalysisVersion(projectID, locale, version string) (*Analysis, error) {
+	path := filepath.Join(s.analysesDir(projectID, locale), version+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading analysis %s/%s: %w", projectID, version, err)
+	}
+
+	var a Analysis
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("parsing analysis %s/%s: %w", projectID, version, err)
+	}
+	return &a, nil
+}
+
+// ListAnalysisVersions returns all versions of a project's analysis in
+// locale, oldest first.
+func (s *Store) ListAnalysisVersions(projectID, locale string) ([]*Analysis, error) {
+	entries, err := os.ReadDir(s.analysesDir(projectID, locale))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("listing analyses dir: %w", err)
+	}
+
+	var versions []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			versions = append(versions, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+	sort.Strings(versions)
+
+	out := make([]*Analysis, 0, len(versions))
+	for _, v := range versions {
+		a, err := s.LoadAnalysisVersion(projectID, locale, v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// SetAnalysisStatus reviews the pending analysis in locale (or, when
+// nothing is pending, the live one) and persists both the version file and
+// project. Publishing a draft supersedes the previously published version;
+// rejecting a parked draft leaves the published version live. Other
+// locales are not touched. The reviewer and reason are stamped on the
+// analysis and the decision is appended to the review log. It returns the
+// analysis that was updated and its previous status.
+func (s *Store) SetAnalysisStatus(p *Project, locale, status string, rv Review) (*Analysis, string, error) {
+	live, draft := p.AnalysisFor(locale), p.DraftFor(locale)
+	target := p.PendingAnalysis(locale)
+	if target == nil {
+		target = live
+	}
+	if target == nil {
+		return nil, "", fmt.Errorf("项目 %s 没有 %s 分析结果", p.FullName, NormalizeLocale(locale))
+	}
+	oldStatus := target.Status
+
+	// Backfill versions for analyses created before versioning.
+	for _, a := range []*Analysis{live, draft} {
+		if a != nil && a.Version == "" {
+			if err := s.SaveAnalysisVersion(p.ID, a); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	target.Status = status
+	stampReview(target, rv)
+
+	if target == draft {
+		switch status {
+		case "published":
+			if err := s.supersede(p.ID, live); err != nil {
+				return nil, "", err
+			}
+			p.setAnalyses(locale, draft, nil)
+		case "rejected":
+			p.setAnalyses(locale, live, nil)
+		}
+	}
+
+	if err := s.SaveAnalysisVersion(p.ID, target); err != nil {
+		return nil, "", err
+	}
+	p.UpdatedAt = rv.Time
+	if err := s.SaveProject(p); err != nil {
+		return nil, "", fmt.Errorf("saving project: %w", err)
+	}
+
+	action := ReviewPublish
+	if status == "rejected" {
+		action = ReviewReject
+	}
+	return target, oldStatus, s.appendReview(p, target, action, oldStatus, rv)
+}
+
+// stampReview records who reviewed a, when, and why. A decision without
+// a reason clears the previous one.
+func stampReview(a *Analysis, rv Review) {
+	t := rv.Time
+	a.ReviewedAt = &t
+	a.ReviewedBy = rv.Reviewer
+	a.ReviewNote = rv.Reason
+}
+
+// RollbackAnalysis republishes an earlier version in locale. The currently
+// published version is superseded; a pending draft is left untouched
+// unless it is the version being published. The rollback is logged like
+// a review decision.
+func (s *Store) RollbackAnalysis(p *Project, locale, version string, rv Review) (*Analysis, error) {
+	target, err := s.LoadAnalysisVersion(p.ID, locale, version)
+	if err != nil {
+		return nil, err
+	}
+
+	live, draft := p.AnalysisFor(locale), p.DraftFor(locale)
+	if live != nil && live.Version != version {
+		if live.Version == "" {
+			if err := s.SaveAnalysisVersion(p.ID, live); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.supersede(p.ID, live); err != nil {
+			return nil, err
+		}
+	}
+	if draft != nil && draft.Version == version {
+		draft = nil
+	}
+
+	from := target.Status
+	target.Status = "published"
+	stampReview(target, rv)
+	target.Stale = false
+	target.StaleReason = ""
+	if err := s.SaveAnalysisVersion(p.ID, target); err != nil {
+		return nil, err
+	}
+
+	p.setAnalyses(locale, target, draft)
+	p.UpdatedAt = rv.Time
+	if err := s.SaveProject(p); err != nil {
+		return nil, fmt.Errorf("saving project: %w", err)
+	}
+	return target, s.appendReview(p, target, ReviewRollback, from, rv)
+}
+
+// ConfirmAnalysis records a reviewer's approval of the published analysis
+// in locale, e.g. after spot-checking an auto-published one. A pending
+// draft is not touched.
+func (s *Store) ConfirmAnalysis(p *Project, locale string, rv Review) (*Analysis, error) {
+	live := p.PublishedAnalysis(locale)
+	if live == nil {
+		return nil, fmt.Errorf("项目 %s 没有已发布的 %s 分析", p.FullName, NormalizeLocale(locale))
+	}
+	stampReview(live, rv)
+	if err := s.SaveAnalysisVersion(p.ID, live); err != nil {
+		return nil, err
+	}
+	p.UpdatedAt = rv.Time
+	if err := s.SaveProject(p); err != nil {
+		return nil, fmt.Errorf("saving project: %w", err)
+	}
+	return live, s.appendReview(p, live, ReviewPublish, "published", rv)
+}
+
+// TakeDownAnalysis rejects the published analysis in locale after a
+// spot-check and republishes the version it replaced: the last other
+// version the review log shows as published, if it is still superseded.
+// Without one, a pending draft takes the live slot, or the rejected
+// analysis stays in place unpublished. Both steps are logged. It returns
+// the rejected analysis and the restored one (nil if none).
+func (s *Store) TakeDownAnalysis(p *Project, locale string, rv Review) (*Analysis, *Analysis, error) {
+	live, draft := p.PublishedAnalysis(locale), p.DraftFor(locale)
+	if live == nil {
+		return nil, nil, fmt.Errorf("项目 %s 没有已发布的 %s 分析", p.FullName, NormalizeLocale(locale))
+	}
+	if live.Version == "" {
+		if err := s.SaveAnalysisVersion(p.ID, live); err != nil {
+			return nil, nil, err
+		}
+	}
+	prev, err := s.replacedVersion(p, locale, live.Version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	live.Status = "rejected"
+	stampReview(live, rv)
+	if err := s.SaveAnalysisVersion(p.ID, live); err != nil {
+		return nil, nil, err
+	}
+	if prev != nil {
+		prev.Status = "published"
+		stampReview(prev, rv)
+		prev.Stale = false
+		prev.StaleReason = ""
+		if err := s.SaveAnalysisVersion(p.ID, prev); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	switch {
+	case prev != nil:
+		p.setAnalyses(locale, prev, draft)
+	case draft != nil:
+		p.setAnalyses(locale, draft, nil)
+	}
+	p.UpdatedAt = rv.Time
+	if err := s.SaveProject(p); err != nil {
+		return nil, nil, fmt.Errorf("saving project: %w", err)
+	}
+
+	if err := s.appendReview(p, live, ReviewReject, "published", rv); err != nil {
+		return nil, nil, err
+	}
+	if prev != nil {
+		if err := s.appendReview(p, prev, ReviewRollback, "superseded", rv); err != nil {
+			return nil, nil, err
+		}
+	}
+	return live, prev, nil
+}
+
+// replacedVersion returns the version that was published in locale before
+// current, or nil if the log has none or it is no longer superseded.
+func (s *Store) replacedVersion(p *Project, locale, current string) (*Analysis, error) {
+	records, err := s.LoadReviews()
+	if err != nil {
+		return nil, err
+	}
+	locale = NormalizeLocale(locale)
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if r.Project != p.ID || NormalizeLocale(r.Locale) != locale || r.To != "published" || r.Version == current {
+			continue
+		}
+		a, err := s.LoadAnalysisVersion(p.ID, locale, r.Version)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return nil, nil
+		case err != nil:
+			return nil, err
+		case a.Status != "superseded":
+			return nil, nil
+		}
+		return a, nil
+	}
+	return nil, nil
+}
+
+// supersede marks a previously published version as superseded.
+func (s *Store) supersede(projectID string, a *Analysis) error {
+	if a == nil || a.Status != "published" {
+		return nil
+	}
+	a.Status = "superseded"
+	return s.SaveAnalysisVersion(projectID, a)
+}
+
+// SupersedeDraft marks an unreviewed draft that was replaced by a newer
+// generation as superseded in its version file.
+func (s *Store) SupersedeDraft(projectID string, a *Analysis) error {
+	if a == nil || a.Version == "" || a.Status != "draft" {
+		return nil
+	}
+	a.Status = "superseded"
+	return s.SaveAnalysisVersion(projectID, a)
+}
@@ -0,0 +1,324 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAttachAnalysis(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	p := &Project{}
+	first := &Analysis{Status: "draft", GeneratedAt: now}
+	if replaced := p.AttachAnalysis(first); replaced != nil {
+		t.Errorf("replaced = %+v, want nil", replaced)
+	}
+	second := &Analysis{Status: "draft", GeneratedAt: now}
+	if replaced := p.AttachAnalysis(second); replaced != first {
+		t.Error("re-analyzing a draft should replace it")
+	}
+	if p.Analysis != second || p.Draft != nil {
+		t.Errorf("Analysis/Draft = %p/%p", p.Analysis, p.Draft)
+	}
+
+	second.Status = "published"
+	third := &Analysis{Status: "draft", GeneratedAt: now}
+	if replaced := p.AttachAnalysis(third); replaced != nil {
+		t.Errorf("replaced = %+v, want nil", replaced)
+	}
+	if p.Analysis != second || p.Draft != third {
+		t.Error("draft must be parked next to the published analysis")
+	}
+	if p.PendingAnalysis(DefaultLocale) != third {
+		t.Error("PendingAnalysis should return the parked draft")
+	}
+}
+
+func TestSetAnalysisStatus_PublishDraftSupersedes(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	old := &Analysis{Status: "published", Summary: "old", GeneratedAt: now.Add(-time.Hour)}
+	p := &Project{ID: "a__b", FullName: "a/b", Analysis: old}
+	draft := &Analysis{Status: "draft", Summary: "new", GeneratedAt: now}
+	if err := s.SaveAnalysisVersion(p.ID, draft); err != nil {
+		t.Fatalf("SaveAnalysisVersion: %v", err)
+	}
+	p.AttachAnalysis(draft)
+
+	got, from, err := s.SetAnalysisStatus(p, DefaultLocale, "published", Review{Reviewer: "alice", Time: now})
+	if err != nil {
+		t.Fatalf("SetAnalysisStatus: %v", err)
+	}
+	if got != draft || from != "draft" {
+		t.Errorf("updated %q from %q", got.Summary, from)
+	}
+	if p.Analysis != draft || p.Draft != nil {
+		t.Error("published draft should become the live analysis")
+	}
+
+	versions, err := s.ListAnalysisVersions(p.ID, DefaultLocale)
+	if err != nil {
+		t.Fatalf("ListAnalysisVersions: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("versions = %d, want 2 (legacy analysis backfilled)", len(versions))
+	}
+	if versions[0].Summary != "old" || versions[0].Status != "superseded" {
+		t.Errorf("oldest = %s/%s, want old/superseded", versions[0].Summary, versions[0].Status)
+	}
+	if versions[1].Status != "published" {
+		t.Errorf("newest status = %s, want published", versions[1].Status)
+	}
+
+	loaded, err := s.LoadProject(p.ID)
+	if err != nil {
+		t.Fatalf("LoadProject: %v", err)
+	}
+	if loaded.Analysis.Summary != "new" || loaded.Draft != nil {
+		t.Errorf("saved project = %+v / %+v", loaded.Analysis, loaded.Draft)
+	}
+}
+
+func TestSetAnalysisStatus_RejectDraftKeepsPublished(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	p := &Project{ID: "a__b", FullName: "a/b",
+		Analysis: &Analysis{Status: "published", Summary: "keep", GeneratedAt: now.Add(-time.Hour)},
+		Draft:    &Analysis{Status: "draft", Summary: "bad", GeneratedAt: now},
+	}
+	draft := p.Draft
+	if _, _, err := s.SetAnalysisStatus(p, DefaultLocale, "rejected", Review{Reviewer: "alice", Reason: "功能列表与 README 不符", Time: now}); err != nil {
+		t.Fatalf("SetAnalysisStatus: %v", err)
+	}
+	if p.Draft != nil || p.Analysis.Summary != "keep" || p.Analysis.Status != "published" {
+		t.Errorf("Analysis = %+v, Draft = %+v", p.Analysis, p.Draft)
+	}
+	if draft.ReviewedBy != "alice" || draft.ReviewNote != "功能列表与 README 不符" {
+		t.Errorf("rejected draft reviewed by %q, note %q", draft.ReviewedBy, draft.ReviewNote)
+	}
+
+	r, err := s.LatestReview(p.ID, DefaultLocale)
+	if err != nil {
+		t.Fatalf("LatestReview: %v", err)
+	}
+	want := ReviewRecord{Time: now, Reviewer: "alice", Action: ReviewReject, Project: "a__b", Locale: "zh",
+		Version: draft.Version, From: "draft", To: "rejected", Reason: "功能列表与 README 不符"}
+	if r == nil || *r != want {
+		t.Errorf("review log = %+v, want %+v", r, want)
+	}
+}
+
+func TestRollbackAnalysis(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	v1 := &Analysis{Status: "superseded", Summary: "v1", GeneratedAt: now.Add(-2 * time.Hour)}
+	v2 := &Analysis{Status: "published", Summary: "v2", GeneratedAt: now.Add(-time.Hour)}
+	for _, a := range []*Analysis{v1, v2} {
+		if err := s.SaveAnalysisVersion("a__b", a); err != nil {
+			t.Fatalf("SaveAnalysisVersion: %v", err)
+		}
+	}
+	p := &Project{ID: "a__b", FullName: "a/b", Analysis: v2}
+
+	got, err := s.RollbackAnalysis(p, DefaultLocale, v1.Version, Review{Reviewer: "alice", Time: now})
+	if err != nil {
+		t.Fatalf("RollbackAnalysis: %v", err)
+	}
+	if got.Summary != "v1" || got.Status != "published" || p.Analysis.Summary != "v1" {
+		t.Errorf("rolled back to %+v", got)
+	}
+
+	prev, err := s.LoadAnalysisVersion("a__b", DefaultLocale, v2.Version)
+	if err != nil {
+		t.Fatalf("LoadAnalysisVersion: %v", err)
+	}
+	if prev.Status != "superseded" {
+		t.Errorf("previous version status = %s, want superseded", prev.Status)
+	}
+	if r, _ := s.LatestReview("a__b", DefaultLocale); r == nil || r.Action != ReviewRollback || r.From != "superseded" || r.Version != v1.Version {
+		t.Errorf("review log = %+v, want rollback of v1", r)
+	}
+
+	if _, err := s.RollbackAnalysis(p, DefaultLocale, "19990101T000000Z", Review{Reviewer: "alice", Time: now}); err == nil {
+		t.Error("rollback to unknown version should fail")
+	}
+}
+
+// attachDraft saves a new draft generated at t and attaches it to p.
+func attachDraft(t *testing.T, s *Store, p *Project, summary string, at time.Time) *Analysis {
+	t.Helper()
+	a := &Analysis{Status: "draft", Summary: summary, GeneratedAt: at}
+	if err := s.SaveAnalysisVersion(p.ID, a); err != nil {
+		t.Fatalf("SaveAnalysisVersion: %v", err)
+	}
+	p.AttachAnalysis(a)
+	return a
+}
+
+func TestTakeDownAnalysis_RestoresReplacedVersion(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	p := &Project{ID: "a__b", FullName: "a/b"}
+
+	v1 := attachDraft(t, s, p, "v1", now.Add(-3*time.Hour))
+	if _, _, err := s.SetAnalysisStatus(p, DefaultLocale, "published", Review{Reviewer: "alice", Time: now.Add(-3 * time.Hour)}); err != nil {
+		t.Fatalf("SetAnalysisStatus: %v", err)
+	}
+	v2 := attachDraft(t, s, p, "v2", now.Add(-2*time.Hour))
+	v2.AutoPublished = true
+	if _, _, err := s.SetAnalysisStatus(p, DefaultLocale, "published", Review{Reviewer: "auto-publish", Time: now.Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("SetAnalysisStatus: %v", err)
+	}
+	v3 := attachDraft(t, s, p, "v3", now.Add(-time.Hour))
+
+	got, restored, err := s.TakeDownAnalysis(p, DefaultLocale, Review{Reviewer: "bob", Reason: "定位错误", Time: now})
+	if err != nil {
+		t.Fatalf("TakeDownAnalysis: %v", err)
+	}
+	if got != v2 || got.Status != "rejected" || got.ReviewedBy != "bob" {
+		t.Errorf("taken down = %s/%s by %s", got.Summary, got.Status, got.ReviewedBy)
+	}
+	if restored == nil || restored.Version != v1.Version || restored.Status != "published" {
+		t.Fatalf("restored = %+v, want v1 published", restored)
+	}
+	if p.Analysis.Summary != "v1" || p.Draft != v3 {
+		t.Errorf("live = %s, draft = %v; want v1 with v3 still pending", p.Analysis.Summary, p.Draft)
+	}
+
+	loaded, err := s.LoadAnalysisVersion(p.ID, DefaultLocale, v2.Version)
+	if err != nil {
+		t.Fatalf("LoadAnalysisVersion: %v", err)
+	}
+	if loaded.Status != "rejected" {
+		t.Errorf("saved v2 status = %s, want rejected", loaded.Status)
+	}
+	if r, _ := s.LatestReview(p.ID, DefaultLocale); r == nil || r.Action != ReviewRollback || r.Version != v1.Version {
+		t.Errorf("review log = %+v, want rollback of v1", r)
+	}
+}
+
+func TestTakeDownAnalysis_NoPreviousVersion(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	p := &Project{ID: "a__b", FullName: "a/b"}
+
+	v1 := attachDraft(t, s, p, "v1", now.Add(-2*time.Hour))
+	if _, _, err := s.SetAnalysisStatus(p, DefaultLocale, "published", Review{Reviewer: "auto-publish", Time: now.Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("SetAnalysisStatus: %v", err)
+	}
+	v2 := attachDraft(t, s, p, "v2", now.Add(-time.Hour))
+
+	_, restored, err := s.TakeDownAnalysis(p, DefaultLocale, Review{Reviewer: "bob", Time: now})
+	if err != nil {
+		t.Fatalf("TakeDownAnalysis: %v", err)
+	}
+	if restored != nil {
+		t.Errorf("restored %s, want none", restored.Summary)
+	}
+	if v1.Status != "rejected" || p.Analysis != v2 || p.Draft != nil {
+		t.Errorf("v1 = %s, live = %s; want v1 rejected and the draft pending in its place", v1.Status, p.Analysis.Summary)
+	}
+	if p.PendingAnalysis(DefaultLocale) != v2 {
+		t.Error("draft should still be pending")
+	}
+	if _, _, err := s.TakeDownAnalysis(p, DefaultLocale, Review{Reviewer: "bob", Time: now}); err == nil {
+		t.Error("taking down without a published analysis should fail")
+	}
+}
+
+func TestConfirmAnalysis_KeepsDraft(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	p := &Project{ID: "a__b", FullName: "a/b"}
+
+	v1 := attachDraft(t, s, p, "v1", now.Add(-2*time.Hour))
+	if _, _, err := s.SetAnalysisStatus(p, DefaultLocale, "published", Review{Reviewer: "auto-publish", Time: now.Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("SetAnalysisStatus: %v", err)
+	}
+	v2 := attachDraft(t, s, p, "v2", now.Add(-time.Hour))
+
+	got, err := s.ConfirmAnalysis(p, DefaultLocale, Review{Reviewer: "bob", Time: now})
+	if err != nil {
+		t.Fatalf("ConfirmAnalysis: %v", err)
+	}
+	if got != v1 || v1.Status != "published" || v1.ReviewedBy != "bob" {
+		t.Errorf("confirmed %s/%s by %s, want v1 published by bob", got.Summary, got.Status, got.ReviewedBy)
+	}
+	if v2.Status != "draft" || p.Draft != v2 {
+		t.Error("pending draft should be untouched")
+	}
+	if r, _ := s.LatestReview(p.ID, DefaultLocale); r == nil || r.Action != ReviewPublish || r.Version != v1.Version || r.Reviewer != "bob" {
+		t.Errorf("review log = %+v, want bob's publish of v1", r)
+	}
+}
+
+func TestSaveAnalysisVersion_UniqueVersions(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	a := &Analysis{Status: "draft", GeneratedAt: now}
+	b := &Analysis{Status: "draft", GeneratedAt: now}
+	_ = s.SaveAnalysisVersion("a__b", a)
+	_ = s.SaveAnalysisVersion("a__b", b)
+	if a.Version != "20261018T120000Z" || b.Version != "20261018T120000Z-2" {
+		t.Errorf("versions = %q, %q", a.Version, b.Version)
+	}
+}
+
+func TestLocalizedAnalysis_ReviewedSeparately(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	zh := &Analysis{Status: "published", Summary: "中文", GeneratedAt: now.Add(-time.Hour)}
+	p := &Project{ID: "a__b", FullName: "a/b", Analysis: zh}
+
+	en := &Analysis{Locale: "en", Status: "draft", Summary: "English", GeneratedAt: now}
+	if err := s.SaveAnalysisVersion(p.ID, en); err != nil {
+		t.Fatalf("SaveAnalysisVersion: %v", err)
+	}
+	if replaced := p.AttachAnalysis(en); replaced != nil {
+		t.Errorf("replaced = %+v, want nil", replaced)
+	}
+	if p.Analysis != zh || p.Draft != nil {
+		t.Error("attaching an en analysis must not touch the zh pair")
+	}
+	if p.AnalysisFor("EN") != en || p.PendingAnalysis("en") != en || p.PendingAnalysis(DefaultLocale) != nil {
+		t.Error("en analysis should be pending in its own locale only")
+	}
+	if got := p.Locales(); len(got) != 2 || got[0] != "zh" || got[1] != "en" {
+		t.Errorf("Locales = %v, want [zh en]", got)
+	}
+
+	if _, _, err := s.SetAnalysisStatus(p, "en", "published", Review{Reviewer: "alice", Time: now}); err != nil {
+		t.Fatalf("SetAnalysisStatus: %v", err)
+	}
+	if p.PublishedAnalysis("en") != en || zh.Status != "published" || zh.ReviewedAt != nil {
+		t.Errorf("en = %s, zh = %s (reviewed %v)", en.Status, zh.Status, zh.ReviewedAt)
+	}
+
+	if v, _ := s.ListAnalysisVersions(p.ID, "en"); len(v) != 1 || v[0].Summary != "English" {
+		t.Errorf("en versions = %+v", v)
+	}
+	if v, _ := s.ListAnalysisVersions(p.ID, DefaultLocale); len(v) != 0 {
+		t.Errorf("zh versions = %+v, want none written by an en review", v)
+	}
+
+	loaded, err := s.LoadProject(p.ID)
+	if err != nil {
+		t.Fatalf("LoadProject: %v", err)
+	}
+	if a := loaded.PublishedAnalysis("en"); a == nil || a.Summary != "English" {
+		t.Errorf("saved en analysis = %+v", a)
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	for in, want := range map[string]string{"": "zh", "zh": "zh", "zh-CN": "zh", "EN": "en", " en-US ": "en-us"} {
+		if got := NormalizeLocale(in); got != want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
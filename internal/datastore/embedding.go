@@ -0,0 +1,98 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Embedding is a project's embedding vector, stored in
+// data/embeddings/{project_id}.json. InputHash covers the model and the
+// embedded text so unchanged projects are not re-embedded.
+type Embedding struct {
+	ProjectID  string    `json:"project_id"`
+	Model      string    `json:"model"`
+	InputHash  string    `json:"input_hash"`
+	Vector     []float32 `json:"vector"`
+	EmbeddedAt time.Time `json:"embedded_at"`
+}
+
+func (s *Store) embeddingsDir() string {
+	return filepath.Join(s.dataDir, "embeddings")
+}
+
+// LoadEmbedding reads a project's embedding. It returns nil without an
+// error when the project has not been embedded yet.
+func (s *Store) LoadEmbedding(projectID string) (*Embedding, error) {
+	data, err := os.ReadFile(filepath.Join(s.embeddingsDir(), projectID+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading embedding %s: %w", projectID, err)
+	}
+
+	var e Embedding
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("parsing embedding %s: %w", projectID, err)
+	}
+	return &e, nil
+}
+
+// SaveEmbedding writes a project's embedding atomically.
+func (s *Store) SaveEmbedding(e *Embedding) error {
+	dir := s.embeddingsDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating embeddings dir: %w", err)
+	}
+
+	// Compact: vectors are long and never hand-edited.
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshaling embedding %s: %w", e.ProjectID, err)
+	}
+	data = append(data, '\n')
+
+	path := filepath.Join(dir, e.ProjectID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+	return nil
+}
+
+// ListEmbeddings reads all stored embeddings, keyed by project ID.
+// Unreadable files are skipped with a warning.
+func (s *Store) ListEmbeddings() (map[string]*Embedding, error) {
+	entries, err := os.ReadDir(s.embeddingsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("listing embeddings dir: %w", err)
+	}
+
+	out := make(map[string]*Embedding, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		id := strings.TrimSuffix(e.Name(), ".json")
+		emb, err := s.LoadEmbedding(id)
+		if err != nil {
+			s.log.Warn("跳过无效 embedding 文件", zap.String("file", e.Name()), zap.Error(err))
+			continue
+		}
+		out[id] = emb
+	}
+	return out, nil
+}
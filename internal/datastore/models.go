@@ -27,10 +27,19 @@ type Project struct {
 	Rank     *int    `json:"rank,omitempty"`
 	Category *string `json:"category,omitempty"` // primary category slug
 
+	TechStack  *TechStack      `json:"tech_stack,omitempty"` // detected from file tree and manifests
 	Trending   *Trending       `json:"trending,omitempty"`
-	Analysis   *Analysis       `json:"analysis,omitempty"`
+	Analysis   *Analysis       `json:"analysis,omitempty"` // live version: published, else latest
+	Draft      *Analysis       `json:"draft,omitempty"`    // unreviewed version pending while one is published
 	Categories []CategoryMatch `json:"categories,omitempty"`
 
+	// Localized holds analyses in locales other than DefaultLocale, keyed
+	// by locale (e.g. "en"). Analysis and Draft above are the DefaultLocale pair.
+	Localized map[string]*LocalizedAnalysis `json:"localized,omitempty"`
+
+	// Similar lists the nearest tracked projects by embedding, best first.
+	Similar []SimilarProject `json:"similar,omitempty"`
+
 	FirstSeenAt time.Time `json:"first_seen_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -43,9 +52,38 @@ type Trending struct {
 	LastSeenTrending *string `json:"last_seen_trending,omitempty"` // YYYY-MM-DD
 }
 
-// Analysis holds LLM-generated Chinese project analysis.
+// TechStack is detected from a repository's top-level files and dependency
+// manifests (go.mod, pyproject.toml, requirements.txt, package.json,
+// Cargo.toml), independent of the LLM.
+type TechStack struct {
+	Languages    []string  `json:"languages,omitempty"`    // e.g. Python, TypeScript
+	Frameworks   []string  `json:"frameworks,omitempty"`   // well-known libraries, e.g. PyTorch, LangChain
+	Dependencies []string  `json:"dependencies,omitempty"` // direct dependency names, capped
+	Manifests    []string  `json:"manifests,omitempty"`    // manifest files that were parsed
+	DetectedAt   time.Time `json:"detected_at"`
+}
+
+// SimilarProject is a tracked project close to another one in embedding
+// space, derived by tishi embed.
+type SimilarProject struct {
+	ProjectID string  `json:"project_id"` // owner__repo
+	FullName  string  `json:"full_name"`  // owner/repo
+	Score     float64 `json:"score"`      // cosine similarity, 0-1
+}
+
+// LocalizedAnalysis is the live/draft pair for one non-default locale,
+// reviewed independently of the Chinese analysis.
+type LocalizedAnalysis struct {
+	Analysis *Analysis `json:"analysis,omitempty"`
+	Draft    *Analysis `json:"draft,omitempty"`
+}
+
+// Analysis holds an LLM-generated project analysis, Chinese unless Locale
+// says otherwise. Every version is also kept under data/analyses/{project_id}/.
 type Analysis struct {
-	Status      string            `json:"status"`                // draft | published | rejected
+	Version     string            `json:"version,omitempty"`     // e.g. 20261018T120000Z, assigned on first save
+	Locale      string            `json:"locale,omitempty"`      // empty = DefaultLocale (zh)
+	Status      string            `json:"status"`                // draft | published | rejected | superseded
 	Model       string            `json:"model"`                 // e.g. deepseek-chat
 	Summary     string            `json:"summary"`               // ≤50 chars
 	Positioning string            `json:"positioning,omitempty"` // ~200 chars
@@ -57,7 +95,42 @@ type Analysis struct {
 	Ecosystem   string            `json:"ecosystem,omitempty"`
 	GeneratedAt time.Time         `json:"generated_at"`
 	ReviewedAt  *time.Time        `json:"reviewed_at,omitempty"`
-	TokenUsage  *int              `json:"token_usage,omitempty"`
+	ReviewedBy  string            `json:"reviewed_by,omitempty"` // reviewer of the last decision
+	ReviewNote  string            `json:"review_note,omitempty"` // reason or comment given with it
+	TokenUsage  *int              `json:"token_usage,omitempty"` // total over the call and its repairs
+
+	PromptTokens     *int `json:"prompt_tokens,omitempty"`     // input part of TokenUsage
+	CompletionTokens *int `json:"completion_tokens,omitempty"` // output part of TokenUsage
+
+	PromptName    string `json:"prompt_name,omitempty"`     // prompt variant, e.g. default or agent
+	PromptVersion string `json:"prompt_version,omitempty"`  // version of that variant, e.g. v3
+	InputHash     string `json:"input_hash,omitempty"`      // sha256 of README + description + topics + prompt version + model
+	TechStackHash string `json:"tech_stack_hash,omitempty"` // sha256 of the detected tech stack in its prompt; empty = none
+	Stale         bool   `json:"stale,omitempty"`           // published, but inputs changed or max age passed
+	StaleReason   string `json:"stale_reason,omitempty"`    // why it was flagged stale
+
+	Validation *Validation `json:"validation,omitempty"` // output checks, shown to reviewers
+
+	HumanEdited bool       `json:"human_edited,omitempty"` // changed by a reviewer with tishi review --edit
+	EditedAt    *time.Time `json:"edited_at,omitempty"`    // last human edit
+	EditedBy    string     `json:"edited_by,omitempty"`    // reviewer of the last edit
+
+	AutoPublished bool `json:"auto_published,omitempty"` // published by the auto-publish policy, not a reviewer
+
+	ReviewFeedback string `json:"review_feedback,omitempty"` // rejection reason this generation was asked to address
+}
+
+// Validation records how LLM output fared against the field limits above.
+type Validation struct {
+	Issues  []ValidationIssue `json:"issues,omitempty"`  // remaining after repair
+	Repairs int               `json:"repairs,omitempty"` // repair round-trips made
+}
+
+// ValidationIssue is a single problem found in an analysis field.
+type ValidationIssue struct {
+	Field   string `json:"field"`   // e.g. summary, features[1].desc
+	Level   string `json:"level"`   // error | warning
+	Message string `json:"message"` // Chinese, shown in tishi review
 }
 
 // Feature describes a single project feature.
@@ -66,12 +139,26 @@ type Feature struct {
 	Desc string `json:"desc"`
 }
 
-// ComparisonEntry compares with a competing project.
+// ComparisonEntry compares with a competing project. Project is the name
+// as written by the LLM; the remaining fields are filled in when the name
+// is resolved against tracked projects and GitHub.
 type ComparisonEntry struct {
-	Project string `json:"project"`
-	Diff    string `json:"diff"`
+	Project   string `json:"project"`
+	Diff      string `json:"diff"`
+	ProjectID string `json:"project_id,omitempty"` // tracked project, owner__repo
+	Repo      string `json:"repo,omitempty"`       // GitHub owner/repo it resolved to
+	Status    string `json:"status,omitempty"`     // tracked | github | suspicious | unresolved; empty = not checked
+	Note      string `json:"note,omitempty"`       // why suspicious or unresolved
 }
 
+// ComparisonEntry.Status values.
+const (
+	ComparisonTracked    = "tracked"    // a project tishi tracks
+	ComparisonGitHub     = "github"     // an existing GitHub repository
+	ComparisonSuspicious = "suspicious" // only a weak match, needs review
+	ComparisonUnresolved = "unresolved" // no such repository found
+)
+
 // CategoryMatch records a matched AI category with confidence score.
 type CategoryMatch struct {
 	Slug       string  `json:"slug"`
@@ -119,10 +206,37 @@ type Post struct {
 	Title         string     `json:"title"`
 	Content       string     `json:"content"` // Markdown
 	PostType      string     `json:"post_type"`
+	Locale        string     `json:"locale,omitempty"` // empty = DefaultLocale (zh)
 	CoverImageURL *string    `json:"cover_image_url,omitempty"`
 	PublishedAt   *time.Time `json:"published_at,omitempty"`
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+	Narrative     *Narrative `json:"narrative,omitempty"` // weekly and monthly only
+}
+
+// Narrative is the LLM-written editorial intro of a weekly or monthly
+// report. Like an analysis it starts as a draft; the post is not published
+// until a reviewer approves it.
+type Narrative struct {
+	Status      string               `json:"status"` // draft | published | rejected
+	Model       string               `json:"model"`
+	Intro       string               `json:"intro"` // 300–500 chars
+	Highlights  []NarrativeHighlight `json:"highlights,omitempty"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	ReviewedAt  *time.Time           `json:"reviewed_at,omitempty"`
+	ReviewedBy  string               `json:"reviewed_by,omitempty"`
+	ReviewNote  string               `json:"review_note,omitempty"`
+	TokenUsage  *int                 `json:"token_usage,omitempty"`
+
+	Validation *Validation `json:"validation,omitempty"` // output checks, shown to reviewers
+
+	ReviewFeedback string `json:"review_feedback,omitempty"` // rejection reason this generation was asked to address
+}
+
+// NarrativeHighlight is the editor's take on one highlighted project.
+type NarrativeHighlight struct {
+	Project string `json:"project"` // owner/repo
+	Take    string `json:"take"`
 }
 
 // Category represents an AI sub-category from data/categories.json.
@@ -0,0 +1,212 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Review log actions.
+const (
+	ReviewPublish  = "publish"
+	ReviewReject   = "reject"
+	ReviewEdit     = "edit"
+	ReviewRollback = "rollback"
+)
+
+// Review identifies a review decision: who made it, when, and why.
+type Review struct {
+	Reviewer string
+	Reason   string // optional reason or comment
+	Filter   string // bulk review filter that selected the analysis
+	Time     time.Time
+}
+
+// ReviewRecord is one entry of the append-only review log
+// data/reviews.jsonl.
+type ReviewRecord struct {
+	Time     time.Time `json:"time"`
+	Reviewer string    `json:"reviewer"`
+	Action   string    `json:"action"` // publish | reject | edit | rollback
+	Project  string    `json:"project"`
+	Post     string    `json:"post,omitempty"` // slug, for narrative decisions
+	Locale   string    `json:"locale"`
+	Version  string    `json:"version"`
+	From     string    `json:"from,omitempty"` // status before the decision
+	To       string    `json:"to,omitempty"`   // status after it
+	Reason   string    `json:"reason,omitempty"`
+	Filter   string    `json:"filter,omitempty"` // set for bulk decisions
+}
+
+// reviewsMu serializes review log appends.
+var reviewsMu sync.Mutex
+
+func (s *Store) reviewsPath() string {
+	return filepath.Join(s.dataDir, "reviews.jsonl")
+}
+
+// AppendReview appends a record to data/reviews.jsonl.
+func (s *Store) AppendReview(r *ReviewRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshaling review record: %w", err)
+	}
+	line = append(line, '\n')
+
+	reviewsMu.Lock()
+	defer reviewsMu.Unlock()
+
+	if err := os.MkdirAll(s.dataDir, 0o755); err != nil {
+		return fmt.Errorf("creating data dir: %w", err)
+	}
+	f, err := os.OpenFile(s.reviewsPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening review log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("writing review log: %w", err)
+	}
+	return nil
+}
+
+// LoadReviews reads the review log, oldest first. A missing log yields no
+// records and no error.
+func (s *Store) LoadReviews() ([]*ReviewRecord, error) {
+	f, err := os.Open(s.reviewsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening review log: %w", err)
+	}
+	defer f.Close()
+
+	var records []*ReviewRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var r ReviewRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			s.log.Warn("跳过无效审核记录", zap.Error(err))
+			continue
+		}
+		records = append(records, &r)
+	}
+
+	return records, scanner.Err()
+}
+
+// LatestReview returns the last review decision (publish, reject or
+// rollback) on a project's analysis in locale, or nil if there is none.
+// Edits are not decisions and are skipped.
+func (s *Store) LatestReview(projectID, locale string) (*ReviewRecord, error) {
+	records, err := s.LoadReviews()
+	if err != nil {
+		return nil, err
+	}
+	locale = NormalizeLocale(locale)
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if r.Project == projectID && NormalizeLocale(r.Locale) == locale && r.Action != ReviewEdit {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+// appendReview logs a decision on a, wrapping failures for callers whose
+// change was already saved.
+func (s *Store) appendReview(p *Project, a *Analysis, action, from string, rv Review) error {
+	err := s.AppendReview(&ReviewRecord{
+		Time:     rv.Time,
+		Reviewer: rv.Reviewer,
+		Action:   action,
+		Project:  p.ID,
+		Locale:   a.EffectiveLocale(),
+		Version:  a.Version,
+		From:     from,
+		To:       a.Status,
+		Reason:   rv.Reason,
+		Filter:   rv.Filter,
+	})
+	if err != nil {
+		return fmt.Errorf("已保存，但审核日志写入失败: %w", err)
+	}
+	return nil
+}
+
+// SaveEditedAnalysis saves a reviewer's edit of a, one of p's analyses,
+// to its version file and the project, and logs it.
+func (s *Store) SaveEditedAnalysis(p *Project, a *Analysis, rv Review) error {
+	a.EditedBy = rv.Reviewer
+	if err := s.SaveAnalysisVersion(p.ID, a); err != nil {
+		return err
+	}
+	p.UpdatedAt = rv.Time
+	if err := s.SaveProject(p); err != nil {
+		return fmt.Errorf("saving project: %w", err)
+	}
+	return s.appendReview(p, a, ReviewEdit, "", rv)
+}
+
+// SetNarrativeStatus publishes or rejects post's draft narrative and logs
+// the decision. Publishing the narrative publishes the post; the post's
+// content already includes the draft, so a rejected narrative keeps it
+// unpublished until it is regenerated. It returns the status before the
+// decision.
+func (s *Store) SetNarrativeStatus(post *Post, status string, rv Review) (string, error) {
+	n := post.Narrative
+	if n == nil {
+		return "", fmt.Errorf("文章 %s 没有编辑导语", post.Slug)
+	}
+	if n.Status != "draft" {
+		return "", fmt.Errorf("文章 %s 的编辑导语已是 %s，只能审核草稿", post.Slug, n.Status)
+	}
+	oldStatus := n.Status
+	n.Status = status
+	t := rv.Time
+	n.ReviewedAt = &t
+	n.ReviewedBy = rv.Reviewer
+	n.ReviewNote = rv.Reason
+
+	switch {
+	case status == "published" && post.PublishedAt == nil:
+		post.PublishedAt = &t
+	case status == "rejected":
+		post.PublishedAt = nil
+	}
+	post.UpdatedAt = &t
+	if err := s.SavePost(post); err != nil {
+		return "", fmt.Errorf("saving post: %w", err)
+	}
+
+	action := ReviewPublish
+	if status == "rejected" {
+		action = ReviewReject
+	}
+	err := s.AppendReview(&ReviewRecord{
+		Time:     rv.Time,
+		Reviewer: rv.Reviewer,
+		Action:   action,
+		Post:     post.Slug,
+		Locale:   post.Locale,
+		From:     oldStatus,
+		To:       status,
+		Reason:   rv.Reason,
+	})
+	if err != nil {
+		return oldStatus, fmt.Errorf("已保存，但审核日志写入失败: %w", err)
+	}
+	return oldStatus, nil
+}
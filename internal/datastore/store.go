@@ -230,8 +230,17 @@ func (s *Store) LoadRanking(date string) (*Ranking, error) {
 
 // LoadLatestRanking finds and reads the most recent ranking file.
 func (s *Store) LoadLatestRanking() (*Ranking, error) {
-	dir := s.rankingsDir()
-	entries, err := os.ReadDir(dir)
+	dates, err := s.ListRankingDates()
+	if err != nil || len(dates) == 0 {
+		return nil, err
+	}
+	return s.LoadRanking(dates[len(dates)-1])
+}
+
+// ListRankingDates returns the dates (YYYY-MM-DD) that have a ranking
+// file, oldest first.
+func (s *Store) ListRankingDates() ([]string, error) {
+	entries, err := os.ReadDir(s.rankingsDir())
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -239,19 +248,14 @@ func (s *Store) LoadLatestRanking() (*Ranking, error) {
 		return nil, fmt.Errorf("listing rankings dir: %w", err)
 	}
 
-	// JSON files sorted by name (date) descending
-	var files []string
+	var dates []string
 	for _, e := range entries {
 		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
-			files = append(files, strings.TrimSuffix(e.Name(), ".json"))
+			dates = append(dates, strings.TrimSuffix(e.Name(), ".json"))
 		}
 	}
-	if len(files) == 0 {
-		return nil, nil
-	}
-	sort.Sort(sort.Reverse(sort.StringSlice(files)))
-
-	return s.LoadRanking(files[0])
+	sort.Strings(dates)
+	return dates, nil
 }
 
 // --- Posts ---
@@ -286,6 +290,22 @@ func (s *Store) SavePost(p *Post) error {
 	return nil
 }
 
+// LoadPost reads a post by slug, returning nil if it does not exist.
+func (s *Store) LoadPost(slug string) (*Post, error) {
+	data, err := os.ReadFile(filepath.Join(s.postsDir(), slug+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading post %s: %w", slug, err)
+	}
+	var p Post
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing post %s: %w", slug, err)
+	}
+	return &p, nil
+}
+
 // ListPosts reads all post JSON files.
 func (s *Store) ListPosts() ([]*Post, error) {
 	dir := s.postsDir()
@@ -319,6 +339,32 @@ func (s *Store) ListPosts() ([]*Post, error) {
 	return posts, nil
 }
 
+// --- README cache ---
+
+// READMECachePath returns data/cache/readme/{id}.md, the preprocessed
+// README last sent to the LLM. Only real model calls write it.
+func (s *Store) READMECachePath(projectID string) string {
+	return filepath.Join(s.dataDir, "cache", "readme", projectID+".md")
+}
+
+// SaveREADMECache writes the preprocessed README for a project.
+func (s *Store) SaveREADMECache(projectID, text string) error {
+	path := s.READMECachePath(projectID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating readme cache dir: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(text+"\n"), 0o644); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+	return nil
+}
+
 // --- Categories ---
 
 // LoadCategories reads data/categories.json.
@@ -335,3 +381,37 @@ func (s *Store) LoadCategories() ([]Category, error) {
 	}
 	return cats, nil
 }
+
+// SaveCategories writes data/categories.json atomically, keeping the
+// 4-space indentation used by the hand-maintained file.
+func (s *Store) SaveCategories(cats []Category) error {
+	for i := range cats {
+		if cats[i].ProjectIDs == nil {
+			cats[i].ProjectIDs = []string{}
+		}
+		if cats[i].Keywords.Topics == nil {
+			cats[i].Keywords.Topics = []string{}
+		}
+		if cats[i].Keywords.Description == nil {
+			cats[i].Keywords.Description = []string{}
+		}
+	}
+
+	data, err := json.MarshalIndent(cats, "", "    ")
+	if err != nil {
+		return fmt.Errorf("marshaling categories: %w", err)
+	}
+	data = append(data, '\n')
+
+	path := filepath.Join(s.dataDir, "categories.json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+
+	return nil
+}
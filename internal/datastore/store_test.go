@@ -177,6 +177,35 @@ func TestLoadSnapshots_NoFile(t *testing.T) {
 	}
 }
 
+func TestAppendAndLoadUsage(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+
+	oct := time.Date(2026, 10, 31, 23, 0, 0, 0, time.UTC)
+	recs := []*UsageRecord{
+		{Time: oct, Command: "analyze", Project: "a/b", Model: "deepseek-chat", PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120, CostCNY: 0.00036},
+		{Time: oct.Add(2 * time.Hour), Command: "embed", Model: "text-embedding-v3", PromptTokens: 50, TotalTokens: 50, Unpriced: true},
+	}
+	for _, r := range recs {
+		if err := s.AppendUsage(r); err != nil {
+			t.Fatalf("AppendUsage: %v", err)
+		}
+	}
+
+	got, err := s.LoadUsage("2026-10")
+	if err != nil {
+		t.Fatalf("LoadUsage: %v", err)
+	}
+	if len(got) != 1 || got[0].Project != "a/b" || got[0].CompletionTokens != 20 {
+		t.Errorf("2026-10 = %+v, want the analyze call only", got)
+	}
+	if got, _ := s.LoadUsage("2026-11"); len(got) != 1 || !got[0].Unpriced {
+		t.Errorf("2026-11 = %+v, want the embed call", got)
+	}
+	if got, err := s.LoadUsage("2025-01"); err != nil || got != nil {
+		t.Errorf("missing month = %v, %v", got, err)
+	}
+}
+
 func TestSaveAndLoadRanking(t *testing.T) {
 	dir := t.TempDir()
 	s := NewStore(dir, testLogger())
@@ -267,6 +296,54 @@ func TestSaveAndListPosts(t *testing.T) {
 	}
 }
 
+func TestSetNarrativeStatus(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+	post := &Post{Slug: "ai-weekly-2026-w41", PostType: "weekly", CreatedAt: time.Now().UTC(),
+		Narrative: &Narrative{Status: "draft", Intro: "导语"}}
+	if err := s.SavePost(post); err != nil {
+		t.Fatalf("SavePost: %v", err)
+	}
+
+	rv := Review{Reviewer: "alice", Reason: "可以", Time: time.Now().UTC()}
+	from, err := s.SetNarrativeStatus(post, "published", rv)
+	if err != nil || from != "draft" {
+		t.Fatalf("SetNarrativeStatus = %q, %v", from, err)
+	}
+	got, _ := s.LoadPost(post.Slug)
+	if got.PublishedAt == nil || got.Narrative.Status != "published" || got.Narrative.ReviewedBy != "alice" {
+		t.Errorf("post = %+v, narrative = %+v", got, got.Narrative)
+	}
+	records, _ := s.LoadReviews()
+	if len(records) != 1 || records[0].Post != post.Slug || records[0].Action != ReviewPublish || records[0].From != "draft" {
+		t.Errorf("review log = %+v", records)
+	}
+
+	if _, err := s.SetNarrativeStatus(got, "rejected", rv); err == nil {
+		t.Error("expected error for a narrative that is no longer a draft")
+	}
+	if _, err := s.SetNarrativeStatus(&Post{Slug: "plain"}, "published", rv); err == nil {
+		t.Error("expected error for a post without narrative")
+	}
+}
+
+func TestSetNarrativeStatus_RejectUnpublishes(t *testing.T) {
+	s := NewStore(t.TempDir(), testLogger())
+	now := time.Now().UTC()
+	post := &Post{Slug: "ai-weekly-2026-w41", PostType: "weekly", CreatedAt: now, PublishedAt: &now,
+		Content: "导语\n\n正文", Narrative: &Narrative{Status: "draft", Intro: "导语"}}
+	if err := s.SavePost(post); err != nil {
+		t.Fatalf("SavePost: %v", err)
+	}
+
+	if _, err := s.SetNarrativeStatus(post, "rejected", Review{Reviewer: "alice", Reason: "语气夸张", Time: now}); err != nil {
+		t.Fatalf("SetNarrativeStatus: %v", err)
+	}
+	got, _ := s.LoadPost(post.Slug)
+	if got.PublishedAt != nil || got.Narrative.Status != "rejected" {
+		t.Errorf("post published at %v, narrative %s; want unpublished until regenerated", got.PublishedAt, got.Narrative.Status)
+	}
+}
+
 func TestLoadCategories(t *testing.T) {
 	dir := t.TempDir()
 	s := NewStore(dir, testLogger())
@@ -292,6 +369,28 @@ func TestLoadCategories(t *testing.T) {
 	}
 }
 
+func TestSaveCategories_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir, testLogger())
+
+	cats := []Category{{Slug: "llm", Name: "LLM", SortOrder: 1}}
+	if err := s.SaveCategories(cats); err != nil {
+		t.Fatalf("SaveCategories: %v", err)
+	}
+
+	loaded, err := s.LoadCategories()
+	if err != nil {
+		t.Fatalf("LoadCategories: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].Slug != "llm" {
+		t.Fatalf("loaded = %+v", loaded)
+	}
+	// nil slices are written as [] so the frontend can rely on arrays
+	if loaded[0].ProjectIDs == nil || loaded[0].Keywords.Topics == nil {
+		t.Error("expected empty arrays, got null")
+	}
+}
+
 func TestDataDir(t *testing.T) {
 	dir := t.TempDir()
 	s := NewStore(dir, testLogger())
@@ -0,0 +1,101 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// UsageRecord is one LLM API call in the append-only ledger
+// data/usage/{YYYY-MM}.jsonl. Cost is priced when the call is made, so
+// later price changes don't rewrite history.
+type UsageRecord struct {
+	Time             time.Time `json:"time"`
+	Command          string    `json:"command"`           // e.g. analyze, embed
+	Project          string    `json:"project,omitempty"` // owner/repo, empty for batch calls
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	CostCNY          float64   `json:"cost_cny"`
+	Unpriced         bool      `json:"unpriced,omitempty"` // no price for the model, cost counted as 0
+}
+
+// UsageMonth is the ledger file a record belongs to, e.g. 2026-10.
+func (r *UsageRecord) UsageMonth() string {
+	return r.Time.UTC().Format("2006-01")
+}
+
+// usageMu serializes ledger appends from concurrent analyzer workers.
+var usageMu sync.Mutex
+
+func (s *Store) usageDir() string {
+	return filepath.Join(s.dataDir, "usage")
+}
+
+// AppendUsage appends a record to data/usage/{month}.jsonl.
+func (s *Store) AppendUsage(r *UsageRecord) error {
+	dir := s.usageDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating usage dir: %w", err)
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshaling usage record: %w", err)
+	}
+	line = append(line, '\n')
+
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	path := filepath.Join(dir, r.UsageMonth()+".jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening usage file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("writing usage line: %w", err)
+	}
+	return nil
+}
+
+// LoadUsage reads all ledger records for a month (YYYY-MM). A month
+// without a ledger yields no records and no error.
+func (s *Store) LoadUsage(month string) ([]*UsageRecord, error) {
+	path := filepath.Join(s.usageDir(), month+".jsonl")
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening usage file: %w", err)
+	}
+	defer f.Close()
+
+	var records []*UsageRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var r UsageRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			s.log.Warn("跳过无效用量记录", zap.String("month", month), zap.Error(err))
+			continue
+		}
+		records = append(records, &r)
+	}
+
+	return records, scanner.Err()
+}
@@ -3,10 +3,10 @@
 package generator
 
 import (
+	"context"
 	"fmt"
-	"sort"
+	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
 	"go.uber.org/zap"
@@ -28,19 +28,153 @@ func New(store *datastore.Store, log *zap.Logger) *Generator {
 // RunOptions configures a generation run.
 type RunOptions struct {
 	ProjectID string // for spotlight only
+	Week      string // ISO week, e.g. 2026-W41, for weekly only; empty = current week
+	Month     string // YYYY-MM, for monthly only; empty = previous month
 	DryRun    bool
+	Locales   []string // one post per locale; empty = zh
+
+	TemplatesDir string // {type}.tmpl and {locale}/{type}.tmpl overrides; empty = built-in only
+	Template     string // template file used instead, for every locale
+
+	// Newcomers only.
+	Since        string // look-back window, e.g. 3d; empty = DefaultNewcomersSince
+	MinNewcomers int    // skip the post when fewer projects qualify
+
+	// Weekly and monthly only: writes a draft editorial narrative for the
+	// Chinese post, which then waits for review. nil = keep the previous one.
+	Narrator Narrator
+	// Replace a draft narrative that is still awaiting review.
+	RegenerateNarrative bool
 }
 
-// Run generates posts of the given type. Supported: weekly, spotlight.
-func (g *Generator) Run(postType string, opts RunOptions) error {
+// Run generates posts of the given type. Supported: weekly, monthly,
+// spotlight, newcomers.
+func (g *Generator) Run(ctx context.Context, postType string, opts RunOptions) error {
+	var gen func(context.Context, RunOptions, string) error
 	switch postType {
 	case "weekly":
-		return g.generateWeekly(opts)
+		gen = g.generateWeekly
+	case "monthly":
+		gen = g.generateMonthly
 	case "spotlight":
-		return g.generateSpotlight(opts)
+		gen = g.generateSpotlight
+	case "newcomers":
+		gen = g.generateNewcomers
 	default:
-		return fmt.Errorf("unsupported post type: %s (use weekly or spotlight)", postType)
+		return fmt.Errorf("unsupported post type: %s (use weekly, monthly, spotlight or newcomers)", postType)
+	}
+
+	locales, err := runLocales(opts)
+	if err != nil {
+		return err
+	}
+	for _, locale := range locales {
+		if err := gen(ctx, opts, locale); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runLocales normalizes and validates opts.Locales, defaulting to zh.
+func runLocales(opts RunOptions) ([]string, error) {
+	if len(opts.Locales) == 0 {
+		return []string{datastore.DefaultLocale}, nil
+	}
+	var locales []string
+	for _, l := range opts.Locales {
+		locale := datastore.NormalizeLocale(l)
+		if _, ok := postTexts[locale]; !ok {
+			return nil, fmt.Errorf("unsupported locale: %s (use zh or en)", l)
+		}
+		locales = append(locales, locale)
+	}
+	return locales, nil
+}
+
+// postText is the locale-specific wording of generated posts.
+type postText struct {
+	templates      map[string]string // built-in template per post type
+	weeklyTitle    string            // week number, start date, end date
+	monthlyTitle   string            // time layout applied to the month
+	spotlightTitle string            // project full name
+	newcomersTitle string            // start date, end date
+}
+
+var postTexts = map[string]postText{
+	datastore.DefaultLocale: {
+		templates: map[string]string{
+			"weekly":    weeklyTemplate,
+			"monthly":   monthlyTemplate,
+			"spotlight": spotlightTemplate,
+			"newcomers": newcomersTemplate,
+		},
+		weeklyTitle:    "AI 开源周报 #%d | %s ~ %s",
+		monthlyTitle:   "AI 开源月报 | 2006 年 1 月",
+		spotlightTitle: "项目深度解读：%s",
+		newcomersTitle: "新项目速递 | %s ~ %s",
+	},
+	"en": {
+		templates: map[string]string{
+			"weekly":    weeklyTemplateEN,
+			"monthly":   monthlyTemplateEN,
+			"spotlight": spotlightTemplateEN,
+			"newcomers": newcomersTemplateEN,
+		},
+		weeklyTitle:    "AI Open Source Weekly #%d | %s ~ %s",
+		monthlyTitle:   "AI Open Source Monthly | January 2006",
+		spotlightTitle: "Project Deep Dive: %s",
+		newcomersTitle: "New Project Flash | %s ~ %s",
+	},
+}
+
+// localizeSlug suffixes slugs of posts outside the default locale, so
+// both languages of the same post can coexist.
+func localizeSlug(slug, locale string) string {
+	if locale == datastore.DefaultLocale {
+		return slug
+	}
+	return slug + "-" + locale
+}
+
+// postLocale is the Post.Locale for locale: empty for the default locale.
+func postLocale(locale string) string {
+	if locale == datastore.DefaultLocale {
+		return ""
+	}
+	return locale
+}
+
+// savePost writes a generated post. Regenerating a post keeps its
+// original creation and publication time and records the update. A post
+// whose narrative is a draft is saved unpublished; approving the
+// narrative publishes it.
+func (g *Generator) savePost(post *datastore.Post, now time.Time) error {
+	prev, err := g.store.LoadPost(post.Slug)
+	if err != nil {
+		return fmt.Errorf("loading post: %w", err)
+	}
+	held := post.Narrative != nil && post.Narrative.Status == "draft"
+	post.CreatedAt = now
+	if !held {
+		post.PublishedAt = &now
+	}
+	if prev != nil {
+		if !prev.CreatedAt.IsZero() {
+			post.CreatedAt = prev.CreatedAt
+		}
+		if prev.PublishedAt != nil && !held {
+			post.PublishedAt = prev.PublishedAt
+		}
+		if prev.PublishedAt != nil && held {
+			g.log.Warn("编辑导语待审核，文章暂时撤下", zap.String("slug", post.Slug))
+		}
+		post.UpdatedAt = &now
+	}
+	if err := g.store.SavePost(post); err != nil {
+		return fmt.Errorf("saving post: %w", err)
 	}
+	return nil
 }
 
 // ── Weekly Report ──────────────────────────────────────────────
@@ -48,57 +182,132 @@ func (g *Generator) Run(postType string, opts RunOptions) error {
 type weeklyData struct {
 	Year          int
 	WeekNum       int
-	StartDate     string
-	EndDate       string
-	TotalProjects int
+	StartDate     string // Monday
+	EndDate       string // Sunday
+	Days          int    // rankings in the week
+	TotalProjects int    // on the week's last ranking
 	NewEntries    int
-	TopGainers    []weeklyProject
-	NewProjects   []weeklyProject
+	TopGainers    []reportProject
+	Climbers      []reportProject
+	Fallers       []reportProject
+	NewProjects   []reportProject
+	Dropped       []reportProject
+	Narrative     *datastore.Narrative // editorial intro, Chinese only; nil = none
 }
 
-type weeklyProject struct {
-	Rank        int
-	FullName    string
-	Language    string
-	Stars       int
-	WeeklyStars int
-	Category    string
-	Summary     string
-	Forks       int
+// narrativeSection renders the editorial intro and takes, when present.
+const narrativeSection = "{{with .Narrative}}{{.Intro}}\n\n" +
+	"{{if .Highlights}}## 编辑点评\n\n" +
+	"{{range .Highlights}}- **{{projectLink .Project}}**：{{.Take}}\n{{end}}\n{{end}}{{end}}"
+
+const weeklyTemplate = narrativeSection + "## 本周概览\n\n" +
+	"{{.StartDate}} ~ {{.EndDate}} 共有 {{.Days}} 天排行数据，AI Trending 共追踪 {{.TotalProjects}} 个项目，" +
+	"{{.NewEntries}} 个新入榜，{{len .Dropped}} 个跌出榜单。\n" +
+	"{{if .TopGainers}}\n## Star 增长 Top 10\n\n" +
+	"| 排名 | 项目 | 语言 | 周增 Star | 总 Star | 分类 |\n" +
+	"|------|------|------|-----------|---------|------|\n" +
+	"{{- range $i, $p := .TopGainers}}\n" +
+	"| {{inc $i}} | {{$p.FullName}} | {{$p.Language}} | +{{$p.StarGain}} | {{$p.Stars}} | {{$p.Category}} |\n" +
+	"{{- end}}\n{{end}}" +
+	"{{if or .Climbers .Fallers}}\n## 排名变动\n\n" +
+	"| 项目 | 周初排名 | 周末排名 | 变化 |\n" +
+	"|------|----------|----------|------|\n" +
+	"{{- range .Climbers}}\n| {{.FullName}} | {{.FromRank}} | {{.ToRank}} | ↑{{.Move}} |{{end}}" +
+	"{{- range .Fallers}}\n| {{.FullName}} | {{.FromRank}} | {{.ToRank}} | ↓{{.Move}} |{{end}}\n{{end}}" +
+	"{{if .NewProjects}}\n" +
+	"## 新入榜项目\n" +
+	"{{range .NewProjects}}\n" +
+	"### {{.FullName}}\n\n" +
+	"> {{.Summary}}\n\n" +
+	"Stars: {{.Stars}} | Forks: {{.Forks}} | Language: {{.Language}} | Category: {{.Category}}\n" +
+	"{{end}}{{end}}" +
+	"{{if .Dropped}}\n## 跌出榜单\n\n" +
+	"{{- range .Dropped}}\n- {{.FullName}}（此前排名 {{.FromRank}}）\n{{- end}}\n{{end}}"
+
+const weeklyTemplateEN = "## This Week\n\n" +
+	"{{.StartDate}} ~ {{.EndDate}} has {{.Days}} days of rankings. AI Trending tracked {{.TotalProjects}} projects, " +
+	"{{.NewEntries}} new entries and {{len .Dropped}} exits.\n" +
+	"{{if .TopGainers}}\n## Top 10 by Star Growth\n\n" +
+	"| Rank | Project | Language | Weekly Stars | Stars | Category |\n" +
+	"|------|---------|----------|--------------|-------|----------|\n" +
+	"{{- range $i, $p := .TopGainers}}\n" +
+	"| {{inc $i}} | {{$p.FullName}} | {{$p.Language}} | +{{$p.StarGain}} | {{$p.Stars}} | {{$p.Category}} |\n" +
+	"{{- end}}\n{{end}}" +
+	"{{if or .Climbers .Fallers}}\n## Rank Movement\n\n" +
+	"| Project | Start Rank | End Rank | Change |\n" +
+	"|---------|------------|----------|--------|\n" +
+	"{{- range .Climbers}}\n| {{.FullName}} | {{.FromRank}} | {{.ToRank}} | ↑{{.Move}} |{{end}}" +
+	"{{- range .Fallers}}\n| {{.FullName}} | {{.FromRank}} | {{.ToRank}} | ↓{{.Move}} |{{end}}\n{{end}}" +
+	"{{if .NewProjects}}\n" +
+	"## New Entries\n" +
+	"{{range .NewProjects}}\n" +
+	"### {{.FullName}}\n\n" +
+	"{{if .Summary}}> {{.Summary}}\n\n{{end}}" +
+	"Stars: {{.Stars}} | Forks: {{.Forks}} | Language: {{.Language}} | Category: {{.Category}}\n" +
+	"{{end}}{{end}}" +
+	"{{if .Dropped}}\n## Dropped Off\n\n" +
+	"{{- range .Dropped}}\n- {{.FullName}} (previously #{{.FromRank}})\n{{- end}}\n{{end}}"
+
+// weekRange parses an ISO week such as 2026-W41 into its Monday,
+// defaulting to the week containing now.
+func weekRange(week string, now time.Time) (time.Time, error) {
+	year, num := now.ISOWeek()
+	if week != "" {
+		y, w, ok := strings.Cut(week, "-W")
+		var err1, err2 error
+		year, err1 = strconv.Atoi(y)
+		num, err2 = strconv.Atoi(w)
+		if !ok || len(y) != 4 || len(w) != 2 || err1 != nil || err2 != nil {
+			return time.Time{}, fmt.Errorf("无效的周 %q，格式应为 YYYY-Www，如 2026-W41", week)
+		}
+	}
+	// ISO week 1 is the week containing January 4th.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -(int(jan4.Weekday())+6)%7+(num-1)*7)
+	if y, w := monday.ISOWeek(); y != year || w != num {
+		return time.Time{}, fmt.Errorf("%d 年没有第 %d 周", year, num)
+	}
+	return monday, nil
+}
+
+// summaryFor returns a ranking item's one-line summary in locale. Chinese
+// uses the ranking's summary; other locales use the project's published
+// analysis in that locale, else its GitHub description.
+func summaryFor(it datastore.RankingItem, p *datastore.Project, locale string) string {
+	if locale == datastore.DefaultLocale {
+		if it.Summary != nil {
+			return *it.Summary
+		}
+		return ""
+	}
+	if p == nil {
+		return ""
+	}
+	if a := p.PublishedAnalysis(locale); a != nil {
+		return a.Summary
+	}
+	if p.Description != nil {
+		return *p.Description
+	}
+	return ""
 }
 
-var weeklyTpl = template.Must(template.New("weekly").Parse(
-	"## 本周概览\n\n" +
-		"本周 AI Trending 共追踪 {{.TotalProjects}} 个项目，{{.NewEntries}} 个新入榜。\n\n" +
-		"## Star 增长 Top 10\n\n" +
-		"| 排名 | 项目 | 语言 | 周增 Star | 总 Star | 分类 |\n" +
-		"|------|------|------|-----------|---------|------|\n" +
-		"{{- range .TopGainers}}\n" +
-		"| {{.Rank}} | {{.FullName}} | {{.Language}} | +{{.WeeklyStars}} | {{.Stars}} | {{.Category}} |\n" +
-		"{{- end}}\n" +
-		"{{if .NewProjects}}\n" +
-		"## 新入榜项目\n" +
-		"{{range .NewProjects}}\n" +
-		"### {{.FullName}}\n\n" +
-		"> {{.Summary}}\n\n" +
-		"Stars: {{.Stars}} | Forks: {{.Forks}} | Language: {{.Language}} | Category: {{.Category}}\n" +
-		"{{end}}{{end}}",
-))
-
-func (g *Generator) generateWeekly(opts RunOptions) error {
+func (g *Generator) generateWeekly(ctx context.Context, opts RunOptions, locale string) error {
 	now := time.Now().UTC()
-	year, week := now.ISOWeek()
-	endDate := now.Format("2006-01-02")
-	startDate := now.AddDate(0, 0, -7).Format("2006-01-02")
+	start, err := weekRange(opts.Week, now)
+	if err != nil {
+		return err
+	}
+	end := start.AddDate(0, 0, 6)
+	year, week := start.ISOWeek()
+	startDate, endDate := start.Format("2006-01-02"), end.Format("2006-01-02")
 
-	slug := fmt.Sprintf("ai-weekly-%d-w%02d", year, week)
+	slug := localizeSlug(fmt.Sprintf("ai-weekly-%d-w%02d", year, week), locale)
+	text := postTexts[locale]
 
-	ranking, err := g.store.LoadLatestRanking()
+	pd, err := g.loadPeriod(start, end, fmt.Sprintf("%d-W%02d", year, week))
 	if err != nil {
-		return fmt.Errorf("loading ranking: %w", err)
-	}
-	if ranking == nil {
-		return fmt.Errorf("没有可用的排行数据，请先运行 tishi score")
+		return err
 	}
 
 	data := weeklyData{
@@ -106,96 +315,56 @@ func (g *Generator) generateWeekly(opts RunOptions) error {
 		WeekNum:       week,
 		StartDate:     startDate,
 		EndDate:       endDate,
-		TotalProjects: ranking.Total,
+		Days:          pd.days,
+		TotalProjects: pd.last.Total,
+		TopGainers:    pd.topGainers(),
+		Climbers:      pd.movers(true),
+		Fallers:       pd.movers(false),
+		Dropped:       pd.exits(),
+	}
+	for _, it := range pd.entries() {
+		rp := pd.project(it.ProjectID)
+		p, _ := g.store.LoadProject(it.ProjectID)
+		rp.Summary = summaryFor(it, p, locale)
+		rp.ToRank = it.Rank
+		data.NewProjects = append(data.NewProjects, rp)
+		data.NewEntries++
 	}
 
-	// Sort by weekly stars for top gainers
-	type ri = datastore.RankingItem
-	sortedItems := make([]ri, len(ranking.Items))
-	copy(sortedItems, ranking.Items)
-
-	sort.Slice(sortedItems, func(i, j int) bool {
-		a, b := 0, 0
-		if sortedItems[i].WeeklyStars != nil {
-			a = *sortedItems[i].WeeklyStars
-		}
-		if sortedItems[j].WeeklyStars != nil {
-			b = *sortedItems[j].WeeklyStars
-		}
-		return a > b
-	})
-
-	topN := 10
-	if topN > len(sortedItems) {
-		topN = len(sortedItems)
-	}
-	for i := 0; i < topN; i++ {
-		it := sortedItems[i]
-		wp := weeklyProject{
-			Rank:     i + 1,
-			FullName: it.FullName,
-			Stars:    it.Stars,
-		}
-		if it.Language != nil {
-			wp.Language = *it.Language
-		}
-		if it.Category != nil {
-			wp.Category = *it.Category
-		}
-		if it.WeeklyStars != nil {
-			wp.WeeklyStars = *it.WeeklyStars
-		}
-		if it.Summary != nil {
-			wp.Summary = *it.Summary
-		}
-		data.TopGainers = append(data.TopGainers, wp)
-	}
-
-	// New entries (rank_change == nil)
-	for _, it := range ranking.Items {
-		if it.RankChange == nil {
-			wp := weeklyProject{FullName: it.FullName, Stars: it.Stars}
-			if it.Language != nil {
-				wp.Language = *it.Language
-			}
-			if it.Category != nil {
-				wp.Category = *it.Category
-			}
-			if it.Summary != nil {
-				wp.Summary = *it.Summary
-			}
-			if p, _ := g.store.LoadProject(it.ProjectID); p != nil {
-				wp.Forks = p.Forks
-			}
-			data.NewProjects = append(data.NewProjects, wp)
-			data.NewEntries++
-		}
+	in := g.narrativeInput("weekly", fmt.Sprintf("%d-W%02d", year, week), startDate, endDate, data.TotalProjects,
+		data.TopGainers, categoryShares(pd.first, pd.last), data.NewProjects)
+	narrative, err := g.narrative(ctx, slug, locale, opts, in)
+	if err != nil {
+		return err
 	}
+	data.Narrative = shownNarrative(narrative)
 
-	var buf strings.Builder
-	if err := weeklyTpl.Execute(&buf, data); err != nil {
-		return fmt.Errorf("rendering weekly: %w", err)
+	content, err := g.render("weekly", locale, opts, data)
+	if err != nil {
+		return err
 	}
 
-	title := fmt.Sprintf("AI 开源周报 #%d | %s ~ %s", week, startDate, endDate)
+	title := fmt.Sprintf(text.weeklyTitle, week, startDate, endDate)
 
 	if opts.DryRun {
-		g.log.Info("dry-run", zap.String("slug", slug), zap.String("title", title))
-		fmt.Println(buf.String())
+		g.log.Info("dry-run", zap.String("slug", slug), zap.String("title", title), zap.String("locale", locale))
+		fmt.Println(content)
 		return nil
 	}
 
 	post := &datastore.Post{
-		Slug:     slug,
-		Title:    title,
-		Content:  buf.String(),
-		PostType: "weekly",
+		Slug:      slug,
+		Title:     title,
+		Content:   content,
+		PostType:  "weekly",
+		Locale:    postLocale(locale),
+		Narrative: narrative,
 	}
-	if err := g.store.SavePost(post); err != nil {
-		return fmt.Errorf("saving post: %w", err)
+	if err := g.savePost(post, now); err != nil {
+		return err
 	}
 
-	g.log.Info("周报生成完成", zap.String("slug", slug))
+	g.log.Info("周报生成完成", zap.String("slug", slug), zap.String("locale", locale), zap.Bool("published", post.PublishedAt != nil))
 	return nil
 }
 
@@ -211,21 +380,49 @@ type spotlightData struct {
 	UseCases    string
 	Comparison  []datastore.ComparisonEntry
 	Ecosystem   string
+	Similar     []datastore.SimilarProject
 }
 
-var spotlightTpl = template.Must(template.New("spotlight").Parse(
-	"## 概述\n\n> {{.Summary}}\n\n{{.Positioning}}\n\n" +
-		"## 核心功能\n{{range .Features}}\n- **{{.Name}}**: {{.Desc}}\n{{- end}}\n\n" +
-		"## 技术亮点\n\n{{.Advantages}}\n\n" +
-		"## 技术栈\n\n{{.TechStack}}\n\n" +
-		"## 适用场景\n\n{{.UseCases}}\n" +
-		"{{if .Comparison}}\n## 竞品对比\n\n" +
-		"| 项目 | 差异 |\n|------|------|\n" +
-		"{{- range .Comparison}}\n| {{.Project}} | {{.Diff}} |\n{{- end}}\n{{end}}\n" +
-		"## 生态定位\n\n{{.Ecosystem}}\n",
-))
-
-func (g *Generator) generateSpotlight(opts RunOptions) error {
+// comparisonLink renders a competitor as a Markdown link to its project
+// page when tishi tracks it, or to GitHub when it resolved to a verified
+// repository. Unverified names are left as plain text.
+func comparisonLink(c datastore.ComparisonEntry) string {
+	switch c.Status {
+	case datastore.ComparisonTracked:
+		if c.ProjectID != "" {
+			return fmt.Sprintf("[%s](/projects/%s)", c.Project, c.ProjectID)
+		}
+	case datastore.ComparisonGitHub:
+		if c.Repo != "" {
+			return fmt.Sprintf("[%s](https://github.com/%s)", c.Project, c.Repo)
+		}
+	}
+	return c.Project
+}
+
+const spotlightTemplate = "## 概述\n\n> {{.Summary}}\n\n{{.Positioning}}\n\n" +
+	"## 核心功能\n{{range .Features}}\n- **{{.Name}}**: {{.Desc}}\n{{- end}}\n\n" +
+	"## 技术亮点\n\n{{.Advantages}}\n\n" +
+	"## 技术栈\n\n{{.TechStack}}\n\n" +
+	"## 适用场景\n\n{{.UseCases}}\n" +
+	"{{if .Comparison}}\n## 竞品对比\n\n" +
+	"| 项目 | 差异 |\n|------|------|\n" +
+	"{{- range .Comparison}}\n| {{comparisonLink .}} | {{.Diff}} |\n{{- end}}\n{{end}}\n" +
+	"## 生态定位\n\n{{.Ecosystem}}\n" +
+	"{{if .Similar}}\n## 相似项目\n{{range .Similar}}\n- [{{.FullName}}](/projects/{{.ProjectID}})\n{{- end}}\n{{end}}"
+
+const spotlightTemplateEN = "## Overview\n\n> {{.Summary}}\n\n{{.Positioning}}\n\n" +
+	"## Key Features\n{{range .Features}}\n- **{{.Name}}**: {{.Desc}}\n{{- end}}\n\n" +
+	"## Highlights\n\n{{.Advantages}}\n\n" +
+	"## Tech Stack\n\n{{.TechStack}}\n\n" +
+	"## Use Cases\n\n{{.UseCases}}\n" +
+	"{{if .Comparison}}\n## Comparison\n\n" +
+	"| Project | Difference |\n|---------|------------|\n" +
+	"{{- range .Comparison}}\n| {{comparisonLink .}} | {{.Diff}} |\n{{- end}}\n{{end}}\n" +
+	"## Ecosystem\n\n{{.Ecosystem}}\n" +
+	"{{if .Similar}}\n## Similar Projects\n{{range .Similar}}\n- [{{.FullName}}](/projects/{{.ProjectID}})\n{{- end}}\n{{end}}"
+
+func (g *Generator) generateSpotlight(ctx context.Context, opts RunOptions, locale string) error {
 	if opts.ProjectID == "" {
 		return fmt.Errorf("--id is required for spotlight posts")
 	}
@@ -235,51 +432,60 @@ func (g *Generator) generateSpotlight(opts RunOptions) error {
 		return fmt.Errorf("loading project: %w", err)
 	}
 
-	if p.Analysis == nil || p.Analysis.Status != "published" {
+	a := p.PublishedAnalysis(locale)
+	if a == nil {
 		status := "无"
-		if p.Analysis != nil {
-			status = p.Analysis.Status
+		if live := p.AnalysisFor(locale); live != nil {
+			status = live.Status
 		}
-		return fmt.Errorf("项目 %s 没有已发布的分析（当前: %s），请先 tishi analyze + tishi review --approve", p.FullName, status)
+		if locale == datastore.DefaultLocale {
+			return fmt.Errorf("项目 %s 没有已发布的分析（当前: %s），请先 tishi analyze + tishi review --approve", p.FullName, status)
+		}
+		return fmt.Errorf("项目 %s 没有已发布的 %s 分析（当前: %s），请先 tishi analyze --locale %s + tishi review --approve --locale %s",
+			p.FullName, locale, status, locale, locale)
 	}
 
-	slug := "spotlight-" + strings.ReplaceAll(p.FullName, "/", "-")
+	now := time.Now().UTC()
+	slug := localizeSlug("spotlight-"+strings.ReplaceAll(p.FullName, "/", "-"), locale)
+	text := postTexts[locale]
 
 	data := spotlightData{
 		FullName:    p.FullName,
-		Summary:     p.Analysis.Summary,
-		Positioning: p.Analysis.Positioning,
-		Features:    p.Analysis.Features,
-		Advantages:  p.Analysis.Advantages,
-		TechStack:   p.Analysis.TechStack,
-		UseCases:    p.Analysis.UseCases,
-		Comparison:  p.Analysis.Comparison,
-		Ecosystem:   p.Analysis.Ecosystem,
+		Summary:     a.Summary,
+		Positioning: a.Positioning,
+		Features:    a.Features,
+		Advantages:  a.Advantages,
+		TechStack:   a.TechStack,
+		UseCases:    a.UseCases,
+		Comparison:  a.Comparison,
+		Ecosystem:   a.Ecosystem,
+		Similar:     p.Similar,
 	}
 
-	var buf strings.Builder
-	if err := spotlightTpl.Execute(&buf, data); err != nil {
-		return fmt.Errorf("rendering spotlight: %w", err)
+	content, err := g.render("spotlight", locale, opts, data)
+	if err != nil {
+		return err
 	}
 
-	title := fmt.Sprintf("项目深度解读：%s", p.FullName)
+	title := fmt.Sprintf(text.spotlightTitle, p.FullName)
 
 	if opts.DryRun {
-		g.log.Info("dry-run", zap.String("slug", slug))
-		fmt.Println(buf.String())
+		g.log.Info("dry-run", zap.String("slug", slug), zap.String("locale", locale))
+		fmt.Println(content)
 		return nil
 	}
 
 	post := &datastore.Post{
 		Slug:     slug,
 		Title:    title,
-		Content:  buf.String(),
+		Content:  content,
 		PostType: "spotlight",
+		Locale:   postLocale(locale),
 	}
-	if err := g.store.SavePost(post); err != nil {
-		return fmt.Errorf("saving post: %w", err)
+	if err := g.savePost(post, now); err != nil {
+		return err
 	}
 
-	g.log.Info("spotlight 生成完成", zap.String("slug", slug), zap.String("project", p.FullName))
+	g.log.Info("spotlight 生成完成", zap.String("slug", slug), zap.String("project", p.FullName), zap.String("locale", locale))
 	return nil
 }
@@ -1,6 +1,8 @@
 package generator
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -42,7 +44,7 @@ func TestGenerateWeekly(t *testing.T) {
 	}
 
 	g := New(store, testLogger())
-	if err := g.Run("weekly", RunOptions{DryRun: true}); err != nil {
+	if err := g.Run(context.Background(), "weekly", RunOptions{DryRun: true}); err != nil {
 		t.Fatalf("Run weekly: %v", err)
 	}
 }
@@ -52,18 +54,112 @@ func TestGenerateWeekly_NoRanking(t *testing.T) {
 	store := datastore.NewStore(dir, testLogger())
 
 	g := New(store, testLogger())
-	err := g.Run("weekly", RunOptions{})
+	err := g.Run(context.Background(), "weekly", RunOptions{})
 	if err == nil {
 		t.Fatal("expected error when no ranking exists")
 	}
 }
 
+func TestGenerateWeekly_Week(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	str := func(s string) *string { return &s }
+	item := func(rank int, name string) datastore.RankingItem {
+		return datastore.RankingItem{Rank: rank, ProjectID: strings.ReplaceAll(name, "/", "__"), FullName: name,
+			Summary: str(name + " 摘要"), Language: str("Go"), Category: str("agent")}
+	}
+
+	// 2026-W41 runs Monday 2026-10-05 to Sunday 2026-10-11.
+	for _, r := range []*datastore.Ranking{
+		{Date: "2026-10-04", Items: []datastore.RankingItem{item(1, "o/a"), item(2, "o/b"), item(3, "o/gone")}},
+		{Date: "2026-10-05", Items: []datastore.RankingItem{item(1, "o/a"), item(2, "o/b"), item(3, "o/gone")}},
+		{Date: "2026-10-11", Items: []datastore.RankingItem{item(1, "o/b"), item(2, "o/a"), item(3, "o/new")}},
+		{Date: "2026-10-12", Items: []datastore.RankingItem{item(1, "o/late")}},
+	} {
+		r.Total = len(r.Items)
+		if err := store.SaveRanking(r); err != nil {
+			t.Fatalf("SaveRanking: %v", err)
+		}
+	}
+	for _, s := range []datastore.Snapshot{
+		{ProjectID: "o__a", Date: "2026-10-04", Stars: 1000},
+		{ProjectID: "o__a", Date: "2026-10-11", Stars: 1070},
+		{ProjectID: "o__b", Date: "2026-10-04", Stars: 500},
+		{ProjectID: "o__b", Date: "2026-10-05", Stars: 520},
+		{ProjectID: "o__b", Date: "2026-10-11", Stars: 800},
+		{ProjectID: "o__new", Date: "2026-10-11", Stars: 300, Forks: 42},
+		{ProjectID: "o__late", Date: "2026-10-12", Stars: 9000},
+	} {
+		if err := store.AppendSnapshot(&s); err != nil {
+			t.Fatalf("AppendSnapshot: %v", err)
+		}
+	}
+	// Forks have grown since; the report shows them as of the week.
+	if err := store.SaveProject(&datastore.Project{ID: "o__new", FullName: "o/new", Forks: 999}); err != nil {
+		t.Fatalf("SaveProject: %v", err)
+	}
+
+	g := New(store, testLogger())
+	if err := g.Run(context.Background(), "weekly", RunOptions{Week: "2026-W41"}); err != nil {
+		t.Fatalf("Run weekly: %v", err)
+	}
+	post, err := store.LoadPost("ai-weekly-2026-w41")
+	if err != nil || post == nil {
+		t.Fatalf("LoadPost: %v, %v", post, err)
+	}
+	if post.Title != "AI 开源周报 #41 | 2026-10-05 ~ 2026-10-11" {
+		t.Errorf("Title = %q", post.Title)
+	}
+	for _, want := range []string{
+		"2026-10-05 ~ 2026-10-11 共有 2 天排行数据，AI Trending 共追踪 3 个项目，1 个新入榜，1 个跌出榜单。",
+		// Gains count from the day before the week.
+		"| 1 | o/b | Go | +300 | 800 | agent |\n| 2 | o/a | Go | +70 | 1070 | agent |",
+		"| o/b | 2 | 1 | ↑1 |\n| o/a | 1 | 2 | ↓1 |",
+		"### o/new\n\n> o/new 摘要",
+		"| Forks: 42 |",
+		"- o/gone（此前排名 3）",
+	} {
+		if !strings.Contains(post.Content, want) {
+			t.Errorf("content missing %q:\n%s", want, post.Content)
+		}
+	}
+	if strings.Contains(post.Content, "o/late") {
+		t.Errorf("content includes a project from the following week:\n%s", post.Content)
+	}
+
+	if err := g.Run(context.Background(), "weekly", RunOptions{Week: "2026-W39"}); err == nil {
+		t.Error("expected error for a week without rankings")
+	}
+}
+
+func TestWeekRange(t *testing.T) {
+	for week, want := range map[string]string{
+		"2026-W41": "2026-10-05",
+		"2026-W01": "2025-12-29",
+		"2026-W53": "2026-12-28",
+		"2021-W01": "2021-01-04",
+	} {
+		got, err := weekRange(week, time.Now())
+		if err != nil || got.Format("2006-01-02") != want {
+			t.Errorf("weekRange(%q) = %v, %v, want %s", week, got, err, want)
+		}
+	}
+	for _, week := range []string{"2025-W53", "2026-W00", "2026-41", "2026-W4", "26-W41", "2026-W41x"} {
+		if _, err := weekRange(week, time.Now()); err == nil {
+			t.Errorf("weekRange(%q) succeeded, want error", week)
+		}
+	}
+	got, _ := weekRange("", time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+	if got.Format("2006-01-02") != "2026-10-19" {
+		t.Errorf("default week starts %v, want 2026-10-19", got)
+	}
+}
+
 func TestGenerateSpotlight_NoID(t *testing.T) {
 	dir := t.TempDir()
 	store := datastore.NewStore(dir, testLogger())
 
 	g := New(store, testLogger())
-	err := g.Run("spotlight", RunOptions{})
+	err := g.Run(context.Background(), "spotlight", RunOptions{})
 	if err == nil {
 		t.Fatal("expected error when no --id for spotlight")
 	}
@@ -84,7 +180,7 @@ func TestGenerateSpotlight_NeedsPublished(t *testing.T) {
 	}
 
 	g := New(store, testLogger())
-	err := g.Run("spotlight", RunOptions{ProjectID: "owner__repo"})
+	err := g.Run(context.Background(), "spotlight", RunOptions{ProjectID: "owner__repo"})
 	if err == nil {
 		t.Fatal("expected error for draft analysis")
 	}
@@ -118,7 +214,7 @@ func TestGenerateSpotlight_Success(t *testing.T) {
 
 	g := New(store, testLogger())
 	// DryRun to avoid file write
-	if err := g.Run("spotlight", RunOptions{ProjectID: "owner__repo", DryRun: true}); err != nil {
+	if err := g.Run(context.Background(), "spotlight", RunOptions{ProjectID: "owner__repo", DryRun: true}); err != nil {
 		t.Fatalf("Run spotlight: %v", err)
 	}
 }
@@ -135,12 +231,13 @@ func TestGenerateSpotlight_SavePost(t *testing.T) {
 			Positioning: "X", Advantages: "Y", TechStack: "Z",
 			UseCases: "W", Ecosystem: "E", GeneratedAt: now,
 		},
+		Similar:     []datastore.SimilarProject{{ProjectID: "other__tool", FullName: "other/tool", Score: 0.9}},
 		FirstSeenAt: now, UpdatedAt: now,
 	}
 	store.SaveProject(p)
 
 	g := New(store, testLogger())
-	if err := g.Run("spotlight", RunOptions{ProjectID: "owner__repo"}); err != nil {
+	if err := g.Run(context.Background(), "spotlight", RunOptions{ProjectID: "owner__repo"}); err != nil {
 		t.Fatalf("Run: %v", err)
 	}
 
@@ -154,11 +251,83 @@ func TestGenerateSpotlight_SavePost(t *testing.T) {
 	if posts[0].PostType != "spotlight" {
 		t.Errorf("PostType = %q", posts[0].PostType)
 	}
+	if !strings.Contains(posts[0].Content, "## 相似项目\n\n- [other/tool](/projects/other__tool)") {
+		t.Errorf("similar projects missing from content:\n%s", posts[0].Content)
+	}
+}
+
+func TestComparisonLink(t *testing.T) {
+	cases := []struct {
+		in   datastore.ComparisonEntry
+		want string
+	}{
+		{datastore.ComparisonEntry{Project: "LlamaIndex", ProjectID: "run-llama__llama_index", Status: datastore.ComparisonTracked},
+			"[LlamaIndex](/projects/run-llama__llama_index)"},
+		{datastore.ComparisonEntry{Project: "FAISS", Repo: "facebookresearch/faiss", Status: datastore.ComparisonGitHub},
+			"[FAISS](https://github.com/facebookresearch/faiss)"},
+		{datastore.ComparisonEntry{Project: "TinyAgent", Repo: "someone/tinyagent", Status: datastore.ComparisonSuspicious},
+			"TinyAgent"},
+		{datastore.ComparisonEntry{Project: "other/tool"}, "other/tool"},
+	}
+	for _, c := range cases {
+		if got := comparisonLink(c.in); got != c.want {
+			t.Errorf("comparisonLink(%s) = %q, want %q", c.in.Project, got, c.want)
+		}
+	}
 }
 
 func TestRun_InvalidType(t *testing.T) {
 	g := New(datastore.NewStore(t.TempDir(), testLogger()), testLogger())
-	if err := g.Run("invalid", RunOptions{}); err == nil {
+	if err := g.Run(context.Background(), "invalid", RunOptions{}); err == nil {
 		t.Fatal("expected error for invalid post type")
 	}
 }
+
+func TestGenerateSpotlight_Locales(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+
+	now := time.Now().UTC()
+	p := &datastore.Project{
+		ID: "owner__repo", FullName: "owner/repo", Stars: 1000,
+		Analysis: &datastore.Analysis{Status: "published", Summary: "工具", GeneratedAt: now},
+		Localized: map[string]*datastore.LocalizedAnalysis{
+			"en": {Analysis: &datastore.Analysis{Locale: "en", Status: "draft", Summary: "Tool", GeneratedAt: now}},
+		},
+		FirstSeenAt: now, UpdatedAt: now,
+	}
+	_ = store.SaveProject(p)
+
+	g := New(store, testLogger())
+	if err := g.Run(context.Background(), "spotlight", RunOptions{ProjectID: "owner__repo", Locales: []string{"en"}}); err == nil {
+		t.Fatal("expected error when the en analysis is not published")
+	}
+
+	p.Localized["en"].Analysis.Status = "published"
+	_ = store.SaveProject(p)
+	if err := g.Run(context.Background(), "spotlight", RunOptions{ProjectID: "owner__repo", Locales: []string{"zh", "en"}}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	posts, err := store.ListPosts()
+	if err != nil {
+		t.Fatalf("ListPosts: %v", err)
+	}
+	bySlug := make(map[string]*datastore.Post)
+	for _, post := range posts {
+		bySlug[post.Slug] = post
+	}
+	zh, en := bySlug["spotlight-owner-repo"], bySlug["spotlight-owner-repo-en"]
+	if zh == nil || en == nil {
+		t.Fatalf("slugs = %v, want zh and en posts", bySlug)
+	}
+	if zh.Locale != "" || !strings.Contains(zh.Content, "## 概述") {
+		t.Errorf("zh post = %+v", zh)
+	}
+	if en.Locale != "en" || en.Title != "Project Deep Dive: owner/repo" || !strings.Contains(en.Content, "> Tool") {
+		t.Errorf("en post = %+v", en)
+	}
+
+	if err := g.Run(context.Background(), "spotlight", RunOptions{ProjectID: "owner__repo", Locales: []string{"fr"}}); err == nil {
+		t.Error("expected error for unsupported locale")
+	}
+}
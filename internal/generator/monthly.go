@@ -0,0 +1,278 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// ── Monthly Report ─────────────────────────────────────────────
+
+type monthlyData struct {
+	Month         string // YYYY-MM
+	StartDate     string // first ranking of the month
+	EndDate       string // last ranking of the month
+	Days          int    // rankings in the month
+	TotalProjects int    // on the last ranking
+	TopGainers    []reportProject
+	Climbers      []reportProject
+	Categories    []categoryShare
+	NewEntrants   []reportProject
+	Dropped       []reportProject
+	Spotlights    []monthlySpotlight
+	Narrative     *datastore.Narrative // editorial intro, Chinese only; nil = none
+}
+
+// categoryShare is a category's share of the ranking, in percent, at the
+// start and end of the month.
+type categoryShare struct {
+	Category string
+	Before   float64
+	After    float64
+	Delta    float64
+}
+
+type monthlySpotlight struct {
+	Title string
+	Slug  string
+}
+
+const monthlyTemplate = narrativeSection + "## 本月概览\n\n" +
+	"{{.Month}} 共有 {{.Days}} 天排行数据（{{.StartDate}} ~ {{.EndDate}}），月末榜单共 {{.TotalProjects}} 个项目，" +
+	"{{len .NewEntrants}} 个新项目入榜并留在榜上，{{len .Dropped}} 个项目跌出榜单。\n" +
+	"{{if .TopGainers}}\n## 月度 Star 增长 Top 10\n\n" +
+	"| # | 项目 | 语言 | 月增 Star | 总 Star | 分类 |\n" +
+	"|---|------|------|-----------|---------|------|\n" +
+	"{{- range $i, $p := .TopGainers}}\n" +
+	"| {{inc $i}} | {{$p.FullName}} | {{$p.Language}} | +{{$p.StarGain}} | {{$p.Stars}} | {{$p.Category}} |\n" +
+	"{{- end}}\n{{end}}" +
+	"{{if .Climbers}}\n## 排名上升最快\n\n" +
+	"| 项目 | 月初排名 | 月末排名 | 上升 |\n" +
+	"|------|----------|----------|------|\n" +
+	"{{- range .Climbers}}\n" +
+	"| {{.FullName}} | {{.FromRank}} | {{.ToRank}} | ↑{{.Move}} |\n" +
+	"{{- end}}\n{{end}}" +
+	"{{if .Categories}}\n## 分类占比变化\n\n" +
+	"| 分类 | 月初 | 月末 | 变化 |\n" +
+	"|------|------|------|------|\n" +
+	"{{- range .Categories}}\n" +
+	"| {{.Category}} | {{printf \"%.1f\" .Before}}% | {{printf \"%.1f\" .After}}% | {{printf \"%+.1f\" .Delta}} pp |\n" +
+	"{{- end}}\n{{end}}" +
+	"{{if .NewEntrants}}\n## 本月新入榜\n" +
+	"{{range .NewEntrants}}\n" +
+	"### {{.FullName}}\n\n" +
+	"{{if .Summary}}> {{.Summary}}\n\n{{end}}" +
+	"Stars: {{.Stars}} | 月末排名: {{.ToRank}} | Language: {{.Language}} | Category: {{.Category}}\n" +
+	"{{end}}{{end}}" +
+	"{{if .Dropped}}\n## 跌出榜单\n\n" +
+	"{{- range .Dropped}}\n- {{.FullName}}（月初排名 {{.FromRank}}）\n{{- end}}\n{{end}}" +
+	"{{if .Spotlights}}\n## 本月深度解读\n\n" +
+	"{{- range .Spotlights}}\n- [{{.Title}}](/blog/{{.Slug}})\n{{- end}}\n{{end}}"
+
+const monthlyTemplateEN = "## This Month\n\n" +
+	"{{.Month}} has {{.Days}} days of rankings ({{.StartDate}} ~ {{.EndDate}}). The final ranking lists {{.TotalProjects}} projects; " +
+	"{{len .NewEntrants}} newcomers entered and stayed, {{len .Dropped}} dropped off.\n" +
+	"{{if .TopGainers}}\n## Top 10 by Monthly Star Growth\n\n" +
+	"| # | Project | Language | Monthly Stars | Stars | Category |\n" +
+	"|---|---------|----------|---------------|-------|----------|\n" +
+	"{{- range $i, $p := .TopGainers}}\n" +
+	"| {{inc $i}} | {{$p.FullName}} | {{$p.Language}} | +{{$p.StarGain}} | {{$p.Stars}} | {{$p.Category}} |\n" +
+	"{{- end}}\n{{end}}" +
+	"{{if .Climbers}}\n## Biggest Rank Climbers\n\n" +
+	"| Project | Start Rank | End Rank | Change |\n" +
+	"|---------|------------|----------|--------|\n" +
+	"{{- range .Climbers}}\n" +
+	"| {{.FullName}} | {{.FromRank}} | {{.ToRank}} | ↑{{.Move}} |\n" +
+	"{{- end}}\n{{end}}" +
+	"{{if .Categories}}\n## Category Share\n\n" +
+	"| Category | Start | End | Change |\n" +
+	"|----------|-------|-----|--------|\n" +
+	"{{- range .Categories}}\n" +
+	"| {{.Category}} | {{printf \"%.1f\" .Before}}% | {{printf \"%.1f\" .After}}% | {{printf \"%+.1f\" .Delta}} pp |\n" +
+	"{{- end}}\n{{end}}" +
+	"{{if .NewEntrants}}\n## New This Month\n" +
+	"{{range .NewEntrants}}\n" +
+	"### {{.FullName}}\n\n" +
+	"{{if .Summary}}> {{.Summary}}\n\n{{end}}" +
+	"Stars: {{.Stars}} | End Rank: {{.ToRank}} | Language: {{.Language}} | Category: {{.Category}}\n" +
+	"{{end}}{{end}}" +
+	"{{if .Dropped}}\n## Dropped Off\n\n" +
+	"{{- range .Dropped}}\n- {{.FullName}} (start rank {{.FromRank}})\n{{- end}}\n{{end}}" +
+	"{{if .Spotlights}}\n## Spotlights This Month\n\n" +
+	"{{- range .Spotlights}}\n- [{{.Title}}](/blog/{{.Slug}})\n{{- end}}\n{{end}}"
+
+// monthRange parses YYYY-MM, defaulting to the month before now.
+func monthRange(month string, now time.Time) (time.Time, error) {
+	if month == "" {
+		y, m, _ := now.Date()
+		return time.Date(y, m-1, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的月份 %q，格式应为 YYYY-MM", month)
+	}
+	return start, nil
+}
+
+func (g *Generator) generateMonthly(ctx context.Context, opts RunOptions, locale string) error {
+	now := time.Now().UTC()
+	start, err := monthRange(opts.Month, now)
+	if err != nil {
+		return err
+	}
+	month := start.Format("2006-01")
+	data, err := g.monthlyData(start, locale)
+	if err != nil {
+		return err
+	}
+
+	slug := localizeSlug("ai-monthly-"+month, locale)
+	text := postTexts[locale]
+
+	in := g.narrativeInput("monthly", month, data.StartDate, data.EndDate, data.TotalProjects,
+		data.TopGainers, data.Categories, data.NewEntrants)
+	narrative, err := g.narrative(ctx, slug, locale, opts, in)
+	if err != nil {
+		return err
+	}
+	data.Narrative = shownNarrative(narrative)
+
+	content, err := g.render("monthly", locale, opts, data)
+	if err != nil {
+		return err
+	}
+
+	title := start.Format(text.monthlyTitle)
+
+	if opts.DryRun {
+		g.log.Info("dry-run", zap.String("slug", slug), zap.String("title", title), zap.String("locale", locale))
+		fmt.Println(content)
+		return nil
+	}
+
+	post := &datastore.Post{
+		Slug:      slug,
+		Title:     title,
+		Content:   content,
+		PostType:  "monthly",
+		Locale:    postLocale(locale),
+		Narrative: narrative,
+	}
+	if err := g.savePost(post, now); err != nil {
+		return err
+	}
+
+	g.log.Info("月报生成完成", zap.String("slug", slug), zap.String("locale", locale), zap.Bool("published", post.PublishedAt != nil))
+	return nil
+}
+
+// monthlyData aggregates the month's rankings, snapshots and spotlights.
+func (g *Generator) monthlyData(start time.Time, locale string) (*monthlyData, error) {
+	month := start.Format("2006-01")
+	pd, err := g.loadPeriod(start, start.AddDate(0, 1, -1), month)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &monthlyData{
+		Month:         month,
+		StartDate:     pd.first.Date,
+		EndDate:       pd.last.Date,
+		Days:          pd.days,
+		TotalProjects: pd.last.Total,
+		TopGainers:    pd.topGainers(),
+		Climbers:      pd.movers(true),
+		Categories:    categoryShares(pd.first, pd.last),
+		Dropped:       pd.exits(),
+	}
+	for _, it := range pd.entries() {
+		rp := pd.project(it.ProjectID)
+		rp.ToRank = it.Rank
+		var p *datastore.Project
+		if locale != datastore.DefaultLocale {
+			p, _ = g.store.LoadProject(it.ProjectID)
+		}
+		rp.Summary = summaryFor(it, p, locale)
+		data.NewEntrants = append(data.NewEntrants, rp)
+	}
+
+	if data.Spotlights, err = g.monthSpotlights(month, locale); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// categoryShares compares each category's share of the first and last
+// ranking, biggest change first. Uncategorized projects are left out.
+func categoryShares(first, last *datastore.Ranking) []categoryShare {
+	share := func(r *datastore.Ranking) map[string]float64 {
+		m := make(map[string]float64)
+		if len(r.Items) == 0 {
+			return m
+		}
+		for _, it := range r.Items {
+			if it.Category != nil && *it.Category != "" {
+				m[*it.Category] += 100 / float64(len(r.Items))
+			}
+		}
+		return m
+	}
+	before, after := share(first), share(last)
+
+	var out []categoryShare
+	seen := make(map[string]bool)
+	for _, m := range []map[string]float64{after, before} {
+		for cat := range m {
+			if seen[cat] {
+				continue
+			}
+			seen[cat] = true
+			out = append(out, categoryShare{Category: cat, Before: before[cat], After: after[cat], Delta: after[cat] - before[cat]})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		di, dj := abs(out[i].Delta), abs(out[j].Delta)
+		if di != dj {
+			return di > dj
+		}
+		return out[i].Category < out[j].Category
+	})
+	return out
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// monthSpotlights lists the spotlight posts in locale first published in
+// month, oldest first.
+func (g *Generator) monthSpotlights(month, locale string) ([]monthlySpotlight, error) {
+	posts, err := g.store.ListPosts()
+	if err != nil {
+		return nil, fmt.Errorf("listing posts: %w", err)
+	}
+	var found []*datastore.Post
+	for _, p := range posts {
+		if p.PostType != "spotlight" || p.PublishedAt == nil || p.Locale != postLocale(locale) {
+			continue
+		}
+		if p.PublishedAt.UTC().Format("2006-01") == month {
+			found = append(found, p)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].PublishedAt.Before(*found[j].PublishedAt) })
+
+	out := make([]monthlySpotlight, 0, len(found))
+	for _, p := range found {
+		out = append(out, monthlySpotlight{Title: p.Title, Slug: p.Slug})
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func TestGenerateMonthly(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	str := func(s string) *string { return &s }
+	item := func(rank int, name, cat string) datastore.RankingItem {
+		return datastore.RankingItem{Rank: rank, ProjectID: strings.ReplaceAll(name, "/", "__"), FullName: name, Category: str(cat)}
+	}
+
+	rankings := []*datastore.Ranking{
+		// Last ranking before the month: o/gone is on it, o/new is not.
+		{Date: "2026-09-30", Items: []datastore.RankingItem{item(1, "o/a", "llm"), item(2, "o/b", "rag"), item(3, "o/gone", "rag")}},
+		{Date: "2026-10-01", Items: []datastore.RankingItem{item(1, "o/a", "llm"), item(2, "o/b", "rag"), item(3, "o/gone", "rag")}},
+		{Date: "2026-10-31", Items: []datastore.RankingItem{item(1, "o/b", "rag"), item(2, "o/new", "agent"), item(3, "o/a", "llm")}},
+		{Date: "2026-11-01", Items: []datastore.RankingItem{item(1, "o/late", "llm")}},
+	}
+	for _, r := range rankings {
+		r.Total = len(r.Items)
+		if err := store.SaveRanking(r); err != nil {
+			t.Fatalf("SaveRanking: %v", err)
+		}
+	}
+	for _, s := range []datastore.Snapshot{
+		{ProjectID: "o__a", Date: "2026-10-01", Stars: 1000},
+		{ProjectID: "o__a", Date: "2026-10-31", Stars: 1100},
+		{ProjectID: "o__b", Date: "2026-10-01", Stars: 500},
+		{ProjectID: "o__b", Date: "2026-10-31", Stars: 900},
+		{ProjectID: "o__new", Date: "2026-10-15", Stars: 50},
+		{ProjectID: "o__new", Date: "2026-10-31", Stars: 300},
+	} {
+		if err := store.AppendSnapshot(&s); err != nil {
+			t.Fatalf("AppendSnapshot: %v", err)
+		}
+	}
+	inMonth := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	outside := time.Date(2026, 9, 12, 0, 0, 0, 0, time.UTC)
+	for _, p := range []*datastore.Post{
+		{Slug: "spotlight-o-b", Title: "项目深度解读：o/b", PostType: "spotlight", PublishedAt: &inMonth},
+		{Slug: "spotlight-o-a", Title: "项目深度解读：o/a", PostType: "spotlight", PublishedAt: &outside},
+		{Slug: "spotlight-o-b-en", Title: "Project Deep Dive: o/b", PostType: "spotlight", Locale: "en", PublishedAt: &inMonth},
+	} {
+		if err := store.SavePost(p); err != nil {
+			t.Fatalf("SavePost: %v", err)
+		}
+	}
+
+	g := New(store, testLogger())
+	if err := g.Run(context.Background(), "monthly", RunOptions{Month: "2026-10", Locales: []string{"zh", "en"}}); err != nil {
+		t.Fatalf("Run monthly: %v", err)
+	}
+
+	post, err := store.LoadPost("ai-monthly-2026-10")
+	if err != nil || post == nil {
+		t.Fatalf("LoadPost: %v, %v", post, err)
+	}
+	if post.PostType != "monthly" || post.Title != "AI 开源月报 | 2026 年 10 月" || post.PublishedAt == nil {
+		t.Errorf("post = %+v", post)
+	}
+	for _, want := range []string{
+		"2026-10 共有 2 天排行数据（2026-10-01 ~ 2026-10-31）",
+		"| 1 | o/b |  | +400 | 900 | rag |\n| 2 | o/new |  | +250 | 300 | agent |\n| 3 | o/a |  | +100 | 1100 | llm |",
+		"| o/b | 2 | 1 | ↑1 |",
+		"| agent | 0.0% | 33.3% | +33.3 pp |",
+		"### o/new",
+		"- o/gone（月初排名 3）",
+		"- [项目深度解读：o/b](/blog/spotlight-o-b)",
+	} {
+		if !strings.Contains(post.Content, want) {
+			t.Errorf("content missing %q:\n%s", want, post.Content)
+		}
+	}
+	for _, unwanted := range []string{"o/late", "o/a](", "### o/a", "### o/b"} {
+		if strings.Contains(post.Content, unwanted) {
+			t.Errorf("content contains %q:\n%s", unwanted, post.Content)
+		}
+	}
+
+	en, _ := store.LoadPost("ai-monthly-2026-10-en")
+	if en == nil || en.Title != "AI Open Source Monthly | October 2026" || !strings.Contains(en.Content, "/blog/spotlight-o-b-en") {
+		t.Errorf("en post = %+v", en)
+	}
+
+	// Regenerating keeps the original publication time.
+	if err := g.Run(context.Background(), "monthly", RunOptions{Month: "2026-10"}); err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	again, _ := store.LoadPost("ai-monthly-2026-10")
+	if !again.PublishedAt.Equal(*post.PublishedAt) || again.UpdatedAt == nil {
+		t.Errorf("regenerated post = %+v", again)
+	}
+}
+
+func TestGenerateMonthly_Errors(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	g := New(store, testLogger())
+	if err := g.Run(context.Background(), "monthly", RunOptions{Month: "2026-10"}); err == nil {
+		t.Error("expected error when the month has no rankings")
+	}
+	if err := g.Run(context.Background(), "monthly", RunOptions{Month: "2026/10"}); err == nil {
+		t.Error("expected error for invalid month")
+	}
+}
+
+func TestMonthRange_Default(t *testing.T) {
+	got, err := monthRange("", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil || got.Format("2006-01") != "2025-12" {
+		t.Errorf("monthRange = %v, %v, want 2025-12", got, err)
+	}
+}
@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/llm"
+)
+
+// ── Editorial Narrative ────────────────────────────────────────
+
+// Narrative caps: the top gainers and newcomers given a take each, and the
+// category shifts sent along.
+const (
+	narrativeHighlights = 5
+	narrativeCategories = 8
+)
+
+// Narrator writes the editorial narrative of a weekly or monthly report.
+// *llm.Narrator implements it.
+type Narrator interface {
+	Narrate(ctx context.Context, in *llm.NarrativeInput) (*datastore.Narrative, error)
+}
+
+// narrative returns the editorial narrative of the report at slug. Only
+// Chinese reports have one. The narrator writes a new draft when there is
+// none yet or the previous one was rejected, told why; a draft awaiting
+// review is only replaced with opts.RegenerateNarrative. Otherwise, and
+// when opts.Narrator is nil, the previous narrative is kept as is. A
+// failed call only warns and keeps the previous one too: the narrative is
+// optional and the report is written without a new one.
+func (g *Generator) narrative(ctx context.Context, slug, locale string, opts RunOptions, in *llm.NarrativeInput) (*datastore.Narrative, error) {
+	if locale != datastore.DefaultLocale {
+		return nil, nil
+	}
+	prev, err := g.store.LoadPost(slug)
+	if err != nil {
+		return nil, fmt.Errorf("loading post: %w", err)
+	}
+	var last *datastore.Narrative
+	if prev != nil {
+		last = prev.Narrative
+	}
+	if opts.Narrator == nil || (last != nil && last.Status == "published") {
+		return last, nil
+	}
+	if last != nil && last.Status == "draft" && !opts.RegenerateNarrative {
+		g.log.Info("编辑导语草稿待审核，保留（重新生成请加 --regenerate-narrative）", zap.String("slug", slug))
+		return last, nil
+	}
+	if opts.DryRun {
+		g.log.Info("dry-run: 跳过编辑导语生成", zap.String("slug", slug))
+		return last, nil
+	}
+
+	if last != nil && last.Status == "rejected" {
+		in.Feedback = last.ReviewNote
+	}
+	n, err := opts.Narrator.Narrate(ctx, in)
+	if err != nil {
+		g.log.Warn("编辑导语生成失败，文章不附带新导语", zap.String("slug", slug), zap.Error(err))
+		return last, nil
+	}
+	g.log.Info("编辑导语已生成，待审核",
+		zap.String("slug", slug),
+		zap.String("model", n.Model),
+		zap.Bool("feedback", in.Feedback != ""),
+	)
+	return n, nil
+}
+
+// shownNarrative is the narrative rendered into the post: none once it
+// was rejected.
+func shownNarrative(n *datastore.Narrative) *datastore.Narrative {
+	if n == nil || n.Status == "rejected" {
+		return nil
+	}
+	return n
+}
+
+// narrativeInput collects a report's top gainers, category shifts and
+// newcomers for the narrator. Projects without a summary take the one of
+// their published Chinese analysis.
+func (g *Generator) narrativeInput(kind, period, startDate, endDate string, total int, gainers []reportProject, shares []categoryShare, newcomers []reportProject) *llm.NarrativeInput {
+	in := &llm.NarrativeInput{
+		Kind:          kind,
+		Period:        period,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		TotalProjects: total,
+	}
+	for _, rp := range head(gainers, narrativeHighlights) {
+		in.TopGainers = append(in.TopGainers, g.narrativeProject(rp))
+	}
+	for _, rp := range head(newcomers, narrativeHighlights) {
+		in.Newcomers = append(in.Newcomers, g.narrativeProject(rp))
+	}
+	for _, cs := range shares {
+		if len(in.Categories) == narrativeCategories {
+			break
+		}
+		if cs.Delta != 0 {
+			in.Categories = append(in.Categories, llm.NarrativeCategory{Category: cs.Category, Before: cs.Before, After: cs.After})
+		}
+	}
+	return in
+}
+
+func (g *Generator) narrativeProject(rp reportProject) llm.NarrativeProject {
+	np := llm.NarrativeProject{
+		FullName: rp.FullName,
+		Category: rp.Category,
+		Summary:  rp.Summary,
+		Stars:    rp.Stars,
+		StarGain: rp.StarGain,
+		Rank:     rp.ToRank,
+	}
+	if np.Summary == "" {
+		if p, _ := g.store.LoadProject(rp.ProjectID); p != nil {
+			if a := p.PublishedAnalysis(datastore.DefaultLocale); a != nil {
+				np.Summary = a.Summary
+			}
+		}
+	}
+	return np
+}
+
+func head(ps []reportProject, n int) []reportProject {
+	if len(ps) > n {
+		return ps[:n]
+	}
+	return ps
+}
@@ -0,0 +1,209 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/llm"
+)
+
+// fakeNarrator returns a draft numbered by call, or err, and records its
+// inputs and the context of the last call.
+type fakeNarrator struct {
+	inputs []*llm.NarrativeInput
+	ctx    context.Context
+	err    error
+}
+
+func (f *fakeNarrator) Narrate(ctx context.Context, in *llm.NarrativeInput) (*datastore.Narrative, error) {
+	f.ctx = ctx
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.inputs = append(f.inputs, in)
+	return &datastore.Narrative{
+		Status:     "draft",
+		Model:      "fake",
+		Intro:      fmt.Sprintf("导语第%d版", len(f.inputs)),
+		Highlights: []datastore.NarrativeHighlight{{Project: "o/b", Take: "增长最快"}},
+	}, nil
+}
+
+func TestGenerateWeekly_Narrative(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	str := func(s string) *string { return &s }
+	item := func(rank int, name, cat string) datastore.RankingItem {
+		return datastore.RankingItem{Rank: rank, ProjectID: strings.ReplaceAll(name, "/", "__"), FullName: name,
+			Summary: str(name + " 摘要"), Category: str(cat)}
+	}
+	for _, r := range []*datastore.Ranking{
+		{Date: "2026-10-05", Items: []datastore.RankingItem{item(1, "o/a", "llm"), item(2, "o/b", "llm")}},
+		{Date: "2026-10-11", Items: []datastore.RankingItem{item(1, "o/b", "llm"), item(2, "o/a", "llm"), item(3, "o/new", "agent")}},
+	} {
+		r.Total = len(r.Items)
+		if err := store.SaveRanking(r); err != nil {
+			t.Fatalf("SaveRanking: %v", err)
+		}
+	}
+	for _, s := range []datastore.Snapshot{
+		{ProjectID: "o__b", Date: "2026-10-05", Stars: 500},
+		{ProjectID: "o__b", Date: "2026-10-11", Stars: 800},
+	} {
+		if err := store.AppendSnapshot(&s); err != nil {
+			t.Fatalf("AppendSnapshot: %v", err)
+		}
+	}
+
+	if err := store.SaveProject(&datastore.Project{ID: "o__b", FullName: "o/b",
+		Analysis: &datastore.Analysis{Status: "published", Summary: "o/b 分析摘要"}}); err != nil {
+		t.Fatalf("SaveProject: %v", err)
+	}
+
+	g := New(store, testLogger())
+	narrator := &fakeNarrator{}
+	opts := RunOptions{Week: "2026-W41", Narrator: narrator, Locales: []string{"zh", "en"}}
+	load := func() *datastore.Post {
+		t.Helper()
+		post, err := store.LoadPost("ai-weekly-2026-w41")
+		if err != nil || post == nil {
+			t.Fatalf("LoadPost: %v, %v", post, err)
+		}
+		return post
+	}
+
+	// A fresh draft holds the Chinese post back; the English one has none.
+	if err := g.Run(context.Background(), "weekly", opts); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	post := load()
+	if post.PublishedAt != nil || post.Narrative == nil || post.Narrative.Status != "draft" {
+		t.Fatalf("post = %+v, want unpublished with a draft narrative", post)
+	}
+	if !strings.HasPrefix(post.Content, "导语第1版\n\n## 编辑点评\n\n- **[o/b](/projects/o__b)**：增长最快\n\n## 本周概览") {
+		t.Errorf("content = %q", post.Content)
+	}
+	in := narrator.inputs[0]
+	if in.Kind != "weekly" || in.Period != "2026-W41" || len(in.TopGainers) != 1 || in.TopGainers[0].Rank != 1 ||
+		in.TopGainers[0].Summary != "o/b 分析摘要" || len(in.Newcomers) != 1 || in.Newcomers[0].FullName != "o/new" {
+		t.Errorf("input = %+v", in)
+	}
+	if len(in.Categories) != 2 || in.Categories[0].Category != "agent" {
+		t.Errorf("categories = %+v", in.Categories)
+	}
+	if en, _ := store.LoadPost("ai-weekly-2026-w41-en"); en == nil || en.Narrative != nil || en.PublishedAt == nil {
+		t.Errorf("en post = %+v, want published without narrative", en)
+	}
+	if len(narrator.inputs) != 1 {
+		t.Errorf("narrator called %d times, want once", len(narrator.inputs))
+	}
+
+	// Rejected: regenerating without a narrator publishes the post without it.
+	rv := datastore.Review{Reviewer: "alice", Reason: "太空泛", Time: time.Now().UTC()}
+	if _, err := store.SetNarrativeStatus(post, "rejected", rv); err != nil {
+		t.Fatalf("SetNarrativeStatus: %v", err)
+	}
+	if post = load(); post.PublishedAt != nil {
+		t.Errorf("rejected narrative published the post")
+	}
+	if err := g.Run(context.Background(), "weekly", RunOptions{Week: "2026-W41"}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if post = load(); post.PublishedAt == nil || strings.Contains(post.Content, "导语") || post.Narrative.Status != "rejected" {
+		t.Errorf("post = %+v, want published without the rejected narrative", post)
+	}
+
+	// With a narrator, the rejection reason goes into the new draft.
+	if err := g.Run(context.Background(), "weekly", RunOptions{Week: "2026-W41", Narrator: narrator}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if narrator.inputs[1].Feedback != "太空泛" {
+		t.Errorf("feedback = %q", narrator.inputs[1].Feedback)
+	}
+	if post = load(); post.PublishedAt != nil || post.Narrative.Intro != "导语第2版" {
+		t.Errorf("post = %+v, want unpublished with the new draft", post)
+	}
+
+	// Approved: the post is published and the narrative kept on regeneration.
+	if _, err := store.SetNarrativeStatus(post, "published", rv); err != nil {
+		t.Fatalf("SetNarrativeStatus: %v", err)
+	}
+	if err := g.Run(context.Background(), "weekly", RunOptions{Week: "2026-W41", Narrator: narrator}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(narrator.inputs) != 2 {
+		t.Errorf("narrator called again for a published narrative")
+	}
+	if post = load(); post.PublishedAt == nil || !strings.HasPrefix(post.Content, "导语第2版") {
+		t.Errorf("post = %+v, want published with the approved narrative", post)
+	}
+}
+
+// saveNarrativeRanking saves a one-project ranking for 2026-W41.
+func saveNarrativeRanking(t *testing.T, store *datastore.Store) {
+	t.Helper()
+	r := &datastore.Ranking{Date: "2026-10-11", Total: 1, Items: []datastore.RankingItem{{Rank: 1, ProjectID: "o__a", FullName: "o/a"}}}
+	if err := store.SaveRanking(r); err != nil {
+		t.Fatalf("SaveRanking: %v", err)
+	}
+}
+
+type ctxKey struct{}
+
+func TestGenerateWeekly_NarrativeUsesRunContext(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	saveNarrativeRanking(t, store)
+
+	narrator := &fakeNarrator{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "run")
+	if err := New(store, testLogger()).Run(ctx, "weekly", RunOptions{Week: "2026-W41", Narrator: narrator}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if narrator.ctx == nil || narrator.ctx.Value(ctxKey{}) != "run" {
+		t.Error("narrator should be called with the run's context")
+	}
+}
+
+func TestGenerateWeekly_NarrativeDraftKept(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	saveNarrativeRanking(t, store)
+	g := New(store, testLogger())
+	narrator := &fakeNarrator{}
+	opts := RunOptions{Week: "2026-W41", Narrator: narrator}
+
+	for i := 0; i < 2; i++ {
+		if err := g.Run(context.Background(), "weekly", opts); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	}
+	post, _ := store.LoadPost("ai-weekly-2026-w41")
+	if len(narrator.inputs) != 1 || post.Narrative.Intro != "导语第1版" {
+		t.Errorf("narrator called %d times, intro %q; want the pending draft kept", len(narrator.inputs), post.Narrative.Intro)
+	}
+
+	opts.RegenerateNarrative = true
+	if err := g.Run(context.Background(), "weekly", opts); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if post, _ = store.LoadPost("ai-weekly-2026-w41"); post.Narrative.Intro != "导语第2版" || post.PublishedAt != nil {
+		t.Errorf("post = %+v, want the draft regenerated and still held", post)
+	}
+}
+
+func TestGenerateWeekly_NarrativeFailureStillWritesPost(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	saveNarrativeRanking(t, store)
+
+	narrator := &fakeNarrator{err: errors.New("budget exceeded")}
+	if err := New(store, testLogger()).Run(context.Background(), "weekly", RunOptions{Week: "2026-W41", Narrator: narrator}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	post, err := store.LoadPost("ai-weekly-2026-w41")
+	if err != nil || post == nil || post.Narrative != nil || post.PublishedAt == nil {
+		t.Errorf("post = %+v, %v; want published without a narrative", post, err)
+	}
+}
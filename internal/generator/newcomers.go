@@ -0,0 +1,148 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/timespan"
+)
+
+// ── Newcomers ──────────────────────────────────────────────────
+
+// DefaultNewcomersSince is the newcomers window when none is given.
+const DefaultNewcomersSince = "3d"
+
+// newcomerFeatures caps the features shown on each card.
+const newcomerFeatures = 3
+
+type newcomersData struct {
+	StartDate string
+	EndDate   string
+	Projects  []newcomer
+}
+
+type newcomer struct {
+	FullName  string
+	ProjectID string
+	Summary   string
+	Stars     int
+	Language  string
+	Category  string
+	Features  []datastore.Feature
+}
+
+const newcomersTemplate = "{{.StartDate}} ~ {{.EndDate}} 新收录 {{len .Projects}} 个已完成分析的 AI 项目，按评分排序。\n" +
+	"{{range .Projects}}\n" +
+	"## [{{.FullName}}](/projects/{{.ProjectID}})\n\n" +
+	"> {{.Summary}}\n\n" +
+	"Stars: {{.Stars}} | Language: {{.Language}} | Category: {{.Category}}\n" +
+	"{{if .Features}}\n{{range .Features}}- **{{.Name}}**: {{.Desc}}\n{{end}}{{end}}" +
+	"{{end}}"
+
+const newcomersTemplateEN = "{{len .Projects}} newly tracked AI projects with a published analysis, {{.StartDate}} ~ {{.EndDate}}, ordered by score.\n" +
+	"{{range .Projects}}\n" +
+	"## [{{.FullName}}](/projects/{{.ProjectID}})\n\n" +
+	"> {{.Summary}}\n\n" +
+	"Stars: {{.Stars}} | Language: {{.Language}} | Category: {{.Category}}\n" +
+	"{{if .Features}}\n{{range .Features}}- **{{.Name}}**: {{.Desc}}\n{{end}}{{end}}" +
+	"{{end}}"
+
+func (g *Generator) generateNewcomers(ctx context.Context, opts RunOptions, locale string) error {
+	since := opts.Since
+	if since == "" {
+		since = DefaultNewcomersSince
+	}
+	window, err := timespan.Parse(since)
+	if err != nil {
+		return fmt.Errorf("时间窗口: %w", err)
+	}
+	now := time.Now().UTC()
+	from := now.Add(-window)
+
+	projects, err := g.store.ListProjects()
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	var picked []*datastore.Project
+	for _, p := range projects {
+		if p.FirstSeenAt.Before(from) || p.FirstSeenAt.After(now) || p.PublishedAnalysis(locale) == nil {
+			continue
+		}
+		picked = append(picked, p)
+	}
+	if len(picked) == 0 || len(picked) < opts.MinNewcomers {
+		g.log.Info("新项目不足，跳过新项目速递",
+			zap.Int("count", len(picked)),
+			zap.Int("min", opts.MinNewcomers),
+			zap.String("since", since),
+			zap.String("locale", locale),
+		)
+		return nil
+	}
+	sort.SliceStable(picked, func(i, j int) bool {
+		if picked[i].Score != picked[j].Score {
+			return picked[i].Score > picked[j].Score
+		}
+		return picked[i].FullName < picked[j].FullName
+	})
+
+	data := newcomersData{
+		StartDate: from.Format("2006-01-02"),
+		EndDate:   now.Format("2006-01-02"),
+	}
+	for _, p := range picked {
+		a := p.PublishedAnalysis(locale)
+		nc := newcomer{
+			FullName:  p.FullName,
+			ProjectID: p.ID,
+			Summary:   a.Summary,
+			Stars:     p.Stars,
+			Features:  a.Features,
+		}
+		if len(nc.Features) > newcomerFeatures {
+			nc.Features = nc.Features[:newcomerFeatures]
+		}
+		if p.Language != nil {
+			nc.Language = *p.Language
+		}
+		if p.Category != nil {
+			nc.Category = *p.Category
+		}
+		data.Projects = append(data.Projects, nc)
+	}
+
+	slug := localizeSlug("ai-newcomers-"+data.EndDate, locale)
+	text := postTexts[locale]
+
+	content, err := g.render("newcomers", locale, opts, data)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf(text.newcomersTitle, data.StartDate, data.EndDate)
+
+	if opts.DryRun {
+		g.log.Info("dry-run", zap.String("slug", slug), zap.String("title", title), zap.String("locale", locale))
+		fmt.Println(content)
+		return nil
+	}
+
+	post := &datastore.Post{
+		Slug:     slug,
+		Title:    title,
+		Content:  content,
+		PostType: "newcomers",
+		Locale:   postLocale(locale),
+	}
+	if err := g.savePost(post, now); err != nil {
+		return err
+	}
+
+	g.log.Info("新项目速递生成完成", zap.String("slug", slug), zap.Int("projects", len(picked)), zap.String("locale", locale))
+	return nil
+}
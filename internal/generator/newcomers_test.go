@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func TestGenerateNewcomers(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	now := time.Now().UTC()
+	lang, cat := "Python", "agent"
+	features := []datastore.Feature{{Name: "f1", Desc: "d1"}, {Name: "f2", Desc: "d2"}, {Name: "f3", Desc: "d3"}, {Name: "f4", Desc: "d4"}}
+
+	for _, p := range []*datastore.Project{
+		{ID: "o__low", FullName: "o/low", Score: 40, Stars: 100, FirstSeenAt: now.Add(-time.Hour),
+			Analysis: &datastore.Analysis{Status: "published", Summary: "低分项目", Features: features[:1]}},
+		{ID: "o__high", FullName: "o/high", Score: 90, Stars: 800, Language: &lang, Category: &cat, FirstSeenAt: now.Add(-48 * time.Hour),
+			Analysis: &datastore.Analysis{Status: "published", Summary: "高分项目", Features: features}},
+		{ID: "o__draft", FullName: "o/draft", Score: 99, FirstSeenAt: now.Add(-time.Hour),
+			Analysis: &datastore.Analysis{Status: "draft", Summary: "草稿"}},
+		{ID: "o__old", FullName: "o/old", Score: 95, FirstSeenAt: now.Add(-10 * 24 * time.Hour),
+			Analysis: &datastore.Analysis{Status: "published", Summary: "老项目"}},
+	} {
+		if err := store.SaveProject(p); err != nil {
+			t.Fatalf("SaveProject: %v", err)
+		}
+	}
+
+	g := New(store, testLogger())
+
+	// Two qualify, fewer than the minimum: no post.
+	if err := g.Run(context.Background(), "newcomers", RunOptions{Since: "3d", MinNewcomers: 3}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if posts, _ := store.ListPosts(); len(posts) != 0 {
+		t.Fatalf("got %d posts, want none below the minimum", len(posts))
+	}
+
+	if err := g.Run(context.Background(), "newcomers", RunOptions{Since: "3d", MinNewcomers: 2}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	post, err := store.LoadPost("ai-newcomers-" + now.Format("2006-01-02"))
+	if err != nil || post == nil {
+		t.Fatalf("LoadPost: %v, %v", post, err)
+	}
+	if post.PostType != "newcomers" || !strings.HasPrefix(post.Title, "新项目速递 | ") {
+		t.Errorf("post = %+v", post)
+	}
+	c := post.Content
+	high, low := strings.Index(c, "## [o/high](/projects/o__high)"), strings.Index(c, "## [o/low]")
+	if high < 0 || low < high {
+		t.Errorf("want o/high before o/low:\n%s", c)
+	}
+	for _, want := range []string{"> 高分项目", "Stars: 800 | Language: Python | Category: agent", "- **f3**: d3"} {
+		if !strings.Contains(c, want) {
+			t.Errorf("content missing %q:\n%s", want, c)
+		}
+	}
+	for _, unwanted := range []string{"f4", "o/draft", "o/old"} {
+		if strings.Contains(c, unwanted) {
+			t.Errorf("content contains %q:\n%s", unwanted, c)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// ── Report Periods ─────────────────────────────────────────────
+
+// reportTopN caps the star-gainer and rank-movement tables.
+const reportTopN = 10
+
+// period holds the rankings and snapshots of a date range. Entries and
+// exits are measured against the last ranking before the range when there
+// is one, else against the range's first ranking; rank movement compares
+// the range's first and last ranking.
+type period struct {
+	days              int // rankings in the range
+	base, first, last *datastore.Ranking
+	items             map[string]datastore.RankingItem // latest entry per project
+	gains             []starGain                       // largest first
+	forks             map[string]int                   // from the latest snapshot in the range
+}
+
+// reportProject is a project row in a weekly or monthly report.
+type reportProject struct {
+	FullName  string
+	ProjectID string
+	Language  string
+	Category  string
+	Summary   string
+	Stars     int
+	Forks     int
+	StarGain  int
+	FromRank  int
+	ToRank    int
+	Move      int // ranks climbed or fallen
+}
+
+type starGain struct {
+	id    string
+	stars int
+	forks int
+	gain  int
+}
+
+// loadPeriod loads the rankings and snapshots from start to end inclusive.
+// label names the range in the error when it has no rankings.
+func (g *Generator) loadPeriod(start, end time.Time, label string) (*period, error) {
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+	dates, err := g.store.ListRankingDates()
+	if err != nil {
+		return nil, fmt.Errorf("listing rankings: %w", err)
+	}
+	var inRange []string
+	baseDate := ""
+	for _, d := range dates {
+		switch {
+		case d >= from && d <= to:
+			inRange = append(inRange, d)
+		case d < from:
+			baseDate = d
+		}
+	}
+	if len(inRange) == 0 {
+		return nil, fmt.Errorf("没有 %s 的排行数据，请先运行 tishi score", label)
+	}
+
+	pd := &period{days: len(inRange)}
+	if pd.first, err = g.store.LoadRanking(inRange[0]); err != nil {
+		return nil, fmt.Errorf("loading ranking: %w", err)
+	}
+	if pd.last, err = g.store.LoadRanking(inRange[len(inRange)-1]); err != nil {
+		return nil, fmt.Errorf("loading ranking: %w", err)
+	}
+	pd.base = pd.first
+	if baseDate != "" {
+		if pd.base, err = g.store.LoadRanking(baseDate); err != nil {
+			return nil, fmt.Errorf("loading ranking: %w", err)
+		}
+	}
+
+	pd.items = make(map[string]datastore.RankingItem)
+	for _, r := range []*datastore.Ranking{pd.base, pd.first, pd.last} {
+		for _, it := range r.Items {
+			pd.items[it.ProjectID] = it
+		}
+	}
+
+	if pd.gains, err = g.starGains(start, end); err != nil {
+		return nil, err
+	}
+	pd.forks = make(map[string]int, len(pd.gains))
+	for _, sg := range pd.gains {
+		pd.forks[sg.id] = sg.forks
+	}
+	return pd, nil
+}
+
+// starGains compares each project's stars at the end of the range with
+// its snapshot from the day before the range, or its first snapshot in the
+// range when it has none, and keeps its forks at the end. Largest gain
+// first.
+func (g *Generator) starGains(start, end time.Time) ([]starGain, error) {
+	firstStars := make(map[string]int)
+	lastStars := make(map[string]int)
+	lastForks := make(map[string]int)
+	for d := start.AddDate(0, 0, -1); !d.After(end); d = d.AddDate(0, 0, 1) {
+		snaps, err := g.store.LoadSnapshots(d.Format("2006-01-02"))
+		if err != nil {
+			return nil, fmt.Errorf("loading snapshots: %w", err)
+		}
+		for _, s := range snaps {
+			if _, ok := firstStars[s.ProjectID]; !ok {
+				firstStars[s.ProjectID] = s.Stars
+			}
+			if !d.Before(start) {
+				lastStars[s.ProjectID] = s.Stars
+				lastForks[s.ProjectID] = s.Forks
+			}
+		}
+	}
+
+	gains := make([]starGain, 0, len(lastStars))
+	for id, stars := range lastStars {
+		gains = append(gains, starGain{id: id, stars: stars, forks: lastForks[id], gain: stars - firstStars[id]})
+	}
+	sort.Slice(gains, func(i, j int) bool {
+		if gains[i].gain != gains[j].gain {
+			return gains[i].gain > gains[j].gain
+		}
+		return gains[i].id < gains[j].id
+	})
+	return gains, nil
+}
+
+// project describes id by its latest ranking entry and, for forks, its
+// latest snapshot in the range, so regenerated reports keep their numbers.
+func (pd *period) project(id string) reportProject {
+	it := pd.items[id]
+	rp := reportProject{FullName: it.FullName, ProjectID: id, Stars: it.Stars, Forks: pd.forks[id]}
+	if it.Language != nil {
+		rp.Language = *it.Language
+	}
+	if it.Category != nil {
+		rp.Category = *it.Category
+	}
+	return rp
+}
+
+// topGainers lists the ranked projects that gained the most stars, with
+// their rank on the last ranking (0 when they dropped off).
+func (pd *period) topGainers() []reportProject {
+	lastRank := rankOf(pd.last)
+	var out []reportProject
+	for _, sg := range pd.gains {
+		if len(out) == reportTopN || sg.gain <= 0 {
+			break
+		}
+		if _, ok := pd.items[sg.id]; !ok {
+			continue
+		}
+		rp := pd.project(sg.id)
+		rp.StarGain, rp.Stars = sg.gain, sg.stars
+		rp.ToRank = lastRank[sg.id]
+		out = append(out, rp)
+	}
+	return out
+}
+
+// movers lists the projects on both the first and last ranking that
+// climbed (up) or fell the most.
+func (pd *period) movers(up bool) []reportProject {
+	firstRank := rankOf(pd.first)
+	var out []reportProject
+	for _, it := range pd.last.Items {
+		from, ok := firstRank[it.ProjectID]
+		if !ok || from == it.Rank || (from > it.Rank) != up {
+			continue
+		}
+		rp := pd.project(it.ProjectID)
+		rp.FromRank, rp.ToRank = from, it.Rank
+		rp.Move = from - it.Rank
+		if !up {
+			rp.Move = -rp.Move
+		}
+		out = append(out, rp)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Move > out[j].Move })
+	if len(out) > reportTopN {
+		out = out[:reportTopN]
+	}
+	return out
+}
+
+// entries returns the last ranking's items that were not on the base
+// ranking, in rank order.
+func (pd *period) entries() []datastore.RankingItem {
+	baseRank := rankOf(pd.base)
+	var out []datastore.RankingItem
+	for _, it := range pd.last.Items {
+		if _, ok := baseRank[it.ProjectID]; !ok {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// exits lists the base ranking's projects missing from the last ranking.
+func (pd *period) exits() []reportProject {
+	lastRank := rankOf(pd.last)
+	var out []reportProject
+	for _, it := range pd.base.Items {
+		if _, ok := lastRank[it.ProjectID]; !ok {
+			rp := pd.project(it.ProjectID)
+			rp.FromRank = it.Rank
+			out = append(out, rp)
+		}
+	}
+	return out
+}
+
+func rankOf(r *datastore.Ranking) map[string]int {
+	m := make(map[string]int, len(r.Items))
+	for _, it := range r.Items {
+		m[it.ProjectID] = it.Rank
+	}
+	return m
+}
@@ -0,0 +1,254 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// Post templates are text/template files under generator.templates_dir,
+// named after the post type:
+//
+//	templates/weekly.tmpl     Chinese weekly report
+//	templates/en/weekly.tmpl  English weekly report; other locales alike
+//
+// A post type without a file uses the built-in template. Templates may use
+// the helpers in templateFuncs; tishi generate <type> --check renders a
+// template against sample data.
+
+// builtinSource names the built-in templates in logs and check output.
+const builtinSource = "内置模板"
+
+// templateFuncs are the helpers available to post templates in locale.
+func templateFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"inc":            func(i int) int { return i + 1 },
+		"num":            func(n int) string { return formatNumber(n, locale) },
+		"date":           formatDate,
+		"truncate":       truncate,
+		"projectLink":    projectLink,
+		"comparisonLink": comparisonLink,
+	}
+}
+
+// formatNumber abbreviates n: 1.2万 and 3.4亿 in Chinese, 12.3k and 4.5M
+// in other locales. Smaller numbers are printed as is.
+func formatNumber(n int, locale string) string {
+	type unit struct {
+		size   float64
+		suffix string
+	}
+	units := []unit{{1e8, "亿"}, {1e4, "万"}}
+	if locale != datastore.DefaultLocale {
+		units = []unit{{1e6, "M"}, {1e3, "k"}}
+	}
+	f, sign := float64(n), ""
+	if f < 0 {
+		f, sign = -f, "-"
+	}
+	for _, u := range units {
+		if f >= u.size {
+			s := strconv.FormatFloat(math.Floor(f/u.size*10)/10, 'f', 1, 64)
+			return sign + strings.TrimSuffix(s, ".0") + u.suffix
+		}
+	}
+	return strconv.Itoa(n)
+}
+
+// formatDate formats a time.Time, *time.Time or YYYY-MM-DD / RFC 3339
+// string with a Go time layout. Zero and nil values give "".
+func formatDate(layout string, v any) (string, error) {
+	var t time.Time
+	switch v := v.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v != nil {
+			t = *v
+		}
+	case string:
+		if v == "" {
+			return "", nil
+		}
+		var err error
+		if t, err = time.Parse("2006-01-02", v); err != nil {
+			if t, err = time.Parse(time.RFC3339, v); err != nil {
+				return "", fmt.Errorf("date: 无法解析 %q", v)
+			}
+		}
+	default:
+		return "", fmt.Errorf("date: 不支持的类型 %T", v)
+	}
+	if t.IsZero() {
+		return "", nil
+	}
+	return t.Format(layout), nil
+}
+
+// truncate shortens s to n characters, ending in "…" when cut.
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+// projectLink renders owner/repo as a Markdown link to its project page.
+func projectLink(fullName string) string {
+	return fmt.Sprintf("[%s](/projects/%s)", fullName, datastore.ProjectIDFromFullName(fullName))
+}
+
+func parseTemplate(postType, locale, text string) (*template.Template, error) {
+	t, err := template.New(postType).Funcs(templateFuncs(locale)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s template: %w", postType, err)
+	}
+	return t, nil
+}
+
+// loadTemplate returns the template for postType in locale and where it
+// came from: opts.Template when set, else the file in opts.TemplatesDir,
+// else the built-in template.
+func loadTemplate(postType, locale string, opts RunOptions) (*template.Template, string, error) {
+	path := opts.Template
+	if path == "" && opts.TemplatesDir != "" {
+		dir := opts.TemplatesDir
+		if locale != datastore.DefaultLocale {
+			dir = filepath.Join(dir, locale)
+		}
+		candidate := filepath.Join(dir, postType+".tmpl")
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+		} else if !os.IsNotExist(err) {
+			return nil, "", fmt.Errorf("reading template %s: %w", candidate, err)
+		}
+	}
+
+	if path == "" {
+		t, err := parseTemplate(postType, locale, postTexts[locale].templates[postType])
+		return t, builtinSource, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading template %s: %w", path, err)
+	}
+	t, err := parseTemplate(postType, locale, string(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", path, err)
+	}
+	return t, path, nil
+}
+
+// render executes the template for postType in locale with data.
+func (g *Generator) render(postType, locale string, opts RunOptions, data any) (string, error) {
+	t, source, err := loadTemplate(postType, locale, opts)
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("rendering %s (%s): %w", postType, source, err)
+	}
+	if source != builtinSource {
+		g.log.Info("使用自定义模板", zap.String("type", postType), zap.String("locale", locale), zap.String("template", source))
+	}
+	return buf.String(), nil
+}
+
+// Check renders the template for postType in each of opts.Locales against
+// sample data and prints the result. It reads no data and writes nothing.
+func (g *Generator) Check(postType string, opts RunOptions) error {
+	if _, ok := postTexts[datastore.DefaultLocale].templates[postType]; !ok {
+		return fmt.Errorf("unsupported post type: %s (use weekly, monthly, spotlight or newcomers)", postType)
+	}
+	locales, err := runLocales(opts)
+	if err != nil {
+		return err
+	}
+	for _, locale := range locales {
+		t, source, err := loadTemplate(postType, locale, opts)
+		if err != nil {
+			return fmt.Errorf("模板校验失败: %w", err)
+		}
+		var buf strings.Builder
+		if err := t.Execute(&buf, sampleData(postType)); err != nil {
+			return fmt.Errorf("模板校验失败 (%s, %s): %w", source, locale, err)
+		}
+		fmt.Printf("── %s / %s: %s ──\n%s\n", postType, locale, source, buf.String())
+	}
+	return nil
+}
+
+// sampleData is representative data for checking postType's template.
+func sampleData(postType string) any {
+	project := reportProject{
+		FullName: "langchain-ai/langchain", ProjectID: "langchain-ai__langchain",
+		Language: "Python", Category: "llm", Summary: "构建 LLM 应用的开发框架",
+		Stars: 98000, Forks: 15800, StarGain: 1250, FromRank: 5, ToRank: 2, Move: 3,
+	}
+	faller := project
+	faller.FullName, faller.ProjectID, faller.FromRank, faller.ToRank, faller.Move = "ollama/ollama", "ollama__ollama", 1, 4, 3
+	features := []datastore.Feature{
+		{Name: "链式调用", Desc: "组合模型、工具与检索"},
+		{Name: "Agent", Desc: "工具调用与多步推理"},
+		{Name: "集成", Desc: "数百种模型与向量库"},
+	}
+	narrative := &datastore.Narrative{
+		Status: "draft",
+		Intro:  "本期 LLM 应用框架继续领涨，Agent 类项目占比明显上升。",
+		Highlights: []datastore.NarrativeHighlight{
+			{Project: project.FullName, Take: "生态优势仍在扩大，新版本的 Agent 能力值得关注。"},
+		},
+	}
+
+	switch postType {
+	case "weekly":
+		return weeklyData{
+			Year: 2026, WeekNum: 41, StartDate: "2026-10-05", EndDate: "2026-10-11",
+			Days: 7, TotalProjects: 100, NewEntries: 1,
+			TopGainers: []reportProject{project}, Climbers: []reportProject{project}, Fallers: []reportProject{faller},
+			NewProjects: []reportProject{project}, Dropped: []reportProject{faller},
+			Narrative: narrative,
+		}
+	case "monthly":
+		return monthlyData{
+			Month: "2026-10", StartDate: "2026-10-01", EndDate: "2026-10-31",
+			Days: 31, TotalProjects: 100,
+			TopGainers: []reportProject{project}, Climbers: []reportProject{project},
+			Categories:  []categoryShare{{Category: "llm", Before: 30, After: 32.5, Delta: 2.5}},
+			NewEntrants: []reportProject{project}, Dropped: []reportProject{faller},
+			Spotlights: []monthlySpotlight{{Title: "项目深度解读：langchain-ai/langchain", Slug: "spotlight-langchain-ai-langchain"}},
+			Narrative:  narrative,
+		}
+	case "spotlight":
+		return spotlightData{
+			FullName: project.FullName, Summary: project.Summary,
+			Positioning: "面向开发者的 LLM 应用框架", Features: features,
+			Advantages: "生态完善", TechStack: "Python, Pydantic", UseCases: "RAG、Agent",
+			Comparison: []datastore.ComparisonEntry{
+				{Project: "LlamaIndex", ProjectID: "run-llama__llama_index", Status: datastore.ComparisonTracked, Diff: "更侧重数据索引"},
+			},
+			Ecosystem: "LLM 应用开发的事实标准之一",
+			Similar:   []datastore.SimilarProject{{ProjectID: "run-llama__llama_index", FullName: "run-llama/llama_index", Score: 0.9}},
+		}
+	case "newcomers":
+		return newcomersData{
+			StartDate: "2026-10-16", EndDate: "2026-10-19",
+			Projects: []newcomer{{
+				FullName: project.FullName, ProjectID: project.ProjectID, Summary: project.Summary,
+				Stars: project.Stars, Language: "Python", Category: "llm", Features: features,
+			}},
+		}
+	}
+	return nil
+}
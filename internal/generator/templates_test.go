@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func TestBuiltinTemplates_SampleData(t *testing.T) {
+	g := New(datastore.NewStore(t.TempDir(), testLogger()), testLogger())
+	for postType := range postTexts[datastore.DefaultLocale].templates {
+		if err := g.Check(postType, RunOptions{Locales: []string{"zh", "en"}}); err != nil {
+			t.Errorf("Check(%s): %v", postType, err)
+		}
+	}
+	if err := g.Check("daily", RunOptions{}); err == nil {
+		t.Error("expected error for unknown post type")
+	}
+}
+
+func TestCheck_CustomTemplate(t *testing.T) {
+	dir := t.TempDir()
+	g := New(datastore.NewStore(t.TempDir(), testLogger()), testLogger())
+
+	good := filepath.Join(dir, "good.tmpl")
+	_ = os.WriteFile(good, []byte("{{range .TopGainers}}{{projectLink .FullName}} {{num .Stars}}{{end}}"), 0o644)
+	if err := g.Check("weekly", RunOptions{Template: good}); err != nil {
+		t.Errorf("Check(good): %v", err)
+	}
+
+	for name, text := range map[string]string{
+		"syntax.tmpl": "{{range .TopGainers}}",
+		"field.tmpl":  "{{.Nope}}",
+		"func.tmpl":   "{{shout .Month}}",
+	} {
+		path := filepath.Join(dir, name)
+		_ = os.WriteFile(path, []byte(text), 0o644)
+		if err := g.Check("weekly", RunOptions{Template: path}); err == nil {
+			t.Errorf("Check(%s) succeeded, want error", name)
+		}
+	}
+}
+
+func TestGenerateWeekly_TemplatesDir(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	today := time.Now().UTC().Format("2006-01-02")
+	if err := store.SaveRanking(&datastore.Ranking{Date: today, Total: 1, Items: []datastore.RankingItem{
+		{Rank: 1, ProjectID: "a__b", FullName: "a/b", Stars: 12345},
+	}}); err != nil {
+		t.Fatalf("SaveRanking: %v", err)
+	}
+
+	dir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(dir, "en"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, "weekly.tmpl"), []byte("自定义周报 #{{.WeekNum}}，{{.TotalProjects}} 个项目"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "en", "weekly.tmpl"), []byte("Custom weekly, {{.TotalProjects}} projects"), 0o644)
+
+	g := New(store, testLogger())
+	opts := RunOptions{TemplatesDir: dir, Locales: []string{"zh", "en"}}
+	if err := g.Run(context.Background(), "weekly", opts); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	posts, _ := store.ListPosts()
+	contents := make(map[string]string)
+	for _, p := range posts {
+		contents[p.Locale] = p.Content
+	}
+	if !strings.HasPrefix(contents[""], "自定义周报 #") || contents["en"] != "Custom weekly, 1 projects" {
+		t.Errorf("contents = %q", contents)
+	}
+
+	// A directory without the post type's file falls back to the built-in template.
+	if err := g.Run(context.Background(), "weekly", RunOptions{TemplatesDir: t.TempDir()}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	posts, _ = store.ListPosts()
+	for _, p := range posts {
+		if p.Locale == "" && !strings.HasPrefix(p.Content, "## 本周概览") {
+			t.Errorf("fallback content = %q", p.Content)
+		}
+	}
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	for _, c := range []struct {
+		n      int
+		locale string
+		want   string
+	}{
+		{980, "zh", "980"},
+		{12345, "zh", "1.2万"},
+		{10000, "zh", "1万"},
+		{250000000, "zh", "2.5亿"},
+		{980, "en", "980"},
+		{12345, "en", "12.3k"},
+		{4560000, "en", "4.5M"},
+		{-1500, "en", "-1.5k"},
+	} {
+		if got := formatNumber(c.n, c.locale); got != c.want {
+			t.Errorf("formatNumber(%d, %s) = %q, want %q", c.n, c.locale, got, c.want)
+		}
+	}
+
+	if got := truncate(4, "深度解读项目"); got != "深度解读…" {
+		t.Errorf("truncate = %q", got)
+	}
+	if got := truncate(10, "short"); got != "short" {
+		t.Errorf("truncate = %q", got)
+	}
+	if got := projectLink("owner/repo"); got != "[owner/repo](/projects/owner__repo)" {
+		t.Errorf("projectLink = %q", got)
+	}
+
+	ts := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	for _, v := range []any{ts, &ts, "2026-10-19", "2026-10-19T08:00:00Z"} {
+		if got, err := formatDate("01/02", v); err != nil || got != "10/19" {
+			t.Errorf("formatDate(%v) = %q, %v", v, got, err)
+		}
+	}
+	var nilTime *time.Time
+	if got, err := formatDate("01/02", nilTime); err != nil || got != "" {
+		t.Errorf("formatDate(nil) = %q, %v", got, err)
+	}
+	if _, err := formatDate("01/02", "yesterday"); err == nil {
+		t.Error("expected error for unparseable date")
+	}
+}
@@ -2,8 +2,16 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v67/github"
@@ -12,52 +20,117 @@ import (
 
 	"github.com/zbb88888/tishi/internal/config"
 	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/techstack"
 )
 
 // Analyzer orchestrates LLM analysis for projects that need it.
 type Analyzer struct {
-	store  *datastore.Store
-	client *Client
-	gh     *github.Client
-	log    *zap.Logger
-	cfg    config.LLMConfig
+	store   *datastore.Store
+	client  *Client
+	prompts *PromptSet
+	gh      *github.Client
+	log     *zap.Logger
+	cfg     config.LLMConfig
+	out     io.Writer  // dry-run prompts and replayed analyses
+	mu      sync.Mutex // serializes project updates when several locales finish at once
+
+	resolver *ComparisonResolver // set per run; links comparison entries
+	ledger   *UsageLedger        // nil when replaying: nothing is spent
+	now      func() time.Time    // nil = wall clock; a Recorder's clock when replaying
+	replay   bool                // nothing is written; analyses are printed to out
 }
 
 // NewAnalyzer creates an Analyzer with LLM client and GitHub client for README fetching.
-func NewAnalyzer(store *datastore.Store, llmCfg config.LLMConfig, ghToken string, log *zap.Logger) (*Analyzer, error) {
-	client, err := NewClient(llmCfg, log)
+func NewAnalyzer(store *datastore.Store, llmCfg config.LLMConfig, ghToken string, log *zap.Logger, opts ...Option) (*Analyzer, error) {
+	o := buildOptions(opts)
+	client, err := NewClient(llmCfg, log, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("creating LLM client: %w", err)
 	}
 
-	var gh *github.Client
-	if ghToken != "" {
-		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: ghToken})
-		gh = github.NewClient(oauth2.NewClient(context.Background(), ts))
-	} else {
-		gh = github.NewClient(nil)
+	prompts, err := LoadPrompts(llmCfg.PromptsDir, llmCfg.PromptVariants)
+	if err != nil {
+		return nil, fmt.Errorf("loading prompts: %w", err)
 	}
+	log.Debug("已加载分析 prompt", zap.Strings("prompts", prompts.Names()))
 
-	return &Analyzer{
-		store:  store,
-		client: client,
-		gh:     gh,
-		log:    log.Named("analyzer"),
-		cfg:    llmCfg,
-	}, nil
+	a := &Analyzer{
+		store:   store,
+		client:  client,
+		prompts: prompts,
+		gh:      newGitHubClient(ghToken, o),
+		log:     log.Named("analyzer"),
+		cfg:     llmCfg,
+		out:     os.Stdout,
+		now:     o.clock(),
+		replay:  o.offline(),
+	}
+	if !o.offline() {
+		a.ledger = NewUsageLedger(store, "analyze", NewPriceTable(llmCfg.Prices), log.Named("usage"))
+	}
+	return a, nil
+}
+
+// newGitHubClient creates a GitHub client, unauthenticated when token is
+// empty.
+func newGitHubClient(token string, o options) *github.Client {
+	hc := &http.Client{Transport: o.transport()}
+	if token == "" {
+		return github.NewClient(hc)
+	}
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, hc)
+	return github.NewClient(oauth2.NewClient(ctx, ts))
+}
+
+// clock returns the current time, from a.now when set.
+func (a *Analyzer) clock() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now().UTC()
 }
 
 // RunOptions configures an analysis run.
 type RunOptions struct {
-	ProjectID string // empty = all eligible projects
-	Force     bool   // force re-analyze even if analysis exists
-	DryRun    bool   // print prompts only, don't call LLM
+	ProjectID string   // empty = all eligible projects
+	Force     bool     // force re-analyze even if analysis exists
+	DryRun    bool     // print prompts only, don't call LLM
+	Locales   []string // output locales; empty = llm.locales
+
+	// AfterRun is called with the analyses generated by a run that was not
+	// a dry run, after all calls finished; its error fails the run.
+	AfterRun func(generated []Generated) error
+
+	// FeedbackFromReview regenerates the project's rejected analyses with
+	// the reviewer's rejection reason added to the prompt. Needs ProjectID.
+	FeedbackFromReview bool
+
+	// Overrides for llm.concurrency / llm.budget; zero = use config.
+	Concurrency  int
+	BudgetTokens int
+	BudgetCNY    float64
+}
+
+// Generated is a project and locale whose analysis was generated in a
+// run; the new analysis is the project's pending one in that locale.
+type Generated struct {
+	Project *datastore.Project
+	Locale  string
 }
 
-// Run executes the analysis pipeline.
+// Run executes the analysis pipeline. Candidates are analyzed concurrently
+// in priority order (rank, then score). When the token or cost budget is
+// reached no new projects are started; in-flight calls finish and the
+// remaining candidates are reported. A replay run writes nothing: its
+// analyses are printed instead and AfterRun is not called.
 func (a *Analyzer) Run(ctx context.Context, opts RunOptions) error {
 	start := time.Now()
 
+	if opts.FeedbackFromReview && opts.ProjectID == "" {
+		return fmt.Errorf("--feedback-from-review 需要配合 --id 指定项目")
+	}
+
 	var projects []*datastore.Project
 
 	if opts.ProjectID != "" {
@@ -76,97 +149,605 @@ func (a *Analyzer) Run(ctx context.Context, opts RunOptions) error {
 		projects = all
 	}
 
-	// Filter projects that need analysis
-	var candidates []*datastore.Project
-	for _, p := range projects {
-		if opts.Force || needsAnalysis(p) {
-			candidates = append(candidates, p)
+	locales, err := a.locales(opts.Locales)
+	if err != nil {
+		return err
+	}
+
+	sortByPriority(projects)
+	concurrency := firstPositive(opts.Concurrency, a.cfg.Concurrency, 1)
+
+	// Fetch current inputs and decide per project
+	maxAge := a.cfg.ReanalyzeMaxAge
+	if maxAge <= 0 {
+		maxAge = defaultReanalyzeMaxAge
+	}
+	now := a.clock()
+
+	cats, err := a.store.LoadCategories()
+	if err != nil {
+		a.log.Warn("分类加载失败，prompt 中不含分类信息", zap.Error(err))
+	}
+
+	prepared := a.prepare(ctx, projects, cats, locales, concurrency)
+	var candidates, stale []*candidate
+	for _, c := range prepared {
+		if c.err != nil {
+			a.log.Warn("prompt 渲染失败，跳过", zap.String("project", c.p.FullName), zap.String("locale", c.locale), zap.Error(c.err))
+			continue
+		}
+		d := decision{Reason: "README 获取失败，跳过新鲜度判断"}
+		if c.hash != "" {
+			d = decide(c.p, c.locale, c.hash, maxAge, now)
+		}
+		if opts.Force {
+			d.Analyze = true
+			d.Reason = "--force"
+		}
+		if opts.FeedbackFromReview {
+			if !a.applyFeedback(c) {
+				continue
+			}
+			d.Analyze = true
+			d.Reason = "review feedback"
+		}
+		c.decision = d
+
+		if d.Analyze {
+			candidates = append(candidates, c)
+		}
+		if d.Stale {
+			stale = append(stale, c)
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	a.refreshTechStacks(ctx, candidates, concurrency, opts.DryRun || a.replay)
+
+	if opts.DryRun {
+		for _, c := range prepared {
+			if c.err != nil {
+				continue
+			}
+			d := c.decision
+			action := "skip"
+			switch {
+			case d.Analyze && d.Stale:
+				action = "analyze+stale"
+			case d.Analyze:
+				action = "analyze"
+			case d.Stale:
+				action = "stale"
+			}
+			a.log.Info("dry-run: 分析决策",
+				zap.String("project", c.p.FullName),
+				zap.String("locale", c.locale),
+				zap.String("action", action),
+				zap.String("reason", d.Reason),
+				zap.String("prompt", c.promptID),
+				zap.Int("prompt_tokens", estimateTokens(c.prompt.System)+estimateTokens(c.prompt.User)),
+				zap.Int("readme_tokens", estimateTokens(c.readme)),
+				zap.Intp("rank", c.p.Rank),
+				zap.Float64("score", c.p.Score),
+			)
+			// For a single project, show exactly what the model would see;
+			// the README cache only holds what a real call was sent.
+			if opts.ProjectID != "" && a.out != nil {
+				fmt.Fprintf(a.out, "===== system (%s, %s) =====\n%s\n\n===== user =====\n%s\n",
+					c.promptID, c.locale, c.prompt.System, c.prompt.User)
+			}
 		}
 	}
 
 	a.log.Info("待分析项目",
 		zap.Int("candidates", len(candidates)),
+		zap.Int("stale", len(stale)),
 		zap.Int("total", len(projects)),
+		zap.Strings("locales", locales),
 		zap.Bool("force", opts.Force),
 	)
 
+	if opts.DryRun {
+		return nil
+	}
+
+	if !a.replay {
+		a.markStale(stale, now)
+	}
+	a.resolver = a.newResolver(opts.ProjectID, projects)
+
 	if len(candidates) == 0 {
 		a.log.Info("没有需要分析的项目")
 		return nil
 	}
 
-	var analyzed, failed, totalTokens int
+	bud := newBudget(
+		firstPositive(opts.BudgetTokens, a.cfg.Budget.MaxTokens),
+		firstPositiveFloat(opts.BudgetCNY, a.cfg.Budget.MaxCostCNY),
+		NewPriceTable(a.cfg.Prices),
+	)
+	a.client.SetUsageHook(func(u Usage) {
+		bud.add(u)
+		if a.ledger != nil {
+			a.ledger.Record(u)
+		}
+	})
+	limiter := newRateLimiter(a.cfg.RequestsPerMinute)
+
+	var (
+		mu               sync.Mutex
+		wg               sync.WaitGroup
+		analyzed, failed int
+		remaining        []*candidate
+		generated        []Generated
+	)
+	sem := make(chan struct{}, concurrency)
 
-	for _, p := range candidates {
+dispatch:
+	for i, c := range candidates {
 		select {
+		case sem <- struct{}{}:
 		case <-ctx.Done():
-			a.log.Warn("分析被取消", zap.Error(ctx.Err()))
-			return ctx.Err()
-		default:
+			break dispatch
 		}
-
-		if opts.DryRun {
-			a.log.Info("dry-run: 将分析项目",
-				zap.String("project", p.FullName),
-				zap.Bool("has_analysis", p.Analysis != nil),
-			)
-			continue
+		// Checked after acquiring a slot so finished calls are accounted for.
+		if bud.exceeded() {
+			<-sem
+			remaining = candidates[i:]
+			break
 		}
-
-		if err := a.analyzeOne(ctx, p); err != nil {
-			a.log.Warn("分析失败，跳过",
-				zap.String("project", p.FullName),
-				zap.Error(err),
-			)
-			failed++
-			continue
+		if err := limiter.wait(ctx); err != nil {
+			<-sem
+			break
 		}
 
-		if p.Analysis != nil && p.Analysis.TokenUsage != nil {
-			totalTokens += *p.Analysis.TokenUsage
-		}
-		analyzed++
+		wg.Add(1)
+		go func(c *candidate) {
+			defer wg.Done()
+			defer func() { <-sem }()
 
-		// Rate limiting: pause between API calls
-		time.Sleep(2 * time.Second)
+			err := a.analyzeOne(ctx, c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				a.log.Warn("分析失败，跳过",
+					zap.String("project", c.p.FullName),
+					zap.String("locale", c.locale),
+					zap.Error(err),
+				)
+				failed++
+				return
+			}
+			analyzed++
+			generated = append(generated, Generated{Project: c.p, Locale: c.locale})
+		}(c)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		a.log.Warn("分析被取消", zap.Error(ctx.Err()))
+		return ctx.Err()
+	}
+
+	totalTokens, cost := bud.spent()
+	if len(remaining) > 0 {
+		names := make([]string, 0, len(remaining))
+		for _, c := range remaining {
+			names = append(names, c.label())
+		}
+		a.log.Warn("已达到预算上限，停止分析",
+			zap.Int("remaining", len(remaining)),
+			zap.Strings("projects", names),
+		)
+	}
+	if models := bud.unpricedModels(); len(models) > 0 {
+		a.log.Warn("以下模型没有价格配置，费用按 0 计算", zap.Strings("models", models))
 	}
 
 	a.log.Info("LLM 分析完成",
 		zap.Int("analyzed", analyzed),
 		zap.Int("failed", failed),
+		zap.Int("remaining", len(remaining)),
 		zap.Int("total_tokens", totalTokens),
+		zap.Float64("cost_cny", math.Round(cost*100)/100),
 		zap.Duration("elapsed", time.Since(start)),
 	)
+
+	if opts.AfterRun != nil && !opts.DryRun && !a.replay {
+		return opts.AfterRun(generated)
+	}
 	return nil
 }
 
-// analyzeOne fetches README + calls LLM + saves result for a single project.
-func (a *Analyzer) analyzeOne(ctx context.Context, p *datastore.Project) error {
+// newResolver builds the comparison resolver over all tracked projects;
+// projects is already the full list unless a single project was requested.
+func (a *Analyzer) newResolver(projectID string, projects []*datastore.Project) *ComparisonResolver {
+	tracked := projects
+	if projectID != "" {
+		all, err := a.store.ListProjects()
+		if err != nil {
+			a.log.Warn("项目列表加载失败，对比项目仅在 GitHub 上查找", zap.Error(err))
+		} else {
+			tracked = all
+		}
+	}
+	return newComparisonResolver(tracked, a.gh, a.log)
+}
+
+// sortByPriority orders projects so the most important are analyzed first:
+// ranked before unranked, better rank first, then higher score, then stars.
+func sortByPriority(projects []*datastore.Project) {
+	sort.SliceStable(projects, func(i, j int) bool {
+		a, b := projects[i], projects[j]
+		if (a.Rank == nil) != (b.Rank == nil) {
+			return a.Rank != nil
+		}
+		if a.Rank != nil && *a.Rank != *b.Rank {
+			return *a.Rank < *b.Rank
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Stars > b.Stars
+	})
+}
+
+func firstPositive(vals ...int) int {
+	for _, v := range vals {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+func firstPositiveFloat(vals ...float64) float64 {
+	for _, v := range vals {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// locales resolves the output locales for a run: requested (--locale),
+// else llm.locales, else the default locale. Each needs a default prompt.
+func (a *Analyzer) locales(requested []string) ([]string, error) {
+	list := requested
+	if len(list) == 0 {
+		list = a.cfg.Locales
+	}
+	if len(list) == 0 {
+		list = []string{datastore.DefaultLocale}
+	}
+
+	available := a.promptSet().Locales()
+	var out []string
+	for _, l := range list {
+		l = datastore.NormalizeLocale(l)
+		if slices.Contains(out, l) {
+			continue
+		}
+		if !slices.Contains(available, l) {
+			return nil, fmt.Errorf("没有 %s 语言的分析 prompt（需要 analysis/%s/%s/vN.tmpl），可用: %s",
+				l, defaultPromptVariant, l, strings.Join(available, ", "))
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+func (a *Analyzer) promptSet() *PromptSet {
+	if a.prompts == nil {
+		return builtinPrompts()
+	}
+	return a.prompts
+}
+
+// candidate is a project and locale with the inputs fetched for this run.
+type candidate struct {
+	p        *datastore.Project
+	locale   string
+	prompt   *Prompt
+	promptID string
+	tmpl     *PromptTemplate
+	data     *PromptData // shared by the project's locales
+	source   string      // README as fetched, hashed
+	fetched  bool        // README fetched, or known to be absent
+	readme   string      // preprocessed README in the prompt, if any
+	hash     string      // empty when the README could not be fetched
+	stack    string      // techStackHash of the stack in the prompt
+	decision decision
+	feedback string // rejection reason added to the prompt
+	err      error  // prompt rendering failed
+}
+
+// label names the candidate in logs: the project, plus the locale when it
+// is not the default one.
+func (c *candidate) label() string {
+	if c.locale == datastore.DefaultLocale {
+		return c.p.FullName
+	}
+	return c.p.FullName + " (" + c.locale + ")"
+}
+
+// prepare fetches each project's README and per locale renders its prompt
+// and computes its input hash, with the tech stack detected so far.
+// Candidates are returned in the order given, project by project, each in
+// locale order.
+func (a *Analyzer) prepare(ctx context.Context, projects []*datastore.Project, cats []datastore.Category, locales []string, concurrency int) []*candidate {
+	prompts := a.promptSet()
+	bySlug := make(map[string]*datastore.Category, len(cats))
+	for i := range cats {
+		bySlug[cats[i].Slug] = &cats[i]
+	}
+	now := a.clock()
+
+	out := make([]*candidate, len(projects)*len(locales))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, p := range projects {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, p *datastore.Project) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			readme, fetched := a.readmeFor(ctx, p)
+
+			data := &PromptData{
+				Project:    p,
+				README:     readmeUnavailable,
+				Categories: cats,
+				Activity:   newActivity(p, now),
+			}
+			if readme != "" {
+				data.README = preprocessREADME(readme, a.cfg.READMEMaxTokens)
+			}
+			if p.Category != nil {
+				data.Category = bySlug[*p.Category]
+			}
+
+			for j, locale := range locales {
+				tmpl := prompts.For(p, locale)
+				c := &candidate{
+					p:        p,
+					locale:   locale,
+					promptID: tmpl.ID(),
+					tmpl:     tmpl,
+					data:     data,
+					source:   readme,
+					fetched:  fetched,
+				}
+				if readme != "" {
+					c.readme = data.README
+				}
+				a.render(c)
+				out[i*len(locales)+j] = c
+			}
+		}(i, p)
+	}
+	wg.Wait()
+	return out
+}
+
+// render renders the candidate's prompt, with any review feedback, and
+// hashes its inputs.
+func (a *Analyzer) render(c *candidate) {
+	c.prompt, c.err = c.tmpl.Render(*c.data)
+	if c.err == nil && c.feedback != "" {
+		c.prompt.User += feedbackPrompt(c.feedback, c.locale)
+	}
+	c.stack = techStackHash(c.p.TechStack)
+	c.hash = ""
+	if c.fetched {
+		c.hash = inputHash(c.p, c.source, c.promptID, a.client.model)
+	}
+}
+
+// applyFeedback adds the reason the candidate's last analysis was
+// rejected to its prompt. It reports false, after logging why, when the
+// latest review decision in the locale was not a rejection.
+func (a *Analyzer) applyFeedback(c *candidate) bool {
+	r, err := a.store.LatestReview(c.p.ID, c.locale)
+	if err != nil {
+		a.log.Warn("审核日志读取失败，跳过", zap.String("project", c.p.FullName), zap.Error(err))
+		return false
+	}
+	if r == nil || r.Action != datastore.ReviewReject {
+		a.log.Warn("最近的审核不是拒绝，跳过（重新生成请用 --force）",
+			zap.String("project", c.p.FullName),
+			zap.String("locale", c.locale),
+		)
+		return false
+	}
+	if r.Reason == "" {
+		a.log.Warn("拒绝时未填写原因，按原 prompt 重新生成",
+			zap.String("project", c.p.FullName),
+			zap.String("locale", c.locale),
+			zap.String("version", r.Version),
+		)
+		return true
+	}
+	c.feedback = r.Reason
+	a.render(c)
+	return true
+}
+
+// feedbackPrompt asks the model to address a reviewer's rejection reason.
+func feedbackPrompt(reason, locale string) string {
+	if locale != datastore.DefaultLocale {
+		return "\n\nA previous analysis of this project was rejected by a human reviewer for this reason:\n" +
+			reason + "\nAddress this feedback in the new analysis."
+	}
+	return "\n\n上一版分析被人工审核拒绝，原因如下：\n" + reason + "\n请在本次分析中针对该意见改进。"
+}
+
+// readmeFor fetches a project's README. A repository without one yields
+// "" and fetched; any other failure reports !fetched, since hashing what
+// was not seen would make a transient error look like changed inputs.
+func (a *Analyzer) readmeFor(ctx context.Context, p *datastore.Project) (readme string, fetched bool) {
+	parts := strings.SplitN(p.FullName, "/", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+
+	readme, err := fetchREADME(ctx, a.gh, parts[0], parts[1])
+	if isNotFound(err) {
+		return "", true
+	}
+	if err != nil {
+		a.log.Warn("README 获取失败，本次不判断分析是否过时",
+			zap.String("project", p.FullName),
+			zap.Error(err),
+		)
+		return "", false
+	}
+	return readme, true
+}
+
+// techStackMaxAge is how long a detected tech stack is reused before the
+// repository tree and manifests are fetched again.
+const techStackMaxAge = 7 * 24 * time.Hour
+
+// refreshTechStacks re-detects the tech stack of the candidates' projects
+// when it is missing or older than techStackMaxAge, saves it unless this
+// is a dry run, and re-renders the affected prompts and hashes. Only
+// projects about to be analyzed are fetched. Failures only warn; the
+// prompt then goes with the stack detected before, if any.
+func (a *Analyzer) refreshTechStacks(ctx context.Context, candidates []*candidate, concurrency int, dryRun bool) {
+	now := a.clock()
+	byProject := make(map[*datastore.Project][]*candidate)
+	var projects []*datastore.Project
+	for _, c := range candidates {
+		if _, ok := byProject[c.p]; !ok {
+			projects = append(projects, c.p)
+		}
+		byProject[c.p] = append(byProject[c.p], c)
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, p := range projects {
+		if p.TechStack != nil && now.Sub(p.TechStack.DetectedAt) < techStackMaxAge {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(p *datastore.Project) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if !a.refreshTechStack(ctx, p, now, dryRun) {
+				return
+			}
+			for _, c := range byProject[p] {
+				a.render(c)
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
+// refreshTechStack detects p.TechStack and saves the project unless this
+// is a dry run. It reports whether p.TechStack was updated.
+func (a *Analyzer) refreshTechStack(ctx context.Context, p *datastore.Project, now time.Time, dryRun bool) bool {
 	parts := strings.SplitN(p.FullName, "/", 2)
 	if len(parts) != 2 {
-		return fmt.Errorf("invalid full_name: %s", p.FullName)
+		return false
 	}
-	owner, repo := parts[0], parts[1]
 
-	// Fetch README
-	readme, err := fetchREADME(ctx, a.gh, owner, repo)
+	primary := ""
+	if p.Language != nil {
+		primary = *p.Language
+	}
+	ts, err := fetchTechStack(ctx, a.gh, parts[0], parts[1], primary, now)
 	if err != nil {
-		a.log.Warn("README 获取失败，继续不含 README",
+		a.log.Warn("技术栈检测失败，沿用已有技术栈",
 			zap.String("project", p.FullName),
 			zap.Error(err),
 		)
-		readme = "(README 不可用)"
+		return false
 	}
+	p.TechStack = ts
+	if dryRun {
+		return true
+	}
+	if err := a.store.SaveProject(p); err != nil {
+		a.log.Warn("技术栈保存失败", zap.String("project", p.FullName), zap.Error(err))
+	}
+	return true
+}
+
+// readmeUnavailable stands in for a missing README in prompts. It is never
+// part of the input hash.
+const readmeUnavailable = "(README 不可用)"
+
+// markStale flags published analyses whose inputs changed or expired.
+func (a *Analyzer) markStale(stale []*candidate, now time.Time) {
+	for _, c := range stale {
+		an := c.p.AnalysisFor(c.locale)
+		if an == nil || an.Status != "published" || (an.Stale && an.StaleReason == c.decision.Reason) {
+			continue
+		}
+		an.Stale = true
+		an.StaleReason = c.decision.Reason
+		c.p.UpdatedAt = now
+		if err := a.store.SaveProject(c.p); err != nil {
+			a.log.Warn("标记 stale 失败", zap.String("project", c.p.FullName), zap.Error(err))
+			continue
+		}
+		a.log.Info("已发布分析标记为 stale",
+			zap.String("project", c.p.FullName),
+			zap.String("locale", c.locale),
+			zap.String("reason", c.decision.Reason),
+		)
+	}
+}
+
+// analyzeOne calls the LLM and saves the result for a single project.
+func (a *Analyzer) analyzeOne(ctx context.Context, c *candidate) error {
+	p := c.p
+	a.log.Debug("开始分析", zap.String("project", p.FullName), zap.String("locale", c.locale), zap.String("reason", c.decision.Reason))
 
 	// Call LLM
-	analysis, err := a.client.AnalyzeProject(ctx, p, readme)
+	analysis, err := a.client.AnalyzeProject(ctx, p, c.prompt)
 	if err != nil {
 		return fmt.Errorf("LLM analyze: %w", err)
 	}
+	analysis.InputHash = c.hash
+	analysis.TechStackHash = c.stack
+	analysis.ReviewFeedback = c.feedback
+	if a.resolver != nil {
+		a.resolver.Resolve(ctx, p, analysis)
+	}
+	if a.replay {
+		return a.printReplayed(c, analysis)
+	}
+
+	// Cache the README the model just saw so it can be inspected.
+	if c.readme != "" {
+		if err := a.store.SaveREADMECache(p.ID, c.readme); err != nil {
+			a.log.Warn("README 缓存写入失败", zap.String("project", p.FullName), zap.Error(err))
+		}
+	}
 
-	// Save
-	p.Analysis = analysis
-	p.UpdatedAt = time.Now().UTC()
+	// Keep every generation as a version; a published analysis stays live
+	// and the new one waits as the draft until reviewed.
+	if err := a.store.SaveAnalysisVersion(p.ID, analysis); err != nil {
+		return fmt.Errorf("saving analysis version: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if replaced := p.AttachAnalysis(analysis); replaced != nil {
+		if err := a.store.SupersedeDraft(p.ID, replaced); err != nil {
+			a.log.Warn("标记旧草稿 superseded 失败", zap.String("project", p.FullName), zap.Error(err))
+		}
+	}
+	p.UpdatedAt = a.clock()
 
 	if err := a.store.SaveProject(p); err != nil {
 		return fmt.Errorf("saving project: %w", err)
@@ -175,16 +756,17 @@ func (a *Analyzer) analyzeOne(ctx context.Context, p *datastore.Project) error {
 	return nil
 }
 
-// needsAnalysis returns true if a project should be (re-)analyzed.
-func needsAnalysis(p *datastore.Project) bool {
-	if p.Analysis == nil {
-		return true
-	}
-	// Re-analyze if draft and older than 7 days
-	if p.Analysis.Status == "draft" {
-		return time.Since(p.Analysis.GeneratedAt) > 7*24*time.Hour
+// printReplayed writes an analysis generated from a recording to a.out
+// instead of the data dir, so a replay never touches the live store.
+func (a *Analyzer) printReplayed(c *candidate, analysis *datastore.Analysis) error {
+	data, err := json.MarshalIndent(analysis, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding analysis: %w", err)
 	}
-	return false
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = fmt.Fprintf(a.out, "===== analysis (%s, %s) =====\n%s\n\n", c.p.FullName, c.locale, data)
+	return err
 }
 
 // fetchREADME fetches the README content from GitHub.
@@ -199,3 +781,41 @@ func fetchREADME(ctx context.Context, gh *github.Client, owner, repo string) (st
 	}
 	return content, nil
 }
+
+// fetchTechStack lists the repository's top-level files, fetches the
+// manifests techstack understands and detects the tech stack from them.
+// A manifest that cannot be fetched is skipped.
+func fetchTechStack(ctx context.Context, gh *github.Client, owner, repo, primary string, now time.Time) (*datastore.TechStack, error) {
+	_, entries, _, err := gh.Repositories.GetContents(ctx, owner, repo, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub API: %w", err)
+	}
+
+	files := make([]string, 0, len(entries))
+	present := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if e.GetType() != "file" {
+			continue
+		}
+		files = append(files, e.GetName())
+		present[e.GetName()] = true
+	}
+
+	manifests := make(map[string]string)
+	for _, name := range techstack.Manifests {
+		if !present[name] {
+			continue
+		}
+		fc, _, _, err := gh.Repositories.GetContents(ctx, owner, repo, name, nil)
+		if err != nil || fc == nil {
+			continue
+		}
+		content, err := fc.GetContent()
+		if err != nil {
+			continue
+		}
+		manifests[name] = content
+	}
+
+	return techstack.Detect(files, manifests, primary, now), nil
+}
@@ -1,7 +1,500 @@
 package llm
 
-import "time"
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v67/github"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
 
 func timeNow() time.Time {
 	return time.Now().UTC()
 }
+
+func intp(v int) *int { return &v }
+
+func TestSortByPriority(t *testing.T) {
+	projects := []*datastore.Project{
+		{FullName: "unranked/low", Score: 10},
+		{FullName: "rank/3", Rank: intp(3)},
+		{FullName: "unranked/high", Score: 90},
+		{FullName: "rank/1", Rank: intp(1)},
+	}
+	sortByPriority(projects)
+
+	want := []string{"rank/1", "rank/3", "unranked/high", "unranked/low"}
+	for i, w := range want {
+		if projects[i].FullName != w {
+			t.Errorf("projects[%d] = %s, want %s", i, projects[i].FullName, w)
+		}
+	}
+}
+
+// newTestAnalyzer wires an Analyzer to a fake server that serves both the
+// GitHub repository endpoints (404) and OpenAI-compatible chat completions.
+func newTestAnalyzer(t *testing.T, cfg config.LLMConfig, chat http.HandlerFunc) (*Analyzer, *datastore.Store) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/repos/") || strings.HasPrefix(r.URL.Path, "/search/") {
+			http.NotFound(w, r)
+			return
+		}
+		chat(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Provider = "ollama"
+	cfg.BaseURL = srv.URL
+	client, err := NewClient(cfg, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	return &Analyzer{store: store, client: client, gh: gh, log: testLogger(), cfg: cfg}, store
+}
+
+func TestRun_BudgetStopsRun(t *testing.T) {
+	var calls atomic.Int32
+	a, store := newTestAnalyzer(t, config.LLMConfig{Concurrency: 1},
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"summary\":\"s\"}"}}],` +
+				`"usage":{"prompt_tokens":80,"completion_tokens":20,"total_tokens":100}}`))
+		})
+
+	now := timeNow()
+	for i, name := range []string{"a/one", "b/two", "c/three"} {
+		p := &datastore.Project{
+			ID: datastore.ProjectIDFromFullName(name), FullName: name,
+			Rank: intp(i + 1), FirstSeenAt: now, UpdatedAt: now,
+		}
+		if err := store.SaveProject(p); err != nil {
+			t.Fatalf("SaveProject: %v", err)
+		}
+	}
+
+	if err := a.Run(context.Background(), RunOptions{BudgetTokens: 150}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// Two calls reach 200 ≥ 150 tokens; the third project must be left.
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+	left, _ := store.LoadProject("c__three")
+	if left.Analysis != nil {
+		t.Error("lowest-priority project should not be analyzed after budget is spent")
+	}
+	first, _ := store.LoadProject("a__one")
+	if first.Analysis == nil {
+		t.Error("top-ranked project should be analyzed first")
+	}
+}
+
+func TestRun_Concurrent(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	a, store := newTestAnalyzer(t, config.LLMConfig{Concurrency: 3},
+		func(w http.ResponseWriter, r *http.Request) {
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			inFlight.Add(-1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(chatResponse(`{"summary":"s"}`)))
+		})
+
+	now := timeNow()
+	for _, name := range []string{"a/1", "a/2", "a/3", "a/4", "a/5", "a/6"} {
+		p := &datastore.Project{ID: datastore.ProjectIDFromFullName(name), FullName: name, FirstSeenAt: now, UpdatedAt: now}
+		_ = store.SaveProject(p)
+	}
+
+	var generated []Generated
+	opts := RunOptions{AfterRun: func(g []Generated) error { generated = g; return nil }}
+	if err := a.Run(context.Background(), opts); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(generated) != 6 || generated[0].Project.PendingAnalysis(generated[0].Locale) == nil {
+		t.Errorf("AfterRun got %d generated analyses, want 6 pending drafts", len(generated))
+	}
+	if peak.Load() < 2 || peak.Load() > 3 {
+		t.Errorf("peak concurrency = %d, want 2..3", peak.Load())
+	}
+
+	projects, _ := store.ListProjects()
+	for _, p := range projects {
+		if p.Analysis == nil {
+			t.Errorf("%s not analyzed", p.FullName)
+		}
+	}
+}
+
+func TestRun_PublishedChangedKeepsPublishedLive(t *testing.T) {
+	var calls atomic.Int32
+	a, store := newTestAnalyzer(t, config.LLMConfig{},
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(chatResponse(`{"summary":"new"}`)))
+		})
+
+	now := timeNow()
+	p := &datastore.Project{
+		ID: "a__b", FullName: "a/b", FirstSeenAt: now, UpdatedAt: now,
+		Analysis: &datastore.Analysis{Status: "published", Summary: "keep", InputHash: "sha256:old", GeneratedAt: now},
+	}
+	_ = store.SaveProject(p)
+
+	if err := a.Run(context.Background(), RunOptions{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+
+	got, _ := store.LoadProject("a__b")
+	if got.Analysis.Status != "published" || got.Analysis.Summary != "keep" {
+		t.Errorf("published analysis replaced: %+v", got.Analysis)
+	}
+	if !got.Analysis.Stale || got.Analysis.StaleReason == "" {
+		t.Error("published analysis should be flagged stale")
+	}
+	if got.Draft == nil || got.Draft.Summary != "new" || got.Draft.Version == "" {
+		t.Fatalf("Draft = %+v, want new versioned draft", got.Draft)
+	}
+
+	versions, err := store.ListAnalysisVersions("a__b", datastore.DefaultLocale)
+	if err != nil || len(versions) != 1 {
+		t.Errorf("versions = %d (%v), want 1", len(versions), err)
+	}
+
+	// A second run must not regenerate the pending draft.
+	if err := a.Run(context.Background(), RunOptions{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("pending draft regenerated, calls = %d", calls.Load())
+	}
+}
+
+func TestRun_READMEFetchErrorSkipsFreshness(t *testing.T) {
+	var calls atomic.Int32
+	a, store := newTestAnalyzer(t, config.LLMConfig{},
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(chatResponse(`{"summary":"new"}`)))
+		})
+	gh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	t.Cleanup(gh.Close)
+	a.gh.BaseURL, _ = url.Parse(gh.URL + "/")
+
+	now := timeNow()
+	_ = store.SaveProject(&datastore.Project{
+		ID: "a__b", FullName: "a/b", FirstSeenAt: now, UpdatedAt: now,
+		Analysis: &datastore.Analysis{Status: "published", Summary: "keep", InputHash: "sha256:old", GeneratedAt: now},
+	})
+
+	if err := a.Run(context.Background(), RunOptions{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	got, _ := store.LoadProject("a__b")
+	if calls.Load() != 0 || got.Analysis.Stale || got.Draft != nil {
+		t.Errorf("calls = %d, analysis = %+v, draft = %+v; want untouched", calls.Load(), got.Analysis, got.Draft)
+	}
+
+	// --force still analyzes, without hashing the missing README.
+	if err := a.Run(context.Background(), RunOptions{Force: true}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got, _ = store.LoadProject("a__b"); got.Draft == nil || got.Draft.InputHash != "" {
+		t.Errorf("draft = %+v, want one without input hash", got.Draft)
+	}
+}
+
+func TestRun_READMECacheOnlyAfterModelCall(t *testing.T) {
+	a, store := newTestAnalyzer(t, config.LLMConfig{},
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(chatResponse(`{"summary":"s"}`)))
+		})
+	gh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/a/b/readme" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"encoding":"base64","content":"` + base64.StdEncoding.EncodeToString([]byte("# B\n\nAn LLM framework.")) + `"}`))
+	}))
+	t.Cleanup(gh.Close)
+	a.gh.BaseURL, _ = url.Parse(gh.URL + "/")
+
+	now := timeNow()
+	_ = store.SaveProject(&datastore.Project{ID: "a__b", FullName: "a/b", FirstSeenAt: now, UpdatedAt: now})
+	cache := store.READMECachePath("a__b")
+
+	if err := a.Run(context.Background(), RunOptions{DryRun: true}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if _, err := os.Stat(cache); !os.IsNotExist(err) {
+		t.Fatalf("dry run wrote the README cache: %v", err)
+	}
+
+	if err := a.Run(context.Background(), RunOptions{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if data, err := os.ReadFile(cache); err != nil || !strings.Contains(string(data), "An LLM framework.") {
+		t.Errorf("cache = %q, %v", data, err)
+	}
+}
+
+func TestRun_TechStackOnlyForCandidates(t *testing.T) {
+	a, store := newTestAnalyzer(t, config.LLMConfig{},
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(chatResponse(`{"summary":"s"}`)))
+		})
+	var mu sync.Mutex
+	fetched := map[string]bool{}
+	gh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/contents/") {
+			mu.Lock()
+			fetched[r.URL.Path] = true
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"type":"file","name":"go.mod"}]`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(gh.Close)
+	a.gh.BaseURL, _ = url.Parse(gh.URL + "/")
+
+	now := timeNow()
+	lang := "Go"
+	_ = store.SaveProject(&datastore.Project{ID: "a__new", FullName: "a/new", Language: &lang, FirstSeenAt: now, UpdatedAt: now})
+	_ = store.SaveProject(&datastore.Project{ID: "a__done", FullName: "a/done", FirstSeenAt: now, UpdatedAt: now,
+		Analysis: &datastore.Analysis{Status: "rejected", GeneratedAt: now}})
+
+	if err := a.Run(context.Background(), RunOptions{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !fetched["/repos/a/new/contents/"] || fetched["/repos/a/done/contents/"] {
+		t.Errorf("tech stack fetched for %v, want only the candidate", fetched)
+	}
+	got, _ := store.LoadProject("a__new")
+	if got.TechStack == nil || got.Analysis == nil || got.Analysis.TechStackHash != techStackHash(got.TechStack) {
+		t.Errorf("project = %+v, want the detected stack recorded on the analysis", got)
+	}
+}
+
+func TestRun_TechStackForOneLocaleKeepsOthersFresh(t *testing.T) {
+	var calls atomic.Int32
+	a, store := newTestAnalyzer(t, config.LLMConfig{},
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(chatResponse(`{"summary":"s"}`)))
+		})
+	var detect atomic.Bool
+	gh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/contents/") && detect.Load() {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"type":"file","name":"go.mod"}]`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(gh.Close)
+	a.gh.BaseURL, _ = url.Parse(gh.URL + "/")
+
+	prompts, err := LoadPrompts("../../prompts", nil)
+	if err != nil {
+		t.Fatalf("LoadPrompts: %v", err)
+	}
+	a.prompts = prompts
+
+	now := timeNow()
+	_ = store.SaveProject(&datastore.Project{ID: "a__b", FullName: "a/b", FirstSeenAt: now, UpdatedAt: now})
+
+	// zh is analyzed and published before any stack is detected.
+	if err := a.Run(context.Background(), RunOptions{Locales: []string{"zh"}}); err != nil {
+		t.Fatalf("Run zh: %v", err)
+	}
+	p, _ := store.LoadProject("a__b")
+	if p.TechStack != nil || p.Analysis == nil || p.Analysis.TechStackHash != "" {
+		t.Fatalf("project = %+v, want a zh analysis without a stack", p)
+	}
+	if _, _, err := store.SetAnalysisStatus(p, "zh", "published", datastore.Review{Reviewer: "alice", Time: now}); err != nil {
+		t.Fatalf("SetAnalysisStatus: %v", err)
+	}
+
+	// Analyzing en detects and stores the stack.
+	detect.Store(true)
+	if err := a.Run(context.Background(), RunOptions{Locales: []string{"en"}}); err != nil {
+		t.Fatalf("Run en: %v", err)
+	}
+	p, _ = store.LoadProject("a__b")
+	if en := p.AnalysisFor("en"); p.TechStack == nil || en == nil || en.TechStackHash != techStackHash(p.TechStack) {
+		t.Fatalf("project = %+v, want the stack recorded on the en analysis", p)
+	}
+
+	// zh was not generated with a stack, so the new one does not outdate it.
+	before := calls.Load()
+	if err := a.Run(context.Background(), RunOptions{Locales: []string{"zh"}}); err != nil {
+		t.Fatalf("Run zh again: %v", err)
+	}
+	p, _ = store.LoadProject("a__b")
+	if calls.Load() != before || p.Analysis.Stale || p.Draft != nil {
+		t.Errorf("zh stale=%v draft=%v after %d calls, want it left alone", p.Analysis.Stale, p.Draft, calls.Load()-before)
+	}
+}
+
+func TestRun_FeedbackFromReview(t *testing.T) {
+	var bodies []string
+	var mu sync.Mutex
+	a, store := newTestAnalyzer(t, config.LLMConfig{},
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			bodies = append(bodies, string(body))
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(chatResponse(`{"summary":"improved"}`)))
+		})
+
+	now := timeNow()
+	p := &datastore.Project{ID: "a__b", FullName: "a/b", FirstSeenAt: now, UpdatedAt: now}
+	p.AttachAnalysis(&datastore.Analysis{Status: "draft", Summary: "bad", GeneratedAt: now})
+	if err := store.SaveAnalysisVersion(p.ID, p.Analysis); err != nil {
+		t.Fatalf("SaveAnalysisVersion: %v", err)
+	}
+	_ = store.SaveProject(p)
+
+	// Nothing was rejected yet: no call.
+	opts := RunOptions{ProjectID: "a__b", FeedbackFromReview: true}
+	if err := a.Run(context.Background(), opts); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(bodies) != 0 {
+		t.Fatalf("calls = %d before any rejection, want 0", len(bodies))
+	}
+
+	rv := datastore.Review{Reviewer: "alice", Reason: "没有提到插件机制", Time: now}
+	if _, _, err := store.SetAnalysisStatus(p, datastore.DefaultLocale, "rejected", rv); err != nil {
+		t.Fatalf("SetAnalysisStatus: %v", err)
+	}
+	if err := a.Run(context.Background(), opts); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(bodies) != 1 || !strings.Contains(bodies[0], "没有提到插件机制") {
+		t.Fatalf("requests = %q, want one carrying the rejection reason", bodies)
+	}
+	got, _ := store.LoadProject("a__b")
+	if got.Analysis == nil || got.Analysis.Summary != "improved" || got.Analysis.ReviewFeedback != "没有提到插件机制" {
+		t.Errorf("Analysis = %+v, want regenerated draft with review feedback", got.Analysis)
+	}
+
+	if err := a.Run(context.Background(), RunOptions{FeedbackFromReview: true}); err == nil {
+		t.Error("--feedback-from-review without a project should fail")
+	}
+}
+
+func TestFetchTechStack(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/a/b/contents/":
+			_, _ = w.Write([]byte(`[{"type":"file","name":"go.mod"},{"type":"file","name":"package.json"},` +
+				`{"type":"dir","name":"cmd"}]`))
+		case "/repos/a/b/contents/go.mod":
+			_, _ = w.Write([]byte(`{"type":"file","name":"go.mod","encoding":"base64",` +
+				`"content":"bW9kdWxlIHgKCnJlcXVpcmUgZ2l0aHViLmNvbS9naW4tZ29uaWMvZ2luIHYxLjEwLjAK"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	ts, err := fetchTechStack(context.Background(), gh, "a", "b", "Go", timeNow())
+	if err != nil {
+		t.Fatalf("fetchTechStack: %v", err)
+	}
+	// package.json is listed but unavailable and must be skipped.
+	if len(ts.Manifests) != 1 || ts.Manifests[0] != "go.mod" {
+		t.Errorf("Manifests = %v, want [go.mod]", ts.Manifests)
+	}
+	if len(ts.Frameworks) != 1 || ts.Frameworks[0] != "Gin" {
+		t.Errorf("Frameworks = %v, want [Gin]", ts.Frameworks)
+	}
+}
+
+func TestRun_Locales(t *testing.T) {
+	a, store := newTestAnalyzer(t, config.LLMConfig{},
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			if strings.Contains(string(body), "written in English") {
+				_, _ = w.Write([]byte(chatResponse(`{"summary":"An LLM framework for Go"}`)))
+				return
+			}
+			_, _ = w.Write([]byte(chatResponse(`{"summary":"Go 语言的 LLM 框架"}`)))
+		})
+	prompts, err := LoadPrompts("../../prompts", nil)
+	if err != nil {
+		t.Fatalf("LoadPrompts: %v", err)
+	}
+	a.prompts = prompts
+
+	now := timeNow()
+	_ = store.SaveProject(&datastore.Project{ID: "a__b", FullName: "a/b", FirstSeenAt: now, UpdatedAt: now})
+
+	if err := a.Run(context.Background(), RunOptions{Locales: []string{"zh", "en"}}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got, _ := store.LoadProject("a__b")
+	if got.Analysis == nil || got.Analysis.Summary != "Go 语言的 LLM 框架" || got.Analysis.Locale != "" {
+		t.Errorf("zh analysis = %+v", got.Analysis)
+	}
+	en := got.AnalysisFor("en")
+	if en == nil || en.Summary != "An LLM framework for Go" || en.Locale != "en" || en.PromptName != "default" {
+		t.Fatalf("en analysis = %+v", en)
+	}
+	if v, _ := store.ListAnalysisVersions("a__b", "en"); len(v) != 1 {
+		t.Errorf("en versions = %d, want 1", len(v))
+	}
+
+	if err := a.Run(context.Background(), RunOptions{Locales: []string{"fr"}}); err == nil {
+		t.Error("locale without prompts should fail")
+	}
+}
@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// budget tracks token and cost consumption for one analyzer run.
+// Zero limits mean unlimited.
+type budget struct {
+	maxTokens int
+	maxCost   float64
+	prices    PriceTable
+
+	mu       sync.Mutex
+	tokens   int
+	cost     float64
+	unpriced map[string]bool
+}
+
+func newBudget(maxTokens int, maxCost float64, prices PriceTable) *budget {
+	return &budget{
+		maxTokens: maxTokens,
+		maxCost:   maxCost,
+		prices:    prices,
+		unpriced:  make(map[string]bool),
+	}
+}
+
+// add records a call's usage. It is safe for concurrent use.
+func (b *budget) add(u Usage) {
+	cost, ok := b.prices.Cost(u.Model, u.PromptTokens, u.CompletionTokens)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += u.TotalTokens
+	b.cost += cost
+	if !ok {
+		b.unpriced[u.Model] = true
+	}
+}
+
+// exceeded reports whether either limit has been reached.
+func (b *budget) exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxTokens > 0 && b.tokens >= b.maxTokens {
+		return true
+	}
+	return b.maxCost > 0 && b.cost >= b.maxCost
+}
+
+// spent returns the tokens and estimated CNY consumed so far.
+func (b *budget) spent() (int, float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens, b.cost
+}
+
+// unpricedModels returns models seen without a price entry.
+func (b *budget) unpricedModels() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	models := make([]string, 0, len(b.unpriced))
+	for m := range b.unpriced {
+		models = append(models, m)
+	}
+	return models
+}
+
+// rateLimiter spaces out calls to at most rpm per minute.
+// A zero or negative rpm disables limiting.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRateLimiter(rpm int) *rateLimiter {
+	if rpm <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{interval: time.Minute / time.Duration(rpm)}
+}
+
+// wait blocks until the caller may issue the next request.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	if d := time.Until(slot); d > 0 {
+		return sleepCtx(ctx, d)
+	}
+	return ctx.Err()
+}
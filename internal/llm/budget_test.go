@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/config"
+)
+
+func TestPriceTable(t *testing.T) {
+	pt := NewPriceTable([]config.LLMPrice{{Model: "Local-Model", Input: 1, Output: 1}})
+
+	cost, ok := pt.Cost("deepseek-chat", 1_000_000, 500_000)
+	if !ok || math.Abs(cost-6) > 1e-9 {
+		t.Errorf("deepseek-chat cost = %v, %v; want 6", cost, ok)
+	}
+	if _, ok := pt.Cost("local-model", 1, 1); !ok {
+		t.Error("override should be case-insensitive")
+	}
+	if cost, ok := pt.Cost("unknown", 1000, 1000); ok || cost != 0 {
+		t.Errorf("unknown model cost = %v, %v", cost, ok)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	b := newBudget(100, 0, NewPriceTable(nil))
+	b.add(Usage{Model: "deepseek-chat", PromptTokens: 40, CompletionTokens: 20, TotalTokens: 60})
+	if b.exceeded() {
+		t.Fatal("60/100 tokens should not exceed")
+	}
+	b.add(Usage{Model: "ollama-thing", TotalTokens: 40})
+	if !b.exceeded() {
+		t.Fatal("100/100 tokens should exceed")
+	}
+	if m := b.unpricedModels(); len(m) != 1 || m[0] != "ollama-thing" {
+		t.Errorf("unpriced = %v", m)
+	}
+
+	cb := newBudget(0, 0.01, NewPriceTable(nil))
+	cb.add(Usage{Model: "deepseek-chat", PromptTokens: 1000, CompletionTokens: 1000, TotalTokens: 2000})
+	if !cb.exceeded() {
+		_, cost := cb.spent()
+		t.Errorf("cost %v should exceed 0.01", cost)
+	}
+
+	if newBudget(0, 0, nil).exceeded() {
+		t.Error("zero limits mean unlimited")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(600) // one slot every 100ms
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.wait(ctx); err != nil {
+			t.Fatalf("wait: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("3 calls took %v, want ≥200ms spacing", elapsed)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.wait(cctx); err == nil {
+		t.Error("expected error on cancelled context")
+	}
+
+	if err := newRateLimiter(0).wait(ctx); err != nil {
+		t.Errorf("disabled limiter: %v", err)
+	}
+}
@@ -1,12 +1,16 @@
 // Package llm provides LLM-based project analysis using OpenAI-compatible APIs.
-// Supports DeepSeek and Qwen providers via configurable BaseURL.
+// Providers (DeepSeek, Qwen, OpenAI, Ollama, vLLM, custom endpoints) are
+// resolved through a registry, see provider.go.
 package llm
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	openai "github.com/sashabaranov/go-openai"
@@ -16,102 +20,161 @@ import (
 	"github.com/zbb88888/tishi/internal/datastore"
 )
 
-// providerEndpoints maps provider names to their API base URLs.
-var providerEndpoints = map[string]string{
-	"deepseek": "https://api.deepseek.com/v1",
-	"qwen":     "https://dashscope.aliyuncs.com/compatible-mode/v1",
+// Client wraps an OpenAI-compatible API client for project analysis.
+type Client struct {
+	client   *openai.Client
+	provider Provider
+	model    string
+	cfg      config.LLMConfig
+	log      *zap.Logger
+
+	// promptJSON is set when the endpoint rejected response_format at
+	// runtime, so later calls skip straight to prompt-only JSON mode.
+	promptJSON atomic.Bool
+
+	// fallbacks are tried in order when this client exhausts its retries.
+	fallbacks []*Client
+
+	// sleep waits between retries; replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+
+	// usageHook observes every completed API call, including retries.
+	usageHook func(Usage)
+
+	// now stamps GeneratedAt; the recording's clock under a Recorder.
+	now func() time.Time
+}
+
+// SetUsageHook registers fn to receive token usage for every API call made
+// by this client and its fallbacks. fn must be safe for concurrent use.
+func (c *Client) SetUsageHook(fn func(Usage)) {
+	c.usageHook = fn
+	for _, fb := range c.fallbacks {
+		fb.usageHook = fn
+	}
 }
 
-// providerDefaultModels maps provider names to default model names.
-var providerDefaultModels = map[string]string{
-	"deepseek": "deepseek-chat",
-	"qwen":     "qwen-plus",
+// clock returns the current time, from c.now when set.
+func (c *Client) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now().UTC()
 }
 
-// Client wraps an OpenAI-compatible API client for project analysis.
-type Client struct {
-	client *openai.Client
-	model  string
-	cfg    config.LLMConfig
-	log    *zap.Logger
+// reportUsage forwards a call's usage to the hook, if any.
+func (c *Client) reportUsage(project string, u openai.Usage) {
+	if c.usageHook == nil {
+		return
+	}
+	c.usageHook(Usage{
+		Project:          project,
+		Provider:         c.provider.Name,
+		Model:            c.model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	})
 }
 
 // NewClient creates an LLM client based on provider configuration.
-func NewClient(cfg config.LLMConfig, log *zap.Logger) (*Client, error) {
-	if cfg.APIKey == "" {
-		return nil, fmt.Errorf("llm.api_key is required (set TISHI_LLM_API_KEY)")
+// The provider is resolved from the registry (see RegisterProvider), the
+// llm.providers config section, and llm.base_url / headers / json_mode overrides.
+// Entries in llm.fallbacks become failover clients, tried in order; a
+// fallback on another provider needs its own api_key unless that provider
+// takes none.
+func NewClient(cfg config.LLMConfig, log *zap.Logger, opts ...Option) (*Client, error) {
+	o := buildOptions(opts)
+	c, err := newClient(cfg, log, o)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, fb := range cfg.Fallbacks {
+		fcfg := cfg
+		fcfg.Fallbacks = nil
+		fcfg.Model = fb.Model
+		crossProvider := fb.Provider != "" && !strings.EqualFold(fb.Provider, cfg.Provider)
+		if crossProvider {
+			// Endpoint overrides and the key belong to the primary provider
+			// only: never send llm.api_key to another vendor.
+			fcfg.Provider = fb.Provider
+			fcfg.BaseURL = ""
+			fcfg.APIKey = ""
+			fcfg.Headers = nil
+			fcfg.JSONMode = ""
+		}
+		if fb.BaseURL != "" {
+			fcfg.BaseURL = fb.BaseURL
+		}
+		if fb.APIKey != "" {
+			fcfg.APIKey = fb.APIKey
+		}
+		if crossProvider && fcfg.APIKey == "" && !o.offline() {
+			if p, err := resolveProvider(fcfg); err == nil && !p.NoAPIKey {
+				return nil, fmt.Errorf("llm.fallbacks[%d]: api_key is required for provider %s (llm.api_key is not shared across providers)", i, p.Name)
+			}
+		}
+
+		fc, err := newClient(fcfg, log, o)
+		if err != nil {
+			return nil, fmt.Errorf("llm.fallbacks[%d]: %w", i, err)
+		}
+		c.fallbacks = append(c.fallbacks, fc)
+	}
+
+	return c, nil
+}
+
+// newClient creates a single-provider client without fallbacks.
+func newClient(cfg config.LLMConfig, log *zap.Logger, o options) (*Client, error) {
+	provider, err := resolveProvider(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	provider := strings.ToLower(cfg.Provider)
-	baseURL, ok := providerEndpoints[provider]
-	if !ok {
-		return nil, fmt.Errorf("unsupported LLM provider %q (use deepseek or qwen)", provider)
+	if cfg.APIKey == "" && !provider.NoAPIKey && !o.offline() {
+		return nil, fmt.Errorf("llm.api_key is required for provider %s (set TISHI_LLM_API_KEY)", provider.Name)
 	}
 
 	model := cfg.Model
 	if model == "" {
-		model = providerDefaultModels[provider]
+		model = provider.DefaultModel
+	}
+	if model == "" {
+		return nil, fmt.Errorf("llm.model is required for provider %s", provider.Name)
+	}
+
+	var transport http.RoundTripper = &retryAfterTransport{base: o.transport()}
+	if len(provider.Headers) > 0 {
+		transport = &headerTransport{base: transport, headers: provider.Headers}
 	}
 
 	ocfg := openai.DefaultConfig(cfg.APIKey)
-	ocfg.BaseURL = baseURL
-
-	return &Client{
-		client: openai.NewClientWithConfig(ocfg),
-		model:  model,
-		cfg:    cfg,
-		log:    log.Named("llm"),
-	}, nil
-}
-
-// systemPrompt instructs the LLM on its role and output requirements.
-var systemPrompt = strings.Join([]string{
-	"你是一个专业的 AI 技术分析师，专门为中文开发者社区撰写开源项目深度分析报告。",
-	"你的报告需要：",
-	"1. 使用中文撰写，专业术语保留英文原文",
-	"2. 客观准确，基于项目实际功能和代码",
-	"3. 面向有一定技术背景的中文开发者",
-	"4. 简洁有力，避免空洞的营销话术",
-	"",
-	"请严格按照 JSON 格式输出分析结果，不要输出其他内容。",
-}, "\n")
-
-// buildUserPrompt constructs the user prompt for a project.
-func buildUserPrompt(p *datastore.Project, readme string) string {
-	desc := ""
-	if p.Description != nil {
-		desc = *p.Description
-	}
-	lang := ""
-	if p.Language != nil {
-		lang = *p.Language
-	}
-	topics := strings.Join(p.Topics, ", ")
-
-	return fmt.Sprintf(`请分析以下 GitHub 开源项目：
-
-项目名称: %s
-描述: %s
-编程语言: %s
-Star 数: %d
-Topics: %s
-
-README 内容（前 3000 字）:
-%s
-
-请输出以下 JSON 格式的分析结果：
-{
-  "summary": "一句话中文概括（50字以内）",
-  "positioning": "项目定位：解决什么问题，面向谁（200字以内）",
-  "features": [{"name": "功能名", "desc": "功能描述"}],
-  "advantages": "相比同类项目的优势（200字以内）",
-  "tech_stack": "使用的核心技术栈",
-  "use_cases": "适用场景（200字以内）",
-  "comparison": [{"project": "竞品名", "diff": "差异点"}],
-  "ecosystem": "上下游生态（200字以内）"
-}`, p.FullName, desc, lang, p.Stars, topics, readme)
+	ocfg.BaseURL = provider.BaseURL
+	ocfg.HTTPClient = &http.Client{Transport: transport, Timeout: cfg.Timeout}
+
+	c := &Client{
+		client:   openai.NewClientWithConfig(ocfg),
+		provider: provider,
+		model:    model,
+		cfg:      cfg,
+		log:      log.Named("llm"),
+		sleep:    sleepCtx,
+		now:      o.clock(),
+	}
+	c.promptJSON.Store(provider.JSONMode == JSONModePrompt)
+	return c, nil
 }
 
+// promptJSONInstruction is appended to the system prompt when the endpoint
+// does not support response_format, to keep the output machine-parseable.
+// promptJSONInstructionEN is used for prompts outside the default locale.
+const (
+	promptJSONInstruction   = "\n\n只输出一个 JSON 对象：以 { 开头、以 } 结尾，不要使用 Markdown 代码块，不要添加任何解释。"
+	promptJSONInstructionEN = "\n\nOutput a single JSON object only: start with { and end with }, no Markdown code fences, no explanations."
+)
+
 // llmResponse is the expected JSON structure from LLM output.
 type llmResponse struct {
 	Summary     string              `json:"summary"`
@@ -126,71 +189,216 @@ type llmResponse struct {
 
 type llmComparison struct {
 	Project    string `json:"project"`
-	Name       string `json:"name"`       // fallback field
+	Name       string `json:"name"` // fallback field
 	Diff       string `json:"diff"`
 	Difference string `json:"difference"` // fallback field
 }
 
 // AnalyzeProject sends project data to the LLM and returns a structured analysis.
-func (c *Client) AnalyzeProject(ctx context.Context, p *datastore.Project, readme string) (*datastore.Analysis, error) {
-	// Truncate README to ~3000 chars
-	if len(readme) > 3000 {
-		readme = readme[:3000]
+// Transient failures are retried with jittered exponential backoff (up to
+// llm.retry_max times); when a provider is exhausted the next fallback is
+// used. Analysis.Model records the model that actually answered.
+func (c *Client) AnalyzeProject(ctx context.Context, p *datastore.Project, prompt *Prompt) (*datastore.Analysis, error) {
+	var analysis *datastore.Analysis
+	err := c.failover(ctx, p.FullName, func(b *Client) error {
+		return b.withRetry(ctx, p.FullName, func(ctx context.Context) error {
+			var err error
+			analysis, err = b.analyzeOnce(ctx, p, prompt)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return analysis, nil
+}
+
+// failover runs call with this client and then each fallback in turn,
+// until one succeeds. subject names what the call is for in logs.
+func (c *Client) failover(ctx context.Context, subject string, call func(*Client) error) error {
+	chain := append([]*Client{c}, c.fallbacks...)
+	var errs []error
+	for i, b := range chain {
+		err := call(b)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("%s/%s: %w", b.provider.Name, b.model, err))
+
+		if i+1 < len(chain) {
+			next := chain[i+1]
+			c.log.Warn("LLM provider 不可用，切换到备用",
+				zap.String("project", subject),
+				zap.String("from", b.provider.Name+"/"+b.model),
+				zap.String("to", next.provider.Name+"/"+next.model),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// withRetry runs call against this client's provider, retrying transient
+// errors with jittered exponential backoff up to llm.retry_max times.
+func (c *Client) withRetry(ctx context.Context, subject string, call func(context.Context) error) error {
+	base := c.cfg.RetryBaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	maxDelay := c.cfg.RetryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
 	}
 
-	userPrompt := buildUserPrompt(p, readme)
+	for attempt := 0; ; attempt++ {
+		hctx, hint := withRetryHint(ctx)
+		err := call(hctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || !isRetryable(err) || attempt >= c.cfg.RetryMax {
+			return err
+		}
+
+		delay := backoff(attempt, base, maxDelay, hint.after)
+		c.log.Warn("LLM 调用失败，准备重试",
+			zap.String("project", subject),
+			zap.String("model", c.model),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+		if err := c.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
 
+// analyzeOnce performs a single completion against this client's provider.
+func (c *Client) analyzeOnce(ctx context.Context, p *datastore.Project, prompt *Prompt) (*datastore.Analysis, error) {
 	c.log.Debug("调用 LLM API",
 		zap.String("project", p.FullName),
 		zap.String("model", c.model),
-		zap.Int("prompt_len", len(userPrompt)),
+		zap.String("prompt", prompt.Name+"/"+prompt.Version),
+		zap.Int("prompt_len", len(prompt.User)),
 	)
 
-	req := openai.ChatCompletionRequest{
-		Model: c.model,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
-			{Role: openai.ChatMessageRoleUser, Content: userPrompt},
-		},
-		Temperature: float32(c.cfg.Temperature),
-		MaxTokens:   c.cfg.MaxTokens,
-		ResponseFormat: &openai.ChatCompletionResponseFormat{
-			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
-		},
+	resp, err := c.complete(ctx, prompt)
+	if err != nil {
+		return nil, err
 	}
+	c.reportUsage(p.FullName, resp.Usage)
 
-	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("%w: empty choices", errMalformedOutput)
+	}
+
+	content := extractJSON(resp.Choices[0].Message.Content)
+	analysis, err := c.parseAnalysis(content, prompt)
 	if err != nil {
-		return nil, fmt.Errorf("LLM API: %w", err)
+		return nil, err
+	}
+	usage := resp.Usage
+
+	// Validate, and ask the model to fix errors before giving up on them.
+	issues := ValidateAnalysis(p, analysis)
+	repairs := 0
+	for countErrors(issues) > 0 && repairs < c.cfg.RepairMax {
+		repairs++
+		fixed, fixedContent, used, err := c.repair(ctx, p, prompt, content, issues)
+		usage.PromptTokens += used.PromptTokens
+		usage.CompletionTokens += used.CompletionTokens
+		usage.TotalTokens += used.TotalTokens
+		if err != nil {
+			c.log.Warn("LLM 输出修复失败，保留原结果",
+				zap.String("project", p.FullName),
+				zap.Int("round", repairs),
+				zap.Error(err),
+			)
+			break
+		}
+		fixedIssues := ValidateAnalysis(p, fixed)
+		if countErrors(fixedIssues) > countErrors(issues) {
+			break
+		}
+		analysis, content, issues = fixed, fixedContent, fixedIssues
 	}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("LLM returned empty choices")
+	analysis.TokenUsage = &usage.TotalTokens
+	analysis.PromptTokens = &usage.PromptTokens
+	analysis.CompletionTokens = &usage.CompletionTokens
+	if len(issues) > 0 || repairs > 0 {
+		analysis.Validation = &datastore.Validation{Issues: issues, Repairs: repairs}
+	}
+	if n := countErrors(issues); n > 0 {
+		c.log.Warn("LLM 输出未通过校验，保留为草稿待审核",
+			zap.String("project", p.FullName),
+			zap.Int("errors", n),
+			zap.Int("repairs", repairs),
+		)
+	}
+
+	c.log.Info("LLM 分析完成",
+		zap.String("project", p.FullName),
+		zap.String("model", c.model),
+		zap.String("summary", analysis.Summary),
+		zap.Int("tokens", usage.TotalTokens),
+	)
+
+	return analysis, nil
+}
+
+// repair sends the validation errors back to the model and parses its
+// corrected reply. It returns the usage even on failure.
+func (c *Client) repair(ctx context.Context, p *datastore.Project, prompt *Prompt, previous string, issues []datastore.ValidationIssue) (*datastore.Analysis, string, openai.Usage, error) {
+	c.log.Debug("请求 LLM 修复输出",
+		zap.String("project", p.FullName),
+		zap.Int("errors", countErrors(issues)),
+	)
+
+	resp, err := c.complete(ctx, prompt, repairMessages(previous, issues, datastore.NormalizeLocale(prompt.Locale))...)
+	if err != nil {
+		return nil, "", openai.Usage{}, err
 	}
+	c.reportUsage(p.FullName, resp.Usage)
+	used := resp.Usage
 
-	content := resp.Choices[0].Message.Content
+	if len(resp.Choices) == 0 {
+		return nil, "", used, fmt.Errorf("%w: empty choices", errMalformedOutput)
+	}
+	content := extractJSON(resp.Choices[0].Message.Content)
+	a, err := c.parseAnalysis(content, prompt)
+	if err != nil {
+		return nil, "", used, err
+	}
+	return a, content, used, nil
+}
 
+// parseAnalysis converts the model's JSON reply into a draft Analysis.
+func (c *Client) parseAnalysis(content string, prompt *Prompt) (*datastore.Analysis, error) {
 	var raw llmResponse
 	if err := json.Unmarshal([]byte(content), &raw); err != nil {
-		return nil, fmt.Errorf("parsing LLM JSON: %w (content: %.500s)", err, content)
+		return nil, fmt.Errorf("%w: parsing JSON: %v (content: %.500s)", errMalformedOutput, err, content)
 	}
 
-	// Build Analysis
-	now := time.Now().UTC()
-	totalTokens := resp.Usage.TotalTokens
-
 	analysis := &datastore.Analysis{
-		Status:      "draft",
-		Model:       c.model,
-		Summary:     raw.Summary,
-		Positioning: raw.Positioning,
-		Features:    raw.Features,
-		Advantages:  raw.Advantages,
-		TechStack:   raw.TechStack,
-		UseCases:    raw.UseCases,
-		Ecosystem:   raw.Ecosystem,
-		GeneratedAt: now,
-		TokenUsage:  &totalTokens,
+		Status:        "draft",
+		Locale:        analysisLocale(prompt.Locale),
+		Model:         c.model,
+		PromptName:    prompt.Name,
+		PromptVersion: prompt.Version,
+		Summary:       raw.Summary,
+		Positioning:   raw.Positioning,
+		Features:      raw.Features,
+		Advantages:    raw.Advantages,
+		TechStack:     raw.TechStack,
+		UseCases:      raw.UseCases,
+		Ecosystem:     raw.Ecosystem,
+		GeneratedAt:   c.clock(),
 	}
 
 	// Convert comparison entries
@@ -210,12 +418,98 @@ func (c *Client) AnalyzeProject(ctx context.Context, p *datastore.Project, readm
 			})
 		}
 	}
+	return analysis, nil
+}
 
-	c.log.Info("LLM 分析完成",
-		zap.String("project", p.FullName),
-		zap.String("summary", raw.Summary),
-		zap.Int("tokens", totalTokens),
-	)
+// analysisLocale returns the Analysis.Locale for a prompt locale: empty
+// for the default locale, so Chinese analyses keep their existing form.
+func analysisLocale(locale string) string {
+	if l := datastore.NormalizeLocale(locale); l != datastore.DefaultLocale {
+		return l
+	}
+	return ""
+}
 
-	return analysis, nil
+// complete sends the chat request, falling back to prompt-only JSON mode
+// once if the endpoint rejects response_format. extra messages are
+// appended after the prompt (used for repair round-trips).
+func (c *Client) complete(ctx context.Context, prompt *Prompt, extra ...openai.ChatCompletionMessage) (openai.ChatCompletionResponse, error) {
+	promptJSON := c.promptJSON.Load()
+	resp, err := c.client.CreateChatCompletion(ctx, c.buildRequest(prompt, promptJSON, extra))
+	if err != nil && !promptJSON && isResponseFormatUnsupported(err) {
+		c.log.Warn("endpoint 不支持 response_format，切换为 prompt JSON 模式",
+			zap.String("provider", c.provider.Name),
+			zap.String("model", c.model),
+		)
+		c.promptJSON.Store(true)
+		resp, err = c.client.CreateChatCompletion(ctx, c.buildRequest(prompt, true, extra))
+	}
+	if err != nil {
+		return resp, fmt.Errorf("LLM API (%s): %w", c.provider.Name, err)
+	}
+	return resp, nil
+}
+
+// buildRequest assembles the chat completion request for the given JSON mode.
+func (c *Client) buildRequest(prompt *Prompt, promptJSON bool, extra []openai.ChatCompletionMessage) openai.ChatCompletionRequest {
+	sys := prompt.System
+	if promptJSON {
+		if datastore.NormalizeLocale(prompt.Locale) == datastore.DefaultLocale {
+			sys += promptJSONInstruction
+		} else {
+			sys += promptJSONInstructionEN
+		}
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: sys},
+			{Role: openai.ChatMessageRoleUser, Content: prompt.User},
+		},
+		Temperature: float32(c.cfg.Temperature),
+		MaxTokens:   c.cfg.MaxTokens,
+	}
+	req.Messages = append(req.Messages, extra...)
+	if !promptJSON {
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		}
+	}
+	return req
+}
+
+// isResponseFormatUnsupported reports whether err is a 400/422 rejecting
+// the response_format parameter.
+func isResponseFormatUnsupported(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.HTTPStatusCode != http.StatusBadRequest && apiErr.HTTPStatusCode != http.StatusUnprocessableEntity {
+			return false
+		}
+		if apiErr.Param != nil && *apiErr.Param == "response_format" {
+			return true
+		}
+		return strings.Contains(strings.ToLower(apiErr.Message), "response_format")
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		if reqErr.HTTPStatusCode != http.StatusBadRequest && reqErr.HTTPStatusCode != http.StatusUnprocessableEntity {
+			return false
+		}
+		return strings.Contains(strings.ToLower(string(reqErr.Body)), "response_format")
+	}
+	return false
+}
+
+// extractJSON pulls the outermost JSON object out of a model reply,
+// tolerating Markdown code fences and surrounding prose.
+func extractJSON(content string) string {
+	s := strings.TrimSpace(content)
+	start := strings.Index(s, "{")
+	end := strings.LastIndex(s, "}")
+	if start < 0 || end <= start {
+		return s
+	}
+	return s[start : end+1]
 }
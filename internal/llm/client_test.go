@@ -73,7 +73,7 @@ func TestBuildUserPrompt(t *testing.T) {
 		Topics:      []string{"ai", "ml"},
 	}
 
-	prompt := buildUserPrompt(p, "# README content")
+	prompt := renderBuiltin(t, p, "# README content")
 
 	// Should contain project name
 	if !containsStr(prompt, "owner/repo") {
@@ -101,36 +101,25 @@ func TestBuildUserPrompt_NilFields(t *testing.T) {
 		FullName: "owner/repo",
 		Stars:    100,
 	}
-	prompt := buildUserPrompt(p, "")
+	prompt := renderBuiltin(t, p, "")
 	if !containsStr(prompt, "owner/repo") {
 		t.Error("prompt missing project name")
 	}
 }
 
-func TestNeedsAnalysis(t *testing.T) {
-	tests := []struct {
-		name string
-		p    *datastore.Project
-		want bool
-	}{
-		{"no analysis", &datastore.Project{}, true},
-		{"published", &datastore.Project{Analysis: &datastore.Analysis{Status: "published"}}, false},
-		{"rejected", &datastore.Project{Analysis: &datastore.Analysis{Status: "rejected"}}, false},
-		{"fresh draft", &datastore.Project{
-			Analysis: &datastore.Analysis{Status: "draft", GeneratedAt: timeNow()},
-		}, false},
-		// Stale draft older than 7 days would need re-analysis but we can't easily
-		// test time-dependent behavior without mocking
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := needsAnalysis(tt.p)
-			if got != tt.want {
-				t.Errorf("needsAnalysis = %v, want %v", got, tt.want)
-			}
-		})
+// renderBuiltin renders the built-in prompt's user part.
+func renderBuiltin(t *testing.T, p *datastore.Project, readme string) string {
+	t.Helper()
+	prompt, err := builtinPrompts().For(p, datastore.DefaultLocale).Render(PromptData{Project: p, README: readme})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
 	}
+	return prompt.User
+}
+
+// testPrompt is a minimal rendered prompt for client tests.
+func testPrompt() *Prompt {
+	return &Prompt{Name: "default", Version: "v1", System: "system", User: "user"}
 }
 
 func containsStr(s, substr string) bool {
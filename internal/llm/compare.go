@@ -0,0 +1,275 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/google/go-github/v67/github"
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// minSearchStars is the star count below which a repository found only by
+// searching for a bare name is treated as suspicious rather than as the
+// project the LLM meant.
+const minSearchStars = 100
+
+// ComparisonResolver links Analysis.Comparison entries to tracked projects
+// or GitHub repositories. Names written as owner/repo are looked up
+// directly; bare names are matched against tracked repository names and
+// otherwise searched on GitHub. Lookups are cached for the resolver's
+// lifetime and it is safe for concurrent use.
+type ComparisonResolver struct {
+	gh  *github.Client
+	log *zap.Logger
+
+	byFullName map[string]*datastore.Project // lowercased owner/repo
+	byRepo     map[string]*datastore.Project // nameKey of the repo name, most starred wins
+
+	mu    sync.Mutex
+	repos map[string]*repoMatch // lookup key -> match, nil = not found
+}
+
+// repoMatch is a GitHub repository a comparison name resolved to.
+type repoMatch struct {
+	fullName string
+	stars    int
+}
+
+// NewComparisonResolver indexes the tracked projects and creates a GitHub
+// client with ghToken (unauthenticated when empty).
+func NewComparisonResolver(projects []*datastore.Project, ghToken string, log *zap.Logger, opts ...Option) *ComparisonResolver {
+	return newComparisonResolver(projects, newGitHubClient(ghToken, buildOptions(opts)), log)
+}
+
+func newComparisonResolver(projects []*datastore.Project, gh *github.Client, log *zap.Logger) *ComparisonResolver {
+	r := &ComparisonResolver{
+		gh:         gh,
+		log:        log.Named("compare"),
+		byFullName: make(map[string]*datastore.Project, len(projects)),
+		byRepo:     make(map[string]*datastore.Project, len(projects)),
+		repos:      make(map[string]*repoMatch),
+	}
+	for _, p := range projects {
+		r.byFullName[strings.ToLower(p.FullName)] = p
+		_, name, _ := strings.Cut(p.FullName, "/")
+		key := nameKey(name)
+		if prev := r.byRepo[key]; prev == nil || p.Stars > prev.Stars {
+			r.byRepo[key] = p
+		}
+	}
+	return r
+}
+
+// Resolve fills in ProjectID, Repo, Status and Note of every comparison
+// entry of a, which was generated for p, and records suspicious and
+// unresolved entries as warnings in a.Validation so they show up in
+// tishi review. Entries whose lookup fails (network, rate limit) are left
+// unchecked. It returns the number of entries that could not be verified.
+func (r *ComparisonResolver) Resolve(ctx context.Context, p *datastore.Project, a *datastore.Analysis) int {
+	var issues []datastore.ValidationIssue
+	if a.Validation != nil {
+		for _, is := range a.Validation.Issues {
+			if !strings.HasSuffix(is.Field, ".project") {
+				issues = append(issues, is)
+			}
+		}
+	}
+
+	unverified := 0
+	for i := range a.Comparison {
+		c := &a.Comparison[i]
+		c.ProjectID, c.Repo, c.Status, c.Note = "", "", "", ""
+		if err := r.resolveEntry(ctx, p, c); err != nil {
+			r.log.Warn("对比项目解析失败，保留为未检查",
+				zap.String("project", p.FullName),
+				zap.String("comparison", c.Project),
+				zap.Error(err),
+			)
+			continue
+		}
+		if c.Status == datastore.ComparisonSuspicious || c.Status == datastore.ComparisonUnresolved {
+			unverified++
+			issues = append(issues, datastore.ValidationIssue{
+				Field:   fmt.Sprintf("comparison[%d].project", i),
+				Level:   levelWarning,
+				Message: fmt.Sprintf("%s: %s", c.Project, c.Note),
+			})
+		}
+	}
+
+	switch {
+	case a.Validation != nil:
+		a.Validation.Issues = issues
+	case len(issues) > 0:
+		a.Validation = &datastore.Validation{Issues: issues}
+	}
+	return unverified
+}
+
+func (r *ComparisonResolver) resolveEntry(ctx context.Context, p *datastore.Project, c *datastore.ComparisonEntry) error {
+	name := repoRef(c.Project)
+	if name == "" {
+		c.Status = datastore.ComparisonUnresolved
+		c.Note = "名称为空"
+		return nil
+	}
+
+	if owner, repo, ok := strings.Cut(name, "/"); ok {
+		if t := r.byFullName[strings.ToLower(name)]; t != nil {
+			r.setTracked(c, t)
+		} else {
+			m, err := r.lookupRepo(ctx, owner, repo)
+			if err != nil {
+				return err
+			}
+			if m == nil {
+				c.Status = datastore.ComparisonUnresolved
+				c.Note = fmt.Sprintf("GitHub 上不存在 %s", name)
+				return nil
+			}
+			r.setRepo(c, m)
+		}
+	} else {
+		if t := r.byRepo[nameKey(name)]; t != nil {
+			r.setTracked(c, t)
+		} else {
+			m, err := r.searchRepo(ctx, name)
+			if err != nil {
+				return err
+			}
+			if m == nil {
+				c.Status = datastore.ComparisonUnresolved
+				c.Note = "GitHub 上找不到同名仓库"
+				return nil
+			}
+			r.setRepo(c, m)
+			if m.stars < minSearchStars {
+				c.Status = datastore.ComparisonSuspicious
+				c.Note = fmt.Sprintf("仅找到 star 很少的同名仓库 %s（%d star）", m.fullName, m.stars)
+			}
+		}
+	}
+
+	if strings.EqualFold(c.Repo, p.FullName) {
+		c.ProjectID = ""
+		c.Status = datastore.ComparisonSuspicious
+		c.Note = "解析结果为项目自身"
+	}
+	return nil
+}
+
+func (r *ComparisonResolver) setTracked(c *datastore.ComparisonEntry, t *datastore.Project) {
+	c.ProjectID = t.ID
+	c.Repo = t.FullName
+	c.Status = datastore.ComparisonTracked
+}
+
+// setRepo records a GitHub match, upgrading it to tracked when the
+// canonical name (after renames) is a tracked project.
+func (r *ComparisonResolver) setRepo(c *datastore.ComparisonEntry, m *repoMatch) {
+	if t := r.byFullName[strings.ToLower(m.fullName)]; t != nil {
+		r.setTracked(c, t)
+		return
+	}
+	c.Repo = m.fullName
+	c.Status = datastore.ComparisonGitHub
+}
+
+// lookupRepo fetches owner/repo from GitHub, returning nil when it does
+// not exist.
+func (r *ComparisonResolver) lookupRepo(ctx context.Context, owner, repo string) (*repoMatch, error) {
+	key := "repo:" + strings.ToLower(owner+"/"+repo)
+	return r.cached(key, func() (*repoMatch, error) {
+		gr, _, err := r.gh.Repositories.Get(ctx, owner, repo)
+		if err != nil {
+			if isNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("GitHub API: %w", err)
+		}
+		return &repoMatch{fullName: gr.GetFullName(), stars: gr.GetStargazersCount()}, nil
+	})
+}
+
+// searchRepo searches GitHub for a repository whose name matches name,
+// ignoring case and punctuation, and returns the most starred one.
+func (r *ComparisonResolver) searchRepo(ctx context.Context, name string) (*repoMatch, error) {
+	key := "search:" + nameKey(name)
+	return r.cached(key, func() (*repoMatch, error) {
+		res, _, err := r.gh.Search.Repositories(ctx, name+" in:name", &github.SearchOptions{
+			Sort:        "stars",
+			Order:       "desc",
+			ListOptions: github.ListOptions{PerPage: 10},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("GitHub search: %w", err)
+		}
+		want := nameKey(name)
+		for _, gr := range res.Repositories {
+			if nameKey(gr.GetName()) == want {
+				return &repoMatch{fullName: gr.GetFullName(), stars: gr.GetStargazersCount()}, nil
+			}
+		}
+		return nil, nil
+	})
+}
+
+// cached runs fetch once per key; errors are not cached.
+func (r *ComparisonResolver) cached(key string, fetch func() (*repoMatch, error)) (*repoMatch, error) {
+	r.mu.Lock()
+	m, ok := r.repos[key]
+	r.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+
+	m, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.repos[key] = m
+	r.mu.Unlock()
+	return m, nil
+}
+
+func isNotFound(err error) bool {
+	var er *github.ErrorResponse
+	return errors.As(err, &er) && er.Response != nil && er.Response.StatusCode == http.StatusNotFound
+}
+
+// repoRef trims a comparison name down to owner/repo or a bare name,
+// dropping a GitHub URL prefix, a .git suffix and trailing slashes.
+func repoRef(name string) string {
+	s := strings.TrimSpace(name)
+	for _, prefix := range []string{"https://", "http://", "www.", "github.com/"} {
+		if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+			s = s[len(prefix):]
+		}
+	}
+	s = strings.TrimRight(s, "/")
+	// Deep links such as owner/repo/tree/main keep only owner/repo.
+	if parts := strings.Split(s, "/"); len(parts) > 2 {
+		s = parts[0] + "/" + parts[1]
+	}
+	return strings.TrimSuffix(s, ".git")
+}
+
+// nameKey normalizes a project name for matching: lowercased letters and
+// digits only, so "LlamaIndex", "llama_index" and "llama-index" agree.
+func nameKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v67/github"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func TestComparisonResolver_Resolve(t *testing.T) {
+	var searches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/old-owner/chroma":
+			// Renamed repositories resolve to their canonical name.
+			_, _ = w.Write([]byte(`{"full_name":"chroma-core/chroma","stargazers_count":15000}`))
+		case "/repos/facebookresearch/faiss":
+			_, _ = w.Write([]byte(`{"full_name":"facebookresearch/faiss","stargazers_count":30000}`))
+		case "/search/repositories":
+			searches.Add(1)
+			switch q := r.URL.Query().Get("q"); {
+			case strings.HasPrefix(q, "Semantic Kernel"):
+				_, _ = w.Write([]byte(`{"items":[` +
+					`{"name":"semantic-kernel-docs","full_name":"x/semantic-kernel-docs","stargazers_count":900},` +
+					`{"name":"semantic-kernel","full_name":"microsoft/semantic-kernel","stargazers_count":21000}]}`))
+			case strings.HasPrefix(q, "TinyAgent"):
+				_, _ = w.Write([]byte(`{"items":[{"name":"tinyagent","full_name":"someone/tinyagent","stargazers_count":3}]}`))
+			default:
+				_, _ = w.Write([]byte(`{"items":[]}`))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	tracked := []*datastore.Project{
+		{ID: "run-llama__llama_index", FullName: "run-llama/llama_index", Stars: 35000},
+		{ID: "chroma-core__chroma", FullName: "chroma-core/chroma", Stars: 15000},
+		{ID: "me__demo", FullName: "me/demo"},
+	}
+	r := newComparisonResolver(tracked, gh, testLogger())
+
+	p := tracked[2]
+	a := &datastore.Analysis{
+		Comparison: []datastore.ComparisonEntry{
+			{Project: "LlamaIndex"},
+			{Project: "https://github.com/old-owner/chroma"},
+			{Project: "facebookresearch/faiss"},
+			{Project: "Semantic Kernel"},
+			{Project: "TinyAgent"},
+			{Project: "NoSuchThing"},
+			{Project: "ghost/repo"},
+		},
+		Validation: &datastore.Validation{Issues: []datastore.ValidationIssue{
+			{Field: "summary", Level: levelError, Message: "kept"},
+			{Field: "comparison[0].project", Level: levelWarning, Message: "stale"},
+		}},
+	}
+
+	if n := r.Resolve(context.Background(), p, a); n != 3 {
+		t.Errorf("unverified = %d, want 3", n)
+	}
+
+	want := []datastore.ComparisonEntry{
+		{ProjectID: "run-llama__llama_index", Repo: "run-llama/llama_index", Status: datastore.ComparisonTracked},
+		{ProjectID: "chroma-core__chroma", Repo: "chroma-core/chroma", Status: datastore.ComparisonTracked},
+		{Repo: "facebookresearch/faiss", Status: datastore.ComparisonGitHub},
+		{Repo: "microsoft/semantic-kernel", Status: datastore.ComparisonGitHub},
+		{Repo: "someone/tinyagent", Status: datastore.ComparisonSuspicious},
+		{Status: datastore.ComparisonUnresolved},
+		{Status: datastore.ComparisonUnresolved},
+	}
+	for i, w := range want {
+		c := a.Comparison[i]
+		if c.ProjectID != w.ProjectID || c.Repo != w.Repo || c.Status != w.Status {
+			t.Errorf("%s: got (%q, %q, %q), want (%q, %q, %q)", c.Project,
+				c.ProjectID, c.Repo, c.Status, w.ProjectID, w.Repo, w.Status)
+		}
+	}
+
+	// Earlier resolution issues are replaced, other issues kept.
+	fields := make([]string, 0, len(a.Validation.Issues))
+	for _, is := range a.Validation.Issues {
+		fields = append(fields, is.Field)
+	}
+	if got := strings.Join(fields, ","); got != "summary,comparison[4].project,comparison[5].project,comparison[6].project" {
+		t.Errorf("issue fields = %s", got)
+	}
+
+	// Lookups are cached across analyses.
+	before := searches.Load()
+	r.Resolve(context.Background(), p, &datastore.Analysis{
+		Comparison: []datastore.ComparisonEntry{{Project: "semantic-kernel"}},
+	})
+	if searches.Load() != before {
+		t.Error("search repeated for a cached name")
+	}
+}
+
+func TestComparisonResolver_Self(t *testing.T) {
+	p := &datastore.Project{ID: "me__demo", FullName: "me/demo"}
+	r := newComparisonResolver([]*datastore.Project{p}, github.NewClient(nil), testLogger())
+
+	a := &datastore.Analysis{Comparison: []datastore.ComparisonEntry{{Project: "Demo"}}}
+	r.Resolve(context.Background(), p, a)
+
+	c := a.Comparison[0]
+	if c.Status != datastore.ComparisonSuspicious || c.ProjectID != "" {
+		t.Errorf("self comparison: status=%q project_id=%q", c.Status, c.ProjectID)
+	}
+	if a.Validation == nil || len(a.Validation.Issues) != 1 {
+		t.Errorf("want one issue, got %+v", a.Validation)
+	}
+}
+
+func TestRepoRef(t *testing.T) {
+	cases := map[string]string{
+		"  LangChain ":                      "LangChain",
+		"https://github.com/owner/repo.git": "owner/repo",
+		"github.com/owner/repo/tree/main/":  "owner/repo",
+		"www.github.com/Owner/Repo":         "Owner/Repo",
+		"milvus-io/milvus":                  "milvus-io/milvus",
+		"http://github.com/a/b/":            "a/b",
+	}
+	for in, want := range cases {
+		if got := repoRef(in); got != want {
+			t.Errorf("repoRef(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// Defaults for llm.embedding when unset.
+const (
+	defaultEmbedBatchSize = 32
+	defaultSimilarTopK    = 5
+)
+
+// Embedder keeps project embeddings in data/embeddings/ up to date and
+// derives Project.Similar from them. Only projects whose embedding input
+// (description, topics, published summary) or model changed are sent to
+// the embeddings endpoint; similarities are recomputed from the stored
+// vectors on every run.
+type Embedder struct {
+	store  *datastore.Store
+	client *Client
+	cfg    config.LLMEmbeddingConfig
+	log    *zap.Logger
+	now    func() time.Time
+}
+
+// NewEmbedder creates an Embedder for the llm.embedding endpoint. The
+// provider, key and base URL default to the llm.* settings; the model to
+// the provider's embeddings model. A different embeddings provider needs
+// its own llm.embedding.api_key unless it takes none.
+func NewEmbedder(store *datastore.Store, llmCfg config.LLMConfig, log *zap.Logger, opts ...Option) (*Embedder, error) {
+	o := buildOptions(opts)
+	emb := llmCfg.Embedding
+
+	ecfg := llmCfg
+	ecfg.Fallbacks = nil
+	crossProvider := emb.Provider != "" && !strings.EqualFold(emb.Provider, llmCfg.Provider)
+	if crossProvider {
+		// Endpoint overrides and the key belong to the chat provider only:
+		// never send llm.api_key to another vendor.
+		ecfg.Provider = emb.Provider
+		ecfg.BaseURL = ""
+		ecfg.APIKey = ""
+		ecfg.Headers = nil
+		ecfg.JSONMode = ""
+	}
+	if emb.BaseURL != "" {
+		ecfg.BaseURL = emb.BaseURL
+	}
+	if emb.APIKey != "" {
+		ecfg.APIKey = emb.APIKey
+	}
+
+	provider, err := resolveProvider(ecfg)
+	if err != nil {
+		return nil, err
+	}
+	if crossProvider && ecfg.APIKey == "" && !provider.NoAPIKey && !o.offline() {
+		return nil, fmt.Errorf("llm.embedding.api_key is required for provider %s (llm.api_key is not shared across providers)", provider.Name)
+	}
+	ecfg.Model = emb.Model
+	if ecfg.Model == "" {
+		ecfg.Model = provider.EmbedModel
+	}
+	if ecfg.Model == "" {
+		return nil, fmt.Errorf("llm.embedding.model is required for provider %s (it has no default embeddings model)", provider.Name)
+	}
+
+	client, err := newClient(ecfg, log, o)
+	if err != nil {
+		return nil, fmt.Errorf("creating embeddings client: %w", err)
+	}
+	if !o.offline() {
+		client.SetUsageHook(NewUsageLedger(store, "embed", NewPriceTable(llmCfg.Prices), log.Named("usage")).Record)
+	}
+	return &Embedder{
+		store:  store,
+		client: client,
+		cfg:    emb,
+		log:    log.Named("embed"),
+		now:    o.clock(),
+	}, nil
+}
+
+// EmbedOptions configures an embedding run.
+type EmbedOptions struct {
+	Force  bool // re-embed every project
+	DryRun bool // report what would be embedded, don't call the API or write
+}
+
+// Run embeds new and changed projects and rewrites Project.Similar for
+// every project whose neighbours changed.
+func (e *Embedder) Run(ctx context.Context, opts EmbedOptions) error {
+	start := time.Now()
+
+	projects, err := e.store.ListProjects()
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+	stored, err := e.store.ListEmbeddings()
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		stored = make(map[string]*datastore.Embedding)
+	}
+
+	model := e.client.model
+	var pending []*datastore.Project
+	inputs := make(map[string]string)
+	for _, p := range projects {
+		text := embeddingInput(p)
+		hash := embeddingHash(model, text)
+		if old := stored[p.ID]; !opts.Force && old != nil && old.Model == model && old.InputHash == hash {
+			continue
+		}
+		pending = append(pending, p)
+		inputs[p.ID] = text
+	}
+
+	e.log.Info("待生成 embedding 的项目",
+		zap.Int("pending", len(pending)),
+		zap.Int("total", len(projects)),
+		zap.String("model", model),
+		zap.Bool("force", opts.Force),
+	)
+	if opts.DryRun {
+		for _, p := range pending {
+			e.log.Info("dry-run: 将生成 embedding", zap.String("project", p.FullName))
+		}
+		return nil
+	}
+
+	batchSize := firstPositive(e.cfg.BatchSize, defaultEmbedBatchSize)
+	embedded := 0
+	for i := 0; i < len(pending); i += batchSize {
+		batch := pending[i:min(i+batchSize, len(pending))]
+		texts := make([]string, len(batch))
+		for j, p := range batch {
+			texts[j] = inputs[p.ID]
+		}
+
+		vectors, err := e.client.Embed(ctx, texts)
+		if err != nil {
+			// Keep what was embedded so far; the rest is retried next run.
+			e.log.Warn("embedding 请求失败，跳过本批", zap.Int("batch", len(batch)), zap.Error(err))
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		now := e.now()
+		for j, p := range batch {
+			emb := &datastore.Embedding{
+				ProjectID:  p.ID,
+				Model:      model,
+				InputHash:  embeddingHash(model, texts[j]),
+				Vector:     vectors[j],
+				EmbeddedAt: now,
+			}
+			if err := e.store.SaveEmbedding(emb); err != nil {
+				return err
+			}
+			stored[p.ID] = emb
+			embedded++
+		}
+	}
+
+	updated := 0
+	similar := computeSimilar(projects, stored, model,
+		firstPositive(e.cfg.TopK, defaultSimilarTopK), e.cfg.MinScore)
+	for _, p := range projects {
+		next := similar[p.ID]
+		if slices.Equal(p.Similar, next) {
+			continue
+		}
+		p.Similar = next
+		if err := e.store.SaveProject(p); err != nil {
+			return fmt.Errorf("saving project: %w", err)
+		}
+		updated++
+	}
+
+	e.log.Info("embedding 完成",
+		zap.Int("embedded", embedded),
+		zap.Int("failed", len(pending)-embedded),
+		zap.Int("similar_updated", updated),
+		zap.Duration("elapsed", time.Since(start)),
+	)
+	return nil
+}
+
+// embeddingInput is the text embedded for a project: name, description,
+// topics and the published Chinese summary. Unreviewed drafts are left out
+// so the vector only changes when published content does.
+func embeddingInput(p *datastore.Project) string {
+	var b strings.Builder
+	b.WriteString(p.FullName)
+	if p.Description != nil && *p.Description != "" {
+		b.WriteString("\n")
+		b.WriteString(*p.Description)
+	}
+	if len(p.Topics) > 0 {
+		b.WriteString("\nTopics: ")
+		b.WriteString(strings.Join(p.Topics, ", "))
+	}
+	if a := p.PublishedAnalysis(datastore.DefaultLocale); a != nil && a.Summary != "" {
+		b.WriteString("\n")
+		b.WriteString(a.Summary)
+	}
+	return b.String()
+}
+
+func embeddingHash(model, text string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + text))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// computeSimilar returns, per project ID, the topK other projects with the
+// highest cosine similarity of at least minScore, best first. Only
+// embeddings made with model are compared; projects without one get none.
+func computeSimilar(projects []*datastore.Project, embeddings map[string]*datastore.Embedding, model string, topK int, minScore float64) map[string][]datastore.SimilarProject {
+	type entry struct {
+		p   *datastore.Project
+		vec []float64 // unit length
+	}
+	var entries []entry
+	for _, p := range projects {
+		emb := embeddings[p.ID]
+		if emb == nil || emb.Model != model {
+			continue
+		}
+		if vec := normalize(emb.Vector); vec != nil {
+			entries = append(entries, entry{p, vec})
+		}
+	}
+
+	out := make(map[string][]datastore.SimilarProject, len(entries))
+	for i, a := range entries {
+		var cands []datastore.SimilarProject
+		for j, b := range entries {
+			if i == j || len(a.vec) != len(b.vec) {
+				continue
+			}
+			score := dot(a.vec, b.vec)
+			if score < minScore {
+				continue
+			}
+			cands = append(cands, datastore.SimilarProject{
+				ProjectID: b.p.ID,
+				FullName:  b.p.FullName,
+				Score:     math.Round(score*1000) / 1000,
+			})
+		}
+		sort.SliceStable(cands, func(x, y int) bool {
+			if cands[x].Score != cands[y].Score {
+				return cands[x].Score > cands[y].Score
+			}
+			return cands[x].ProjectID < cands[y].ProjectID
+		})
+		if len(cands) > topK {
+			cands = cands[:topK]
+		}
+		if len(cands) > 0 {
+			out[a.p.ID] = cands
+		}
+	}
+	return out
+}
+
+// normalize returns v scaled to unit length, or nil for a zero vector.
+func normalize(v []float32) []float64 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = float64(x) / norm
+	}
+	return out
+}
+
+func dot(a, b []float64) float64 {
+	var s float64
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// Embed returns one embedding vector per input, in order, retrying
+// transient errors like chat completions do.
+func (c *Client) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	var vectors [][]float32
+	err := c.withRetry(ctx, "embeddings", func(ctx context.Context) error {
+		var err error
+		vectors, err = c.embedOnce(ctx, inputs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+func (c *Client) embedOnce(ctx context.Context, inputs []string) ([][]float32, error) {
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: inputs,
+		Model: openai.EmbeddingModel(c.model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("embeddings API (%s): %w", c.provider.Name, err)
+	}
+	c.reportUsage("", resp.Usage)
+
+	if len(resp.Data) != len(inputs) {
+		return nil, fmt.Errorf("%w: got %d embeddings for %d inputs", errMalformedOutput, len(resp.Data), len(inputs))
+	}
+	out := make([][]float32, len(inputs))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(out) || len(d.Embedding) == 0 {
+			return nil, fmt.Errorf("%w: bad embedding index %d", errMalformedOutput, d.Index)
+		}
+		out[d.Index] = d.Embedding
+	}
+	return out, nil
+}
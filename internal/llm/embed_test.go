@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func strp(s string) *string { return &s }
+
+// fakeEmbeddings serves /embeddings with vectors that place texts about
+// agents, vectors and other topics on separate axes, and records inputs.
+func fakeEmbeddings(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		inputs []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/embeddings") {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Input []string `json:"input"`
+			Model string   `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		mu.Lock()
+		inputs = append(inputs, req.Input...)
+		mu.Unlock()
+
+		type item struct {
+			Embedding []float32 `json:"embedding"`
+			Index     int       `json:"index"`
+		}
+		var data []item
+		for i, in := range req.Input {
+			v := []float32{0.1, 0.1, 0.1}
+			switch {
+			case strings.Contains(in, "agent"):
+				v[0] = 1
+			case strings.Contains(in, "vector"):
+				v[1] = 1
+			default:
+				v[2] = 1
+			}
+			data = append(data, item{v, i})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data, "model": req.Model})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		out := inputs
+		inputs = nil
+		return out
+	}
+}
+
+func TestEmbedder_Run(t *testing.T) {
+	srv, takeInputs := fakeEmbeddings(t)
+	store := datastore.NewStore(t.TempDir(), testLogger())
+
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	projects := []*datastore.Project{
+		{ID: "a__agent1", FullName: "a/agent1", Description: strp("An agent framework"), Topics: []string{"agent"}},
+		{ID: "b__agent2", FullName: "b/agent2", Description: strp("Multi-agent toolkit")},
+		{ID: "c__vec", FullName: "c/vec", Description: strp("A vector database")},
+		{ID: "d__other", FullName: "d/other", Description: strp("Image generation")},
+	}
+	for _, p := range projects {
+		p.FirstSeenAt, p.UpdatedAt = now, now
+		if err := store.SaveProject(p); err != nil {
+			t.Fatalf("SaveProject: %v", err)
+		}
+	}
+
+	e, err := NewEmbedder(store, config.LLMConfig{
+		Provider:  "ollama",
+		BaseURL:   srv.URL,
+		Embedding: config.LLMEmbeddingConfig{TopK: 2, MinScore: 0.5},
+	}, testLogger())
+	if err != nil {
+		t.Fatalf("NewEmbedder: %v", err)
+	}
+	if e.client.model != "nomic-embed-text" {
+		t.Errorf("model = %q, want provider default", e.client.model)
+	}
+
+	ctx := context.Background()
+	if err := e.Run(ctx, EmbedOptions{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := takeInputs(); len(got) != 4 {
+		t.Fatalf("first run embedded %d inputs, want 4", len(got))
+	}
+
+	p, _ := store.LoadProject("a__agent1")
+	if len(p.Similar) != 1 || p.Similar[0].ProjectID != "b__agent2" || p.Similar[0].FullName != "b/agent2" {
+		t.Errorf("a/agent1 Similar = %+v, want only b/agent2", p.Similar)
+	}
+	if p.Similar[0].Score < 0.99 {
+		t.Errorf("score = %v, want ~1", p.Similar[0].Score)
+	}
+	if p, _ := store.LoadProject("d__other"); len(p.Similar) != 0 {
+		t.Errorf("d/other Similar = %+v, want none above min_score", p.Similar)
+	}
+
+	// Unchanged projects are not re-embedded.
+	if err := e.Run(ctx, EmbedOptions{}); err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if got := takeInputs(); len(got) != 0 {
+		t.Errorf("second run embedded %v, want nothing", got)
+	}
+
+	// Changing a description re-embeds only that project and moves it.
+	c, _ := store.LoadProject("c__vec")
+	c.Description = strp("An agent memory store")
+	if err := store.SaveProject(c); err != nil {
+		t.Fatalf("SaveProject: %v", err)
+	}
+	if err := e.Run(ctx, EmbedOptions{}); err != nil {
+		t.Fatalf("third Run: %v", err)
+	}
+	if got := takeInputs(); len(got) != 1 || !strings.HasPrefix(got[0], "c/vec") {
+		t.Errorf("third run embedded %v, want only c/vec", got)
+	}
+	p, _ = store.LoadProject("a__agent1")
+	if len(p.Similar) != 2 {
+		t.Errorf("a/agent1 Similar = %+v, want 2 (top_k)", p.Similar)
+	}
+
+	// Dry runs call nothing.
+	if err := e.Run(ctx, EmbedOptions{Force: true, DryRun: true}); err != nil {
+		t.Fatalf("dry Run: %v", err)
+	}
+	if got := takeInputs(); len(got) != 0 {
+		t.Errorf("dry run embedded %v", got)
+	}
+}
+
+func TestNewEmbedder_NoDefaultModel(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	_, err := NewEmbedder(store, config.LLMConfig{Provider: "deepseek", APIKey: "k"}, testLogger())
+	if err == nil || !strings.Contains(err.Error(), "llm.embedding.model") {
+		t.Errorf("err = %v, want llm.embedding.model required", err)
+	}
+
+	// A different embeddings provider inherits neither the chat endpoint
+	// nor its key.
+	cfg := config.LLMConfig{
+		Provider: "deepseek", APIKey: "k", BaseURL: "http://chat.invalid",
+		Embedding: config.LLMEmbeddingConfig{Provider: "qwen"},
+	}
+	if _, err := NewEmbedder(store, cfg, testLogger()); err == nil || !strings.Contains(err.Error(), "llm.embedding.api_key") {
+		t.Errorf("err = %v, want llm.embedding.api_key required", err)
+	}
+	cfg.Embedding.APIKey = "qk"
+	e, err := NewEmbedder(store, cfg, testLogger())
+	if err != nil {
+		t.Fatalf("NewEmbedder: %v", err)
+	}
+	if e.client.model != "text-embedding-v3" || strings.Contains(e.client.provider.BaseURL, "chat.invalid") || e.client.cfg.APIKey != "qk" {
+		t.Errorf("model=%q base_url=%q key=%q", e.client.model, e.client.provider.BaseURL, e.client.cfg.APIKey)
+	}
+
+	// Keyless providers need none.
+	cfg.Embedding = config.LLMEmbeddingConfig{Provider: "ollama"}
+	if _, err := NewEmbedder(store, cfg, testLogger()); err != nil {
+		t.Errorf("ollama embeddings: %v", err)
+	}
+}
+
+func TestComputeSimilar_ModelMismatch(t *testing.T) {
+	projects := []*datastore.Project{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	embs := map[string]*datastore.Embedding{
+		"a": {Model: "m1", Vector: []float32{1, 0}},
+		"b": {Model: "m1", Vector: []float32{0.9, 0.1}},
+		"c": {Model: "m2", Vector: []float32{1, 0}},
+	}
+	got := computeSimilar(projects, embs, "m1", 5, 0)
+	if len(got["a"]) != 1 || got["a"][0].ProjectID != "b" {
+		t.Errorf("a: %+v", got["a"])
+	}
+	if _, ok := got["c"]; ok {
+		t.Errorf("c embedded with another model should have no neighbours")
+	}
+}
@@ -0,0 +1,129 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// defaultReanalyzeMaxAge applies when llm.reanalyze_max_age is unset.
+const defaultReanalyzeMaxAge = 30 * 24 * time.Hour
+
+// inputHash fingerprints the inputs that determine an analysis: README,
+// description, topics, prompt (name/version) and model. Whitespace is
+// collapsed and topics are sorted so cosmetic edits do not count as
+// changes. The detected tech stack has its own hash, see techStackHash.
+func inputHash(p *datastore.Project, readme, prompt, model string) string {
+	desc := ""
+	if p.Description != nil {
+		desc = *p.Description
+	}
+	topics := slices.Clone(p.Topics)
+	for i := range topics {
+		topics[i] = strings.ToLower(topics[i])
+	}
+	slices.Sort(topics)
+
+	h := sha256.New()
+	for _, part := range []string{
+		normalizeSpace(readme),
+		normalizeSpace(desc),
+		strings.Join(topics, ","),
+		prompt,
+		model,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// techStackHash fingerprints the languages, frameworks and dependencies
+// of a detected tech stack, or returns "" for none. Each analysis records
+// the hash of the stack in its prompt: the stack is shared by all locales
+// of a project but only re-detected for the ones being analyzed, so it is
+// compared against that snapshot rather than folded into inputHash.
+func techStackHash(ts *datastore.TechStack) string {
+	if ts == nil {
+		return ""
+	}
+	h := sha256.New()
+	for _, list := range [][]string{ts.Languages, ts.Frameworks, ts.Dependencies} {
+		h.Write([]byte(strings.Join(list, ",")))
+		h.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// stackChanged reports whether the tech stack a was generated with
+// differs from the project's current one. Analyses generated without a
+// stack are not affected by one being detected later.
+func stackChanged(p *datastore.Project, a *datastore.Analysis) bool {
+	return a.TechStackHash != "" && a.TechStackHash != techStackHash(p.TechStack)
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// decision is the outcome of checking one project's analysis freshness.
+type decision struct {
+	Analyze bool   // generate a new analysis version
+	Stale   bool   // flag the live published analysis as stale
+	Reason  string // human-readable explanation, shown in --dry-run
+}
+
+// decide determines whether a project needs (re-)analysis in locale given
+// the hash of its current inputs. A published analysis whose inputs changed
+// is flagged stale and a new draft is generated next to it; the published
+// text stays live until the draft is reviewed.
+func decide(p *datastore.Project, locale, hash string, maxAge time.Duration, now time.Time) decision {
+	if d := p.DraftFor(locale); d != nil {
+		if d.InputHash == hash && !stackChanged(p, d) && !(maxAge > 0 && now.Sub(d.GeneratedAt) > maxAge) {
+			return decision{Reason: "已有待审核的新草稿"}
+		}
+		return decision{Analyze: true, Reason: "待审核草稿已过时，重新生成"}
+	}
+
+	a := p.AnalysisFor(locale)
+	if a == nil {
+		return decision{Analyze: true, Reason: "尚无分析"}
+	}
+
+	changed := a.InputHash != "" && (a.InputHash != hash || stackChanged(p, a))
+	expired := maxAge > 0 && now.Sub(a.GeneratedAt) > maxAge
+
+	switch a.Status {
+	case "published":
+		switch {
+		case changed:
+			return decision{Analyze: true, Stale: true, Reason: "输入已变化，生成新草稿，已发布版本标记 stale"}
+		case expired:
+			return decision{Analyze: true, Stale: true, Reason: "超过最大有效期，生成新草稿，已发布版本标记 stale"}
+		case a.InputHash == "":
+			return decision{Reason: "已发布（旧版分析无输入哈希，保持不变）"}
+		}
+		return decision{Reason: "已发布且输入未变化"}
+
+	case "rejected":
+		if changed {
+			return decision{Analyze: true, Reason: "已拒绝，但输入已变化"}
+		}
+		return decision{Reason: "已拒绝且输入未变化"}
+	}
+
+	// draft
+	switch {
+	case a.InputHash == "":
+		return decision{Analyze: true, Reason: "旧版草稿缺少输入哈希"}
+	case changed:
+		return decision{Analyze: true, Reason: "输入已变化"}
+	case expired:
+		return decision{Analyze: true, Reason: "草稿超过最大有效期"}
+	}
+	return decision{Reason: "草稿输入未变化"}
+}
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func TestInputHash(t *testing.T) {
+	desc := "An AI tool"
+	p := &datastore.Project{Description: &desc, Topics: []string{"llm", "AI"}}
+	base := inputHash(p, "# Title\n\nBody", "v2", "deepseek-chat")
+
+	// Cosmetic changes keep the hash
+	q := &datastore.Project{Description: &desc, Topics: []string{"ai", "llm"}}
+	if got := inputHash(q, "# Title\n\n\n  Body  ", "v2", "deepseek-chat"); got != base {
+		t.Error("whitespace / topic order should not change the hash")
+	}
+	withStack := &datastore.Project{Description: &desc, Topics: p.Topics, TechStack: &datastore.TechStack{Languages: []string{"Go"}}}
+	if inputHash(withStack, "# Title\n\nBody", "v2", "deepseek-chat") != base {
+		t.Error("the tech stack has its own hash and should not change the input hash")
+	}
+
+	for name, got := range map[string]string{
+		"readme":  inputHash(p, "# Title\n\nNew body", "v2", "deepseek-chat"),
+		"prompt":  inputHash(p, "# Title\n\nBody", "v3", "deepseek-chat"),
+		"model":   inputHash(p, "# Title\n\nBody", "v2", "qwen-plus"),
+		"topics":  inputHash(&datastore.Project{Description: &desc, Topics: []string{"llm"}}, "# Title\n\nBody", "v2", "deepseek-chat"),
+		"no desc": inputHash(&datastore.Project{Topics: p.Topics}, "# Title\n\nBody", "v2", "deepseek-chat"),
+	} {
+		if got == base {
+			t.Errorf("%s change should change the hash", name)
+		}
+	}
+}
+
+func TestTechStackHash(t *testing.T) {
+	goStack := func(at time.Time, deps ...string) *datastore.TechStack {
+		return &datastore.TechStack{Languages: []string{"Go"}, Dependencies: deps, DetectedAt: at}
+	}
+	if techStackHash(nil) != "" {
+		t.Error("no stack should hash to empty")
+	}
+	if techStackHash(goStack(timeNow())) != techStackHash(goStack(time.Time{})) {
+		t.Error("re-detecting the same tech stack should not change the hash")
+	}
+	if techStackHash(goStack(timeNow())) == techStackHash(goStack(timeNow(), "cobra")) {
+		t.Error("a dependency change should change the hash")
+	}
+}
+
+func TestDecide(t *testing.T) {
+	now := timeNow()
+	old := now.Add(-40 * 24 * time.Hour)
+	maxAge := 30 * 24 * time.Hour
+
+	an := func(status, hash string, at time.Time) *datastore.Project {
+		return &datastore.Project{Analysis: &datastore.Analysis{Status: status, InputHash: hash, GeneratedAt: at}}
+	}
+
+	tests := []struct {
+		name          string
+		p             *datastore.Project
+		analyze, stal bool
+	}{
+		{"no analysis", &datastore.Project{}, true, false},
+		{"fresh draft", an("draft", "h", now), false, false},
+		{"draft inputs changed", an("draft", "old", now), true, false},
+		{"draft expired", an("draft", "h", old), true, false},
+		{"legacy draft", an("draft", "", now), true, false},
+		{"published unchanged", an("published", "h", now), false, false},
+		{"published changed", an("published", "old", now), true, true},
+		{"published expired", an("published", "h", old), true, true},
+		{"legacy published", an("published", "", now), false, false},
+		{"rejected unchanged", an("rejected", "h", now), false, false},
+		{"rejected changed", an("rejected", "old", now), true, false},
+		{"pending draft current", &datastore.Project{
+			Analysis: &datastore.Analysis{Status: "published", InputHash: "old", GeneratedAt: now},
+			Draft:    &datastore.Analysis{Status: "draft", InputHash: "h", GeneratedAt: now},
+		}, false, false},
+		{"published without stack, stack detected since", &datastore.Project{
+			TechStack: &datastore.TechStack{Languages: []string{"Go"}},
+			Analysis:  &datastore.Analysis{Status: "published", InputHash: "h", GeneratedAt: now},
+		}, false, false},
+		{"published stack unchanged", &datastore.Project{
+			TechStack: &datastore.TechStack{Languages: []string{"Go"}},
+			Analysis: &datastore.Analysis{Status: "published", InputHash: "h", GeneratedAt: now,
+				TechStackHash: techStackHash(&datastore.TechStack{Languages: []string{"Go"}})},
+		}, false, false},
+		{"published stack changed", &datastore.Project{
+			TechStack: &datastore.TechStack{Languages: []string{"Go"}, Dependencies: []string{"cobra"}},
+			Analysis: &datastore.Analysis{Status: "published", InputHash: "h", GeneratedAt: now,
+				TechStackHash: techStackHash(&datastore.TechStack{Languages: []string{"Go"}})},
+		}, true, true},
+		{"pending draft outdated", &datastore.Project{
+			Analysis: &datastore.Analysis{Status: "published", InputHash: "old", GeneratedAt: now},
+			Draft:    &datastore.Analysis{Status: "draft", InputHash: "older", GeneratedAt: now},
+		}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := decide(tt.p, datastore.DefaultLocale, "h", maxAge, now)
+			if d.Analyze != tt.analyze || d.Stale != tt.stal {
+				t.Errorf("decide = %+v, want analyze=%v stale=%v", d, tt.analyze, tt.stal)
+			}
+			if d.Reason == "" {
+				t.Error("decision must carry a reason")
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Package llmtest provides a local OpenAI-compatible server for tests and
+// for recording llm fixtures without a real provider.
+//
+// The server answers POST .../chat/completions with text from a reply
+// function and POST .../embeddings with deterministic vectors. Any other
+// path is looked up in GitHub routes registered with SetGitHub, so the
+// same server can stand in for api.github.com; unknown paths get 404.
+package llmtest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ReplyFunc returns the assistant message for a chat request. call is the
+// 0-based number of chat requests received so far.
+type ReplyFunc func(req openai.ChatCompletionRequest, call int) string
+
+// Server is a fake OpenAI-compatible and GitHub API endpoint.
+type Server struct {
+	URL string
+
+	srv   *httptest.Server
+	reply ReplyFunc
+
+	mu       sync.Mutex
+	requests []openai.ChatCompletionRequest
+	github   map[string]string // path -> JSON body
+}
+
+// NewServer starts a server that is closed when the test ends.
+func NewServer(t testing.TB, reply ReplyFunc) *Server {
+	t.Helper()
+	s := &Server{reply: reply, github: make(map[string]string)}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = s.srv.URL
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+// Close stops the server, e.g. to prove a replayed run makes no requests.
+func (s *Server) Close() { s.srv.Close() }
+
+// SetGitHub serves body as JSON for GET path, e.g. "/repos/o/r/readme".
+func (s *Server) SetGitHub(path, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.github[path] = body
+}
+
+// Requests returns the chat requests received so far.
+func (s *Server) Requests() []openai.ChatCompletionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]openai.ChatCompletionRequest(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/chat/completions"):
+		s.chat(w, r)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/embeddings"):
+		s.embeddings(w, r)
+	default:
+		s.mu.Lock()
+		body, ok := s.github[r.URL.Path]
+		s.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func (s *Server) chat(w http.ResponseWriter, r *http.Request) {
+	var req openai.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	call := len(s.requests)
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	content := s.reply(req, call)
+	prompt := 0
+	for _, m := range req.Messages {
+		prompt += len(m.Content) / 4
+	}
+	completion := len(content) / 4
+
+	writeJSON(w, openai.ChatCompletionResponse{
+		ID:     "chatcmpl-llmtest",
+		Object: "chat.completion",
+		Model:  req.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+			FinishReason: openai.FinishReasonStop,
+		}},
+		Usage: openai.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion},
+	})
+}
+
+func (s *Server) embeddings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Input []string `json:"input"`
+		Model string   `json:"model"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data := make([]openai.Embedding, len(req.Input))
+	for i, in := range req.Input {
+		data[i] = openai.Embedding{Object: "embedding", Embedding: Vector(in), Index: i}
+	}
+	writeJSON(w, openai.EmbeddingResponse{Object: "list", Data: data, Model: openai.EmbeddingModel(req.Model)})
+}
+
+// Vector is the deterministic 8-dimensional embedding served for text.
+func Vector(text string) []float32 {
+	sum := sha256.Sum256([]byte(text))
+	v := make([]float32, 8)
+	for i := range v {
+		v[i] = float32(sum[i]) / 255
+	}
+	return v
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,277 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// Narrative length limits, in characters.
+const (
+	minNarrativeIntro = 300
+	maxNarrativeIntro = 500
+	maxNarrativeTake  = 120
+)
+
+// narrativePromptVersion is recorded as the prompt version of narrative
+// calls. Bump it when narrativeSystemPrompt changes.
+const narrativePromptVersion = "v1"
+
+const narrativeSystemPrompt = "你是 AI 开源项目%s的编辑。根据给出的本期数据撰写中文编辑导语：\n" +
+	"1. intro：300–500 字的导语，解读本期趋势——哪些项目增长最快、哪些分类占比在上升或下降、新项目有什么共同点，以及这些变化对开发者意味着什么。\n" +
+	"2. highlights：为每个重点项目写一句 30–80 字的点评，按给出的顺序，project 与数据中的 owner/repo 完全一致。\n\n" +
+	"语气专业、克制，只依据给出的数据，不要编造数字、项目或发布信息。\n" +
+	"输出 JSON：{\"intro\": \"导语\", \"highlights\": [{\"project\": \"owner/repo\", \"take\": \"点评\"}]}"
+
+// NarrativeInput is the computed data of a weekly or monthly report that
+// its editorial narrative is written from.
+type NarrativeInput struct {
+	Kind          string // weekly | monthly
+	Period        string // e.g. 2026-W41 or 2026-10
+	StartDate     string
+	EndDate       string
+	TotalProjects int
+	TopGainers    []NarrativeProject
+	Categories    []NarrativeCategory
+	Newcomers     []NarrativeProject
+	Feedback      string // rejection reason of the previous narrative, if any
+}
+
+// NarrativeProject is a project the narrative may comment on.
+type NarrativeProject struct {
+	FullName string
+	Category string
+	Summary  string
+	Stars    int
+	StarGain int
+	Rank     int // on the period's last ranking; 0 = unranked
+}
+
+// NarrativeCategory is a category's share of the ranking, in percent, at
+// the start and end of the period.
+type NarrativeCategory struct {
+	Category string
+	Before   float64
+	After    float64
+}
+
+// highlights lists the projects that get a take: top gainers first, then
+// newcomers, each once.
+func (in *NarrativeInput) highlights() []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, list := range [][]NarrativeProject{in.TopGainers, in.Newcomers} {
+		for _, p := range list {
+			if !seen[p.FullName] {
+				seen[p.FullName] = true
+				out = append(out, p.FullName)
+			}
+		}
+	}
+	return out
+}
+
+// Narrator writes the editorial narratives of weekly and monthly reports.
+type Narrator struct {
+	client *Client
+	log    *zap.Logger
+}
+
+// NewNarrator creates a Narrator on the llm.* provider and its fallbacks.
+// Calls are recorded in the usage ledger under the generate command.
+func NewNarrator(store *datastore.Store, llmCfg config.LLMConfig, log *zap.Logger, opts ...Option) (*Narrator, error) {
+	o := buildOptions(opts)
+	client, err := NewClient(llmCfg, log, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating LLM client: %w", err)
+	}
+	if !o.offline() {
+		client.SetUsageHook(NewUsageLedger(store, "generate", NewPriceTable(llmCfg.Prices), log.Named("usage")).Record)
+	}
+	return &Narrator{client: client, log: log.Named("narrative")}, nil
+}
+
+// Narrate asks the model for a draft narrative of in, with the same
+// retries and fallbacks as project analyses. Length problems and takes on
+// projects outside the highlights are recorded in Narrative.Validation
+// for the reviewer.
+func (n *Narrator) Narrate(ctx context.Context, in *NarrativeInput) (*datastore.Narrative, error) {
+	prompt := narrativePrompt(in)
+	var narrative *datastore.Narrative
+	err := n.client.failover(ctx, in.Period, func(b *Client) error {
+		return b.withRetry(ctx, in.Period, func(ctx context.Context) error {
+			var err error
+			narrative, err = b.narrateOnce(ctx, in, prompt)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n.log.Info("编辑导语生成完成",
+		zap.String("period", in.Period),
+		zap.String("model", narrative.Model),
+		zap.Int("intro_len", utf8.RuneCountInString(narrative.Intro)),
+		zap.Int("highlights", len(narrative.Highlights)),
+	)
+	return narrative, nil
+}
+
+// narrateOnce performs a single narrative completion.
+func (c *Client) narrateOnce(ctx context.Context, in *NarrativeInput, prompt *Prompt) (*datastore.Narrative, error) {
+	c.log.Debug("调用 LLM API",
+		zap.String("period", in.Period),
+		zap.String("model", c.model),
+		zap.String("prompt", prompt.Name+"/"+prompt.Version),
+		zap.Int("prompt_len", len(prompt.User)),
+	)
+
+	resp, err := c.complete(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	c.reportUsage("", resp.Usage)
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("%w: empty choices", errMalformedOutput)
+	}
+	content := extractJSON(resp.Choices[0].Message.Content)
+	var raw struct {
+		Intro      string                         `json:"intro"`
+		Highlights []datastore.NarrativeHighlight `json:"highlights"`
+	}
+	if err := json.Unmarshal([]byte(content), &raw); err != nil {
+		return nil, fmt.Errorf("%w: parsing JSON: %v (content: %.500s)", errMalformedOutput, err, content)
+	}
+	if strings.TrimSpace(raw.Intro) == "" {
+		return nil, fmt.Errorf("%w: empty intro (content: %.500s)", errMalformedOutput, content)
+	}
+
+	narrative := &datastore.Narrative{
+		Status:         "draft",
+		Model:          c.model,
+		Intro:          strings.TrimSpace(raw.Intro),
+		GeneratedAt:    c.clock(),
+		TokenUsage:     &resp.Usage.TotalTokens,
+		ReviewFeedback: in.Feedback,
+	}
+	issues := checkNarrative(in, narrative, raw.Highlights)
+	if len(issues) > 0 {
+		narrative.Validation = &datastore.Validation{Issues: issues}
+	}
+	return narrative, nil
+}
+
+// checkNarrative keeps the takes on in's highlights, in highlight order,
+// and reports length problems, dropped takes and missing ones. None of
+// them are errors: the reviewer decides.
+func checkNarrative(in *NarrativeInput, n *datastore.Narrative, takes []datastore.NarrativeHighlight) []datastore.ValidationIssue {
+	var issues []datastore.ValidationIssue
+	warn := func(field, format string, args ...any) {
+		issues = append(issues, datastore.ValidationIssue{Field: field, Level: levelWarning, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if l := utf8.RuneCountInString(n.Intro); l < minNarrativeIntro || l > maxNarrativeIntro {
+		warn("intro", "长度 %d 字，应为 %d–%d 字", l, minNarrativeIntro, maxNarrativeIntro)
+	}
+
+	highlights := in.highlights()
+	wanted := make(map[string]bool, len(highlights))
+	for _, name := range highlights {
+		wanted[name] = true
+	}
+	byProject := make(map[string]string)
+	for i, h := range takes {
+		take := strings.TrimSpace(h.Take)
+		switch {
+		case take == "":
+			warn(fmt.Sprintf("highlights[%d].take", i), "%s 的点评为空，已删除", h.Project)
+		case !wanted[h.Project]:
+			warn(fmt.Sprintf("highlights[%d].project", i), "%s 不是本期重点项目，已删除", h.Project)
+		default:
+			byProject[h.Project] = take
+		}
+	}
+	for _, name := range highlights {
+		take, ok := byProject[name]
+		if !ok {
+			warn("highlights", "缺少 %s 的点评", name)
+			continue
+		}
+		if l := utf8.RuneCountInString(take); l > maxNarrativeTake {
+			warn("highlights", "%s 的点评长度 %d 字，超过 %d 字", name, l, maxNarrativeTake)
+		}
+		n.Highlights = append(n.Highlights, datastore.NarrativeHighlight{Project: name, Take: take})
+	}
+	return issues
+}
+
+// narrativePrompt renders in as the narrative prompt.
+func narrativePrompt(in *NarrativeInput) *Prompt {
+	kind := "周报"
+	if in.Kind == "monthly" {
+		kind = "月报"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s（%s ~ %s），期末榜单共 %d 个项目。\n", kind, in.Period, in.StartDate, in.EndDate, in.TotalProjects)
+	if len(in.TopGainers) > 0 {
+		b.WriteString("\nStar 增长最多的项目：\n")
+		for i, p := range in.TopGainers {
+			fmt.Fprintf(&b, "%d. %s（%s）+%d Star，总 %d%s\n", i+1, p.FullName, narrativeLabel(p), p.StarGain, p.Stars, narrativeSummary(p))
+		}
+	}
+	if len(in.Categories) > 0 {
+		b.WriteString("\n分类占榜单比例变化：\n")
+		for _, c := range in.Categories {
+			fmt.Fprintf(&b, "- %s：%.1f%% → %.1f%%（%+.1f 个百分点）\n", c.Category, c.Before, c.After, c.After-c.Before)
+		}
+	}
+	if len(in.Newcomers) > 0 {
+		b.WriteString("\n新入榜项目：\n")
+		for _, p := range in.Newcomers {
+			fmt.Fprintf(&b, "- %s（%s）%d Star%s\n", p.FullName, narrativeLabel(p), p.Stars, narrativeSummary(p))
+		}
+	}
+	fmt.Fprintf(&b, "\n重点项目（每个写一句点评）：%s\n", strings.Join(in.highlights(), "、"))
+	if in.Feedback != "" {
+		b.WriteString("\n上一版编辑导语被人工审核拒绝，原因如下：\n" + in.Feedback + "\n请在本次导语中针对该意见改进。\n")
+	}
+
+	return &Prompt{
+		Name:    "narrative",
+		Locale:  datastore.DefaultLocale,
+		Version: narrativePromptVersion,
+		System:  fmt.Sprintf(narrativeSystemPrompt, kind),
+		User:    b.String(),
+	}
+}
+
+func narrativeLabel(p NarrativeProject) string {
+	var parts []string
+	if p.Category != "" {
+		parts = append(parts, p.Category)
+	}
+	if p.Rank > 0 {
+		parts = append(parts, fmt.Sprintf("排名 %d", p.Rank))
+	}
+	if len(parts) == 0 {
+		return "未分类"
+	}
+	return strings.Join(parts, "，")
+}
+
+func narrativeSummary(p NarrativeProject) string {
+	if p.Summary == "" {
+		return ""
+	}
+	return "：" + p.Summary
+}
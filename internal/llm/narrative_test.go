@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/llm/llmtest"
+)
+
+func TestNarrate(t *testing.T) {
+	intro := strings.Repeat("趋势", 160)
+	srv := llmtest.NewServer(t, func(_ openai.ChatCompletionRequest, call int) string {
+		if call == 0 {
+			return "not json"
+		}
+		return `{"intro": "` + intro + `", "highlights": [
+			{"project": "o/b", "take": "增长最快"},
+			{"project": "x/y", "take": "不在数据里"},
+			{"project": "o/new", "take": " "}
+		]}`
+	})
+
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	n, err := NewNarrator(store, config.LLMConfig{Provider: "ollama", BaseURL: srv.URL, RetryMax: 1}, testLogger())
+	if err != nil {
+		t.Fatalf("NewNarrator: %v", err)
+	}
+	var delays []time.Duration
+	n.client.sleep = noSleep(&delays)
+
+	in := &NarrativeInput{
+		Kind: "weekly", Period: "2026-W41", StartDate: "2026-10-05", EndDate: "2026-10-11", TotalProjects: 3,
+		TopGainers: []NarrativeProject{{FullName: "o/b", Category: "llm", Summary: "LLM 框架", Stars: 800, StarGain: 300, Rank: 1}},
+		Categories: []NarrativeCategory{{Category: "agent", Before: 0, After: 33.3}},
+		Newcomers:  []NarrativeProject{{FullName: "o/new", Stars: 120, Rank: 3}},
+		Feedback:   "太空泛",
+	}
+	got, err := n.Narrate(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Narrate: %v", err)
+	}
+	if len(delays) != 1 {
+		t.Errorf("delays = %v, want one retry of the malformed reply", delays)
+	}
+	if got.Status != "draft" || got.Intro != intro || got.ReviewFeedback != "太空泛" || got.TokenUsage == nil {
+		t.Errorf("narrative = %+v", got)
+	}
+	if len(got.Highlights) != 1 || got.Highlights[0] != (datastore.NarrativeHighlight{Project: "o/b", Take: "增长最快"}) {
+		t.Errorf("highlights = %+v", got.Highlights)
+	}
+	if got.Validation == nil || len(got.Validation.Issues) != 3 {
+		t.Fatalf("validation = %+v, want the unknown, empty and missing takes", got.Validation)
+	}
+
+	user := srv.Requests()[1].Messages[1].Content
+	for _, want := range []string{
+		"周报 2026-W41（2026-10-05 ~ 2026-10-11）",
+		"1. o/b（llm，排名 1）+300 Star，总 800：LLM 框架",
+		"- agent：0.0% → 33.3%（+33.3 个百分点）",
+		"- o/new（排名 3）120 Star",
+		"重点项目（每个写一句点评）：o/b、o/new",
+		"太空泛",
+	} {
+		if !strings.Contains(user, want) {
+			t.Errorf("prompt missing %q:\n%s", want, user)
+		}
+	}
+
+	records, err := store.LoadUsage(time.Now().UTC().Format("2006-01"))
+	if err != nil || len(records) != 2 || records[0].Command != "generate" {
+		t.Errorf("usage = %+v, %v", records, err)
+	}
+}
+
+func TestCheckNarrative_IntroLength(t *testing.T) {
+	in := &NarrativeInput{}
+	n := &datastore.Narrative{Intro: "太短"}
+	issues := checkNarrative(in, n, nil)
+	if len(issues) != 1 || issues[0].Field != "intro" || issues[0].Level != levelWarning {
+		t.Errorf("issues = %+v", issues)
+	}
+}
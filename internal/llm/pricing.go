@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/zbb88888/tishi/internal/config"
+)
+
+// Usage is the token consumption of a single chat completion or
+// embeddings call.
+type Usage struct {
+	Project          string // project full_name, empty for non-project calls
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// defaultPrices are list prices in CNY per million tokens. Entries in
+// llm.prices override or extend them.
+var defaultPrices = []config.LLMPrice{
+	{Model: "deepseek-chat", Input: 2, Output: 8},
+	{Model: "deepseek-reasoner", Input: 4, Output: 16},
+	{Model: "qwen-turbo", Input: 0.3, Output: 0.6},
+	{Model: "qwen-plus", Input: 0.8, Output: 2},
+	{Model: "qwen-max", Input: 2.4, Output: 9.6},
+	{Model: "text-embedding-v3", Input: 0.5},
+}
+
+// PriceTable maps lowercased model names to per-million-token prices.
+type PriceTable map[string]config.LLMPrice
+
+// NewPriceTable merges the built-in prices with configured overrides.
+func NewPriceTable(overrides []config.LLMPrice) PriceTable {
+	t := make(PriceTable, len(defaultPrices)+len(overrides))
+	for _, p := range defaultPrices {
+		t[strings.ToLower(p.Model)] = p
+	}
+	for _, p := range overrides {
+		t[strings.ToLower(p.Model)] = p
+	}
+	return t
+}
+
+// Cost returns the estimated cost in CNY and whether the model is priced.
+// Unpriced models (e.g. local Ollama) cost 0.
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) (float64, bool) {
+	p, ok := t[strings.ToLower(model)]
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*p.Input + float64(completionTokens)*p.Output) / 1e6, true
+}
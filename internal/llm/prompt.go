@@ -0,0 +1,355 @@
+package llm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// Analysis prompts are text/template files under {prompts_dir}/analysis/:
+//
+//	analysis/default/v3.tmpl    used when no variant matches
+//	analysis/agent/v1.tmpl      used for projects in the "agent" category
+//	analysis/default/en/v1.tmpl English output; other locales alike
+//
+// Each file defines a "system" and a "user" template. The directory name is
+// the prompt name and the file name its version; the highest version of
+// each variant wins. Files directly in the variant directory produce
+// Chinese (datastore.DefaultLocale); a locale subdirectory holds that
+// locale's prompts. Bump the version when the wording changes so existing
+// analyses are refreshed.
+
+// defaultPromptVariant is used when a project's category has no variant.
+const defaultPromptVariant = "default"
+
+// PromptTemplate is one loaded prompt variant.
+type PromptTemplate struct {
+	Name    string // variant, e.g. default or agent
+	Locale  string // output locale, e.g. zh or en
+	Version string // e.g. v3
+	tmpl    *template.Template
+}
+
+// ID returns "name/version", or "name/locale/version" outside the default
+// locale, as folded into the input hash.
+func (t *PromptTemplate) ID() string {
+	if t.Locale == datastore.DefaultLocale {
+		return t.Name + "/" + t.Version
+	}
+	return t.Name + "/" + t.Locale + "/" + t.Version
+}
+
+// Prompt is a rendered prompt ready to send.
+type Prompt struct {
+	Name    string
+	Locale  string
+	Version string
+	System  string
+	User    string
+}
+
+// PromptData is the data available to prompt templates.
+type PromptData struct {
+	Project    *datastore.Project
+	README     string
+	Category   *datastore.Category // primary category, nil if unclassified
+	Categories []datastore.Category
+	Activity   Activity
+}
+
+// Activity summarizes a project's recent activity for prompts.
+type Activity struct {
+	DailyStars    int
+	WeeklyStars   int
+	DaysSincePush int // -1 when unknown
+	AgeDays       int // days since GitHub creation, -1 when unknown
+	Archived      bool
+}
+
+func newActivity(p *datastore.Project, now time.Time) Activity {
+	a := Activity{DaysSincePush: -1, AgeDays: -1, Archived: p.IsArchived}
+	if t := p.Trending; t != nil {
+		a.DailyStars = derefInt(t.DailyStars)
+		a.WeeklyStars = derefInt(t.WeeklyStars)
+	}
+	if p.PushedAt != nil {
+		a.DaysSincePush = int(now.Sub(*p.PushedAt).Hours() / 24)
+	}
+	if p.CreatedAtGH != nil {
+		a.AgeDays = int(now.Sub(*p.CreatedAtGH).Hours() / 24)
+	}
+	return a
+}
+
+// Render executes the template's system and user parts.
+func (t *PromptTemplate) Render(data PromptData) (*Prompt, error) {
+	var sys, user bytes.Buffer
+	if err := t.tmpl.ExecuteTemplate(&sys, "system", data); err != nil {
+		return nil, fmt.Errorf("rendering prompt %s system: %w", t.ID(), err)
+	}
+	if err := t.tmpl.ExecuteTemplate(&user, "user", data); err != nil {
+		return nil, fmt.Errorf("rendering prompt %s user: %w", t.ID(), err)
+	}
+	return &Prompt{
+		Name:    t.Name,
+		Locale:  t.Locale,
+		Version: t.Version,
+		System:  strings.TrimSpace(sys.String()),
+		User:    strings.TrimSpace(user.String()),
+	}, nil
+}
+
+// PromptSet holds the latest version of each prompt variant per locale.
+type PromptSet struct {
+	variants map[string]map[string]*PromptTemplate // locale -> variant -> template
+	aliases  map[string]string                     // category slug -> variant
+}
+
+// LoadPrompts reads {dir}/analysis/*/v*.tmpl and {dir}/analysis/*/{locale}/v*.tmpl.
+// The built-in prompt serves as the Chinese default variant when the
+// directory has none, and is the only prompt when dir is empty; a dir
+// without an analysis/ subdirectory is a configuration error. aliases
+// maps category slugs to variant names (llm.prompt_variants); they must
+// exist in Chinese.
+func LoadPrompts(dir string, aliases map[string]string) (*PromptSet, error) {
+	set := builtinPrompts()
+	for k, v := range aliases {
+		set.aliases[strings.ToLower(k)] = v
+	}
+	if dir != "" {
+		if err := set.loadDir(filepath.Join(dir, "analysis")); err != nil {
+			return nil, err
+		}
+	}
+
+	for slug, name := range set.aliases {
+		if _, ok := set.variants[datastore.DefaultLocale][name]; !ok {
+			return nil, fmt.Errorf("llm.prompt_variants: %s 指向不存在的 prompt %q", slug, name)
+		}
+	}
+	return set, nil
+}
+
+// loadDir adds the latest version of every variant and locale under root.
+func (s *PromptSet) loadDir(root string) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("llm.prompts_dir: %s 不存在（留空则只使用内置 prompt）", root)
+		}
+		return fmt.Errorf("reading prompts dir: %w", err)
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		t, err := loadLatestVersion(dir, e.Name(), datastore.DefaultLocale)
+		if err != nil {
+			return err
+		}
+		s.add(t)
+
+		subs, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("reading prompt dir %s: %w", dir, err)
+		}
+		for _, sub := range subs {
+			if !sub.IsDir() {
+				continue
+			}
+			locale := datastore.NormalizeLocale(sub.Name())
+			t, err := loadLatestVersion(filepath.Join(dir, sub.Name()), e.Name(), locale)
+			if err != nil {
+				return err
+			}
+			s.add(t)
+		}
+	}
+	return nil
+}
+
+// add registers t, replacing the same variant and locale; nil is ignored.
+func (s *PromptSet) add(t *PromptTemplate) {
+	if t == nil {
+		return
+	}
+	if s.variants[t.Locale] == nil {
+		s.variants[t.Locale] = make(map[string]*PromptTemplate)
+	}
+	s.variants[t.Locale][t.Name] = t
+}
+
+// loadLatestVersion parses the highest vN.tmpl in a variant (or variant
+// locale) directory.
+func loadLatestVersion(dir, name, locale string) (*PromptTemplate, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading prompt dir %s: %w", dir, err)
+	}
+
+	best, bestN := "", -1
+	for _, e := range entries {
+		v, ok := strings.CutSuffix(e.Name(), ".tmpl")
+		if e.IsDir() || !ok {
+			continue
+		}
+		if n := versionNumber(v); n > bestN {
+			best, bestN = v, n
+		}
+	}
+	if bestN < 0 {
+		return nil, nil
+	}
+
+	path := filepath.Join(dir, best+".tmpl")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading prompt %s: %w", path, err)
+	}
+	return parsePrompt(name, locale, best, string(data))
+}
+
+// versionNumber returns N for "vN", or -1.
+func versionNumber(v string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(v, "v"))
+	if err != nil || !strings.HasPrefix(v, "v") || n < 0 {
+		return -1
+	}
+	return n
+}
+
+func parsePrompt(name, locale, version, text string) (*PromptTemplate, error) {
+	t := &PromptTemplate{Name: name, Locale: locale, Version: version}
+	tmpl, err := template.New(name).Funcs(promptFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing prompt %s: %w", t.ID(), err)
+	}
+	for _, part := range []string{"system", "user"} {
+		if tmpl.Lookup(part) == nil {
+			return nil, fmt.Errorf("prompt %s: 缺少 {{define %q}}", t.ID(), part)
+		}
+	}
+	t.tmpl = tmpl
+	return t, nil
+}
+
+// For picks the prompt variant for a project in locale: an
+// llm.prompt_variants mapping for its category, a variant named after the
+// category, or default. It returns nil when locale has no default prompt.
+func (s *PromptSet) For(p *datastore.Project, locale string) *PromptTemplate {
+	variants := s.variants[datastore.NormalizeLocale(locale)]
+	if p.Category != nil {
+		slug := strings.ToLower(*p.Category)
+		if name, ok := s.aliases[slug]; ok {
+			if t, ok := variants[name]; ok {
+				return t
+			}
+		} else if t, ok := variants[slug]; ok {
+			return t
+		}
+	}
+	return variants[defaultPromptVariant]
+}
+
+// Locales returns the locales that have a default prompt, sorted.
+func (s *PromptSet) Locales() []string {
+	var out []string
+	for locale, variants := range s.variants {
+		if variants[defaultPromptVariant] != nil {
+			out = append(out, locale)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Names returns the loaded variants as IDs (see PromptTemplate.ID), sorted.
+func (s *PromptSet) Names() []string {
+	var out []string
+	for _, variants := range s.variants {
+		for _, t := range variants {
+			out = append(out, t.ID())
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+var promptFuncs = template.FuncMap{
+	"deref": func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	},
+	"derefInt": derefInt,
+	"join":     strings.Join,
+}
+
+func derefInt(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// builtinPrompts returns a set containing only the built-in default prompt.
+func builtinPrompts() *PromptSet {
+	t, err := parsePrompt(defaultPromptVariant, datastore.DefaultLocale, "v2", builtinPrompt)
+	if err != nil {
+		panic(err)
+	}
+	return &PromptSet{
+		variants: map[string]map[string]*PromptTemplate{
+			datastore.DefaultLocale: {defaultPromptVariant: t},
+		},
+		aliases: map[string]string{},
+	}
+}
+
+// builtinPrompt is used when no prompts directory is deployed.
+const builtinPrompt = `{{define "system"}}
+你是一个专业的 AI 技术分析师，专门为中文开发者社区撰写开源项目深度分析报告。
+你的报告需要：
+1. 使用中文撰写，专业术语保留英文原文
+2. 客观准确，基于项目实际功能和代码
+3. 面向有一定技术背景的中文开发者
+4. 简洁有力，避免空洞的营销话术
+
+请严格按照 JSON 格式输出分析结果，不要输出其他内容。
+{{end}}
+
+{{define "user"}}
+请分析以下 GitHub 开源项目：
+
+项目名称: {{.Project.FullName}}
+描述: {{deref .Project.Description}}
+编程语言: {{deref .Project.Language}}
+Star 数: {{.Project.Stars}}
+Topics: {{join .Project.Topics ", "}}
+
+README 内容（节选）:
+{{.README}}
+
+请输出以下 JSON 格式的分析结果：
+{
+  "summary": "一句话中文概括（50字以内）",
+  "positioning": "项目定位：解决什么问题，面向谁（200字以内）",
+  "features": [{"name": "功能名", "desc": "功能描述"}],
+  "advantages": "相比同类项目的优势（200字以内）",
+  "tech_stack": "使用的核心技术栈",
+  "use_cases": "适用场景（200字以内）",
+  "comparison": [{"project": "竞品名", "diff": "差异点"}],
+  "ecosystem": "上下游生态（200字以内）"
+}
+{{end}}`
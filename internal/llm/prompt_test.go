@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func writePrompt(t *testing.T, dir, variant, version, text string) {
+	t.Helper()
+	d := filepath.Join(dir, "analysis", variant)
+	if err := os.MkdirAll(d, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(d, version+".tmpl"), []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPrompts_LatestVersionAndVariants(t *testing.T) {
+	dir := t.TempDir()
+	body := func(s string) string {
+		return `{{define "system"}}sys ` + s + `{{end}}{{define "user"}}` + s + ` {{.Project.FullName}}{{end}}`
+	}
+	writePrompt(t, dir, "default", "v3", body("d3"))
+	writePrompt(t, dir, "default", "v10", body("d10"))
+	writePrompt(t, dir, "agent", "v1", body("a1"))
+
+	set, err := LoadPrompts(dir, map[string]string{"rag": "agent"})
+	if err != nil {
+		t.Fatalf("LoadPrompts: %v", err)
+	}
+
+	cat := func(s string) *datastore.Project { return &datastore.Project{FullName: "o/r", Category: &s} }
+	tests := []struct {
+		p    *datastore.Project
+		want string
+	}{
+		{&datastore.Project{FullName: "o/r"}, "default/v10"},
+		{cat("agent"), "agent/v1"},
+		{cat("rag"), "agent/v1"},
+		{cat("speech"), "default/v10"},
+	}
+	for _, tt := range tests {
+		if got := set.For(tt.p, datastore.DefaultLocale).ID(); got != tt.want {
+			t.Errorf("For(%v) = %s, want %s", tt.p.Category, got, tt.want)
+		}
+	}
+
+	prompt, err := set.For(cat("agent"), datastore.DefaultLocale).Render(PromptData{Project: cat("agent")})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if prompt.System != "sys a1" || prompt.User != "a1 o/r" {
+		t.Errorf("prompt = %+v", prompt)
+	}
+}
+
+func TestLoadPrompts_Locales(t *testing.T) {
+	dir := t.TempDir()
+	body := func(s string) string {
+		return `{{define "system"}}` + s + `{{end}}{{define "user"}}{{.Project.FullName}}{{end}}`
+	}
+	writePrompt(t, dir, "default", "v3", body("zh"))
+	writePrompt(t, dir, "agent", "v1", body("zh agent"))
+	writePrompt(t, dir, "default/en", "v1", body("en"))
+	writePrompt(t, dir, "default/en", "v2", body("en2"))
+
+	set, err := LoadPrompts(dir, nil)
+	if err != nil {
+		t.Fatalf("LoadPrompts: %v", err)
+	}
+	if got := set.Locales(); len(got) != 2 || got[0] != "en" || got[1] != "zh" {
+		t.Errorf("Locales = %v, want [en zh]", got)
+	}
+
+	agent := "agent"
+	p := &datastore.Project{FullName: "o/r", Category: &agent}
+	if got := set.For(p, datastore.DefaultLocale).ID(); got != "agent/v1" {
+		t.Errorf("zh = %s, want agent/v1", got)
+	}
+	// No en agent prompt: falls back to the en default.
+	en := set.For(p, "EN")
+	if en == nil || en.ID() != "default/en/v2" {
+		t.Fatalf("en = %v, want default/en/v2", en)
+	}
+	prompt, err := en.Render(PromptData{Project: p})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if prompt.Locale != "en" || prompt.System != "en2" {
+		t.Errorf("prompt = %+v", prompt)
+	}
+	if set.For(p, "fr") != nil {
+		t.Error("locale without prompts should return nil")
+	}
+}
+
+func TestLoadPrompts_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "default", "v1", `{{define "user"}}only user{{end}}`)
+	if _, err := LoadPrompts(dir, nil); err == nil || !strings.Contains(err.Error(), "system") {
+		t.Errorf("missing system template: err = %v", err)
+	}
+
+	if _, err := LoadPrompts("", map[string]string{"agent": "nope"}); err == nil {
+		t.Error("alias to unknown variant should fail")
+	}
+}
+
+func TestLoadPrompts_MissingDir(t *testing.T) {
+	if _, err := LoadPrompts(filepath.Join(t.TempDir(), "absent"), nil); err == nil || !strings.Contains(err.Error(), "llm.prompts_dir") {
+		t.Errorf("missing prompts dir: err = %v", err)
+	}
+
+	set, err := LoadPrompts("", nil)
+	if err != nil {
+		t.Fatalf("LoadPrompts: %v", err)
+	}
+	if got := set.For(&datastore.Project{}, datastore.DefaultLocale).ID(); got != "default/v2" {
+		t.Errorf("builtin = %s, want default/v2", got)
+	}
+}
+
+// TestShippedPrompts renders every template in the repo's prompts/ dir.
+func TestShippedPrompts(t *testing.T) {
+	set, err := LoadPrompts("../../prompts", nil)
+	if err != nil {
+		t.Fatalf("LoadPrompts: %v", err)
+	}
+
+	desc := "A framework"
+	pushed := time.Now().Add(-48 * time.Hour)
+	for _, slug := range []string{"agent", "vector-db", "other"} {
+		p := &datastore.Project{FullName: "o/r", Description: &desc, Category: &slug, PushedAt: &pushed,
+			TechStack: &datastore.TechStack{Languages: []string{"Go"}, Frameworks: []string{"Gin"}, Manifests: []string{"go.mod"}}}
+		cat := &datastore.Category{Slug: slug, Name: slug}
+		prompt, err := set.For(p, datastore.DefaultLocale).Render(PromptData{
+			Project:  p,
+			README:   "# readme",
+			Category: cat,
+			Activity: newActivity(p, time.Now()),
+		})
+		if err != nil {
+			t.Fatalf("%s: Render: %v", slug, err)
+		}
+		if !strings.Contains(prompt.User, "o/r") || !strings.Contains(prompt.User, `"summary"`) {
+			t.Errorf("%s: user prompt missing project or output schema:\n%s", slug, prompt.User)
+		}
+		if !strings.Contains(prompt.User, "框架与核心库: Gin") {
+			t.Errorf("%s: user prompt missing detected tech stack:\n%s", slug, prompt.User)
+		}
+
+		en, err := set.For(p, "en").Render(PromptData{Project: p, README: "# readme", Category: cat})
+		if err != nil {
+			t.Fatalf("%s: Render en: %v", slug, err)
+		}
+		if !strings.Contains(en.User, "o/r") || !strings.Contains(en.User, "Frameworks and core libraries: Gin") {
+			t.Errorf("%s: en user prompt:\n%s", slug, en.User)
+		}
+	}
+	if got := set.For(&datastore.Project{}, datastore.DefaultLocale).Version; got == "v2" {
+		t.Error("shipped default prompt should supersede the built-in one")
+	}
+}
@@ -0,0 +1,181 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zbb88888/tishi/internal/config"
+)
+
+// JSON output modes for chat completions.
+const (
+	JSONModeNative = "native" // send response_format={"type":"json_object"}
+	JSONModePrompt = "prompt" // rely on prompt instructions + lenient extraction
+)
+
+// Provider describes an OpenAI-compatible chat completion endpoint.
+type Provider struct {
+	Name         string
+	BaseURL      string
+	DefaultModel string
+	EmbedModel   string            // default embeddings model; empty = llm.embedding.model required
+	NoAPIKey     bool              // local servers (Ollama, vLLM) accept unauthenticated requests
+	JSONMode     string            // native or prompt
+	Headers      map[string]string // extra headers sent with every request
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]Provider)
+)
+
+func init() {
+	RegisterProvider(Provider{
+		Name:         "deepseek",
+		BaseURL:      "https://api.deepseek.com/v1",
+		DefaultModel: "deepseek-chat",
+		JSONMode:     JSONModeNative,
+	})
+	RegisterProvider(Provider{
+		Name:         "qwen",
+		BaseURL:      "https://dashscope.aliyuncs.com/compatible-mode/v1",
+		DefaultModel: "qwen-plus",
+		EmbedModel:   "text-embedding-v3",
+		JSONMode:     JSONModeNative,
+	})
+	RegisterProvider(Provider{
+		Name:         "openai",
+		BaseURL:      "https://api.openai.com/v1",
+		DefaultModel: "gpt-4o-mini",
+		EmbedModel:   "text-embedding-3-small",
+		JSONMode:     JSONModeNative,
+	})
+	RegisterProvider(Provider{
+		Name:         "ollama",
+		BaseURL:      "http://localhost:11434/v1",
+		DefaultModel: "qwen2.5:7b",
+		EmbedModel:   "nomic-embed-text",
+		NoAPIKey:     true,
+		JSONMode:     JSONModeNative,
+	})
+	// vLLM only honors response_format with guided decoding enabled,
+	// so default to prompt-only JSON.
+	RegisterProvider(Provider{
+		Name:     "vllm",
+		BaseURL:  "http://localhost:8000/v1",
+		NoAPIKey: true,
+		JSONMode: JSONModePrompt,
+	})
+}
+
+// RegisterProvider adds or replaces a provider in the registry.
+// Names are case-insensitive.
+func RegisterProvider(p Provider) {
+	p.Name = strings.ToLower(p.Name)
+	if p.JSONMode == "" {
+		p.JSONMode = JSONModeNative
+	}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[p.Name] = p
+}
+
+// LookupProvider returns the registered provider with the given name.
+func LookupProvider(name string) (Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[strings.ToLower(name)]
+	return p, ok
+}
+
+// ProviderNames returns all registered provider names, sorted.
+func ProviderNames() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for n := range providers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveProvider merges the registry entry, llm.providers config entries
+// and top-level overrides (base_url, headers, json_mode) into one Provider.
+// An unknown provider name is accepted as a generic OpenAI-compatible
+// endpoint when base_url is set.
+func resolveProvider(cfg config.LLMConfig) (Provider, error) {
+	name := strings.ToLower(cfg.Provider)
+
+	p, ok := LookupProvider(name)
+	if pc, found := cfg.Providers[name]; found {
+		if !ok {
+			p = Provider{Name: name, JSONMode: JSONModeNative}
+			ok = true
+		}
+		p = applyOverrides(p, pc.BaseURL, pc.Model, pc.JSONMode, pc.Headers)
+		if pc.NoAPIKey {
+			p.NoAPIKey = true
+		}
+	}
+	if !ok {
+		if cfg.BaseURL == "" {
+			return Provider{}, fmt.Errorf("unsupported LLM provider %q (use %s, or set llm.base_url for an OpenAI-compatible endpoint)",
+				name, strings.Join(ProviderNames(), ", "))
+		}
+		p = Provider{Name: name, JSONMode: JSONModeNative}
+	}
+
+	p = applyOverrides(p, cfg.BaseURL, "", cfg.JSONMode, cfg.Headers)
+
+	if p.BaseURL == "" {
+		return Provider{}, fmt.Errorf("LLM provider %q has no base_url", name)
+	}
+	switch p.JSONMode {
+	case JSONModeNative, JSONModePrompt:
+	default:
+		return Provider{}, fmt.Errorf("invalid llm.json_mode %q (use native or prompt)", p.JSONMode)
+	}
+	return p, nil
+}
+
+// applyOverrides copies non-empty fields onto p. Headers are merged.
+func applyOverrides(p Provider, baseURL, model, jsonMode string, headers map[string]string) Provider {
+	if baseURL != "" {
+		p.BaseURL = strings.TrimRight(baseURL, "/")
+	}
+	if model != "" {
+		p.DefaultModel = model
+	}
+	if jsonMode != "" {
+		p.JSONMode = strings.ToLower(jsonMode)
+	}
+	if len(headers) > 0 {
+		merged := make(map[string]string, len(p.Headers)+len(headers))
+		for k, v := range p.Headers {
+			merged[k] = v
+		}
+		for k, v := range headers {
+			merged[k] = v
+		}
+		p.Headers = merged
+	}
+	return p
+}
+
+// headerTransport injects static headers into every outgoing request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	return t.base.RoundTrip(req)
+}
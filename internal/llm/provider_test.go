@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func TestNewClient_OllamaNoAPIKey(t *testing.T) {
+	c, err := NewClient(config.LLMConfig{Provider: "ollama"}, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if c.provider.BaseURL != "http://localhost:11434/v1" {
+		t.Errorf("BaseURL = %q", c.provider.BaseURL)
+	}
+}
+
+func TestNewClient_CustomBaseURL(t *testing.T) {
+	cfg := config.LLMConfig{
+		Provider: "corp-gateway",
+		APIKey:   "k",
+		BaseURL:  "https://llm.corp.example/v1/",
+		Model:    "gpt-4o",
+	}
+	c, err := NewClient(cfg, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if c.provider.BaseURL != "https://llm.corp.example/v1" {
+		t.Errorf("BaseURL = %q, want trailing slash trimmed", c.provider.BaseURL)
+	}
+}
+
+func TestNewClient_CustomProviderNeedsModel(t *testing.T) {
+	cfg := config.LLMConfig{Provider: "corp", APIKey: "k", BaseURL: "http://x/v1"}
+	if _, err := NewClient(cfg, testLogger()); err == nil {
+		t.Fatal("expected error when custom provider has no model")
+	}
+}
+
+func TestNewClient_ConfigProvider(t *testing.T) {
+	cfg := config.LLMConfig{
+		Provider: "lab",
+		Providers: map[string]config.LLMProviderConfig{
+			"lab": {BaseURL: "http://gpu01:8000/v1", Model: "qwen2.5-72b", NoAPIKey: true, JSONMode: "prompt"},
+		},
+	}
+	c, err := NewClient(cfg, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if c.model != "qwen2.5-72b" {
+		t.Errorf("model = %q", c.model)
+	}
+	if !c.promptJSON.Load() {
+		t.Error("expected prompt JSON mode")
+	}
+}
+
+func TestNewClient_InvalidJSONMode(t *testing.T) {
+	cfg := config.LLMConfig{Provider: "deepseek", APIKey: "k", JSONMode: "xml"}
+	if _, err := NewClient(cfg, testLogger()); err == nil {
+		t.Fatal("expected error for invalid json_mode")
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	RegisterProvider(Provider{Name: "Test-Reg", BaseURL: "http://reg/v1", DefaultModel: "m", NoAPIKey: true})
+	c, err := NewClient(config.LLMConfig{Provider: "test-reg"}, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if c.model != "m" || c.provider.JSONMode != JSONModeNative {
+		t.Errorf("provider = %+v, model = %q", c.provider, c.model)
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`{"a":1}`, `{"a":1}`},
+		{"```json\n{\"a\":1}\n```", `{"a":1}`},
+		{"好的，结果如下：\n{\"a\":{\"b\":2}}\n以上。", `{"a":{"b":2}}`},
+		{"no json", "no json"},
+	}
+	for _, tt := range tests {
+		if got := extractJSON(tt.in); got != tt.want {
+			t.Errorf("extractJSON(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestAnalyzeProject_ResponseFormatFallback checks headers are sent and that a
+// 400 on response_format switches the client to prompt-only JSON mode.
+func TestAnalyzeProject_ResponseFormatFallback(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("X-Team") != "ai" {
+			t.Errorf("missing custom header, got %v", r.Header)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(string(body), "response_format") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"response_format is not supported","type":"invalid_request_error"}}`))
+			return
+		}
+		content, _ := json.Marshal("```json\n{\"summary\":\"测试摘要\"}\n```")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":` + string(content) + `}}],"usage":{"total_tokens":42}}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.LLMConfig{
+		Provider: "ollama",
+		BaseURL:  srv.URL,
+		Headers:  map[string]string{"X-Team": "ai"},
+	}
+	c, err := NewClient(cfg, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	a, err := c.AnalyzeProject(context.Background(), &datastore.Project{FullName: "a/b"}, testPrompt())
+	if err != nil {
+		t.Fatalf("AnalyzeProject: %v", err)
+	}
+	if a.Summary != "测试摘要" {
+		t.Errorf("Summary = %q", a.Summary)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2 (native then prompt)", calls)
+	}
+	if !c.promptJSON.Load() {
+		t.Error("client should remember prompt JSON mode")
+	}
+}
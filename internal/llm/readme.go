@@ -0,0 +1,280 @@
+package llm
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultREADMEMaxTokens applies when llm.readme_max_tokens is unset.
+const defaultREADMEMaxTokens = 1500
+
+// maxCodeBlockLines caps each fenced code block kept in the README.
+const maxCodeBlockLines = 12
+
+var (
+	reHTMLComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	reLinkedImage = regexp.MustCompile(`\[!\[[^\]]*\]\([^)]*\)\]\([^)]*\)`)
+	reImage       = regexp.MustCompile(`!\[[^\]]*\](\([^)]*\)|\[[^\]]*\])`)
+	reImgTag      = regexp.MustCompile(`(?i)<(img|picture|source|video|svg)\b[^>]*>`)
+	reHTMLTag     = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	reLink        = regexp.MustCompile(`\[([^\]]+)\](\([^)]*\)|\[[^\]]*\])`)
+	reLinkRefDef  = regexp.MustCompile(`(?m)^\s*\[[^\]]+\]:\s*\S+.*$`)
+	reBareURL     = regexp.MustCompile(`<https?://[^>]+>`)
+	reHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	reBlankLines  = regexp.MustCompile(`\n{3,}`)
+)
+
+// Section priorities used to spend the token budget.
+const (
+	prioDrop = -1 // never sent: TOC, license, contributors, …
+	prioLow  = 0  // install / usage boilerplate
+	prioNorm = 1
+	prioHigh = 2 // overview, features, architecture
+)
+
+// sectionPriorities maps heading keywords to priorities; the first match
+// wins, so more specific keywords come first.
+var sectionPriorities = []struct {
+	keywords []string
+	prio     int
+}{
+	{[]string{"table of contents", "contents", "目录",
+		"license", "许可", "contributor", "contributing", "贡献", "acknowledg", "致谢", "citation", "引用",
+		"star history", "sponsor", "赞助", "changelog", "更新日志", "community", "社区", "contact", "联系"}, prioDrop},
+	{[]string{"overview", "introduction", "about", "what is", "why", "features", "highlights", "architecture",
+		"how it works", "design", "concepts", "简介", "介绍", "概述", "特性", "功能", "亮点", "架构", "原理", "设计"}, prioHigh},
+	{[]string{"install", "getting started", "quick start", "quickstart", "setup", "requirements", "prerequisites",
+		"usage", "build", "docker", "faq", "安装", "快速开始", "使用", "部署", "环境", "常见问题"}, prioLow},
+}
+
+type readmeSection struct {
+	index int
+	prio  int
+	text  string // heading line plus body
+}
+
+// preprocessREADME strips badges, images, HTML and link noise, ranks
+// sections by heading and keeps the most useful ones within maxTokens.
+// Kept sections stay in document order.
+func preprocessREADME(raw string, maxTokens int) string {
+	if maxTokens <= 0 {
+		maxTokens = defaultREADMEMaxTokens
+	}
+
+	s := strings.ReplaceAll(raw, "\r\n", "\n")
+	s = cleanMarkdown(s)
+
+	sections := splitSections(s)
+	order := make([]*readmeSection, 0, len(sections))
+	for _, sec := range sections {
+		if sec.prio != prioDrop && strings.TrimSpace(sec.text) != "" {
+			order = append(order, sec)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].prio > order[j].prio })
+
+	// Fill the budget by priority; the first section that does not fit is
+	// truncated if enough budget is left to be useful.
+	budget := maxTokens
+	keep := make(map[int]string)
+	for _, sec := range order {
+		cost := estimateTokens(sec.text)
+		if cost <= budget {
+			keep[sec.index] = sec.text
+			budget -= cost
+			continue
+		}
+		if budget >= 50 {
+			keep[sec.index] = truncateTokens(sec.text, budget) + "\n…"
+		}
+		break
+	}
+
+	var b strings.Builder
+	for _, sec := range sections {
+		if text, ok := keep[sec.index]; ok {
+			b.WriteString(text)
+			b.WriteString("\n\n")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// cleanMarkdown removes markup that carries no meaning for the model.
+// Fenced code blocks are left intact apart from being shortened.
+func cleanMarkdown(s string) string {
+	var out []string
+	var code []string
+	inCode := false
+
+	flushCode := func() {
+		if len(code) > maxCodeBlockLines+1 {
+			code = append(code[:maxCodeBlockLines+1], "…")
+		}
+		out = append(out, code...)
+		code = code[:0]
+	}
+
+	var prose strings.Builder
+	flushProse := func() {
+		if prose.Len() > 0 {
+			out = append(out, cleanProse(prose.String()))
+			prose.Reset()
+		}
+	}
+
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") || strings.HasPrefix(strings.TrimSpace(line), "~~~") {
+			if inCode {
+				flushCode()
+				out = append(out, line)
+			} else {
+				flushProse()
+				code = append(code, line)
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			code = append(code, line)
+			continue
+		}
+		prose.WriteString(line)
+		prose.WriteByte('\n')
+	}
+	if inCode {
+		flushCode()
+	}
+	flushProse()
+
+	return reBlankLines.ReplaceAllString(strings.Join(out, "\n"), "\n\n")
+}
+
+func cleanProse(s string) string {
+	s = reHTMLComment.ReplaceAllString(s, "")
+	s = reLinkedImage.ReplaceAllString(s, "")
+	s = reImage.ReplaceAllString(s, "")
+	s = reImgTag.ReplaceAllString(s, "")
+	s = reHTMLTag.ReplaceAllString(s, "")
+	s = reLinkRefDef.ReplaceAllString(s, "")
+	s = reLink.ReplaceAllString(s, "$1")
+	s = reBareURL.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "&nbsp;", " ")
+
+	// Drop lines left with only separators, e.g. "| |" or " · · ".
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" && strings.TrimFunc(line, isSeparator) == "" {
+			continue
+		}
+		lines = append(lines, strings.TrimRightFunc(line, unicode.IsSpace))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isSeparator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("|·•-_*=:", r)
+}
+
+// splitSections splits markdown at headings outside code blocks. The text
+// before the first heading is the lead and has high priority; subsections
+// without a keyword of their own inherit their parent's priority.
+func splitSections(s string) []*readmeSection {
+	sections := []*readmeSection{{index: 0, prio: prioHigh}}
+	var body strings.Builder
+	inCode := false
+
+	type parent struct{ level, prio int }
+	var stack []parent
+
+	for _, line := range strings.Split(s, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inCode = !inCode
+		}
+		if m := reHeading.FindStringSubmatch(line); m != nil && !inCode {
+			sections[len(sections)-1].text = body.String()
+			body.Reset()
+
+			level := len(m[1])
+			for len(stack) > 0 && stack[len(stack)-1].level >= level {
+				stack = stack[:len(stack)-1]
+			}
+			prio, matched := headingPriority(m[2])
+			if !matched && len(stack) > 0 {
+				prio = stack[len(stack)-1].prio
+			}
+			stack = append(stack, parent{level, prio})
+
+			sections = append(sections, &readmeSection{index: len(sections), prio: prio})
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	sections[len(sections)-1].text = body.String()
+
+	for _, sec := range sections {
+		sec.text = strings.TrimSpace(sec.text)
+	}
+	return sections
+}
+
+// headingPriority ranks a heading by keyword; matched is false when no
+// keyword applies and the normal priority is returned.
+func headingPriority(heading string) (prio int, matched bool) {
+	h := strings.ToLower(heading)
+	for _, sp := range sectionPriorities {
+		for _, kw := range sp.keywords {
+			if strings.Contains(h, kw) {
+				return sp.prio, true
+			}
+		}
+	}
+	return prioNorm, false
+}
+
+// estimateTokens approximates the token count: one per CJK character and
+// one per four other non-space characters, which is close enough for the
+// BPE tokenizers of DeepSeek, Qwen and OpenAI models.
+func estimateTokens(s string) int {
+	cjk, other := 0, 0
+	for _, r := range s {
+		switch {
+		case isCJK(r):
+			cjk++
+		case !unicode.IsSpace(r):
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// truncateTokens cuts s to about maxTokens, on a rune boundary and,
+// where possible, at the end of a line.
+func truncateTokens(s string, maxTokens int) string {
+	cjk, other := 0, 0
+	for i, r := range s {
+		switch {
+		case isCJK(r):
+			cjk++
+		case !unicode.IsSpace(r):
+			other++
+		}
+		if cjk+(other+3)/4 > maxTokens {
+			cut := s[:i]
+			if nl := strings.LastIndexByte(cut, '\n'); nl > len(cut)/2 {
+				cut = cut[:nl]
+			}
+			return strings.TrimRightFunc(cut, unicode.IsSpace)
+		}
+	}
+	return s
+}
+
+func isCJK(r rune) bool {
+	return r >= utf8.RuneSelf && (unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r))
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const sampleREADME = `<p align="center">
+  <img src="logo.png" width="200">
+</p>
+
+[![Build](https://ci.example.com/badge.svg)](https://ci.example.com) ![Stars](https://img.shields.io/github/stars/a/b)
+
+<!-- generated by readme-md-generator -->
+**FastRAG** is a [retrieval](https://en.wikipedia.org/wiki/RAG) framework.
+
+## Table of Contents
+- [Features](#features)
+- [Installation](#installation)
+
+## Installation
+
+` + "```bash\npip install fastrag\n```" + `
+
+### From source
+
+Clone the repo and run make.
+
+## Features
+
+- Hybrid search over [BM25][bm25] and vectors
+- 中文分词支持
+
+## Architecture
+
+The indexer and the retriever run as separate services.
+
+## License
+
+MIT
+
+[bm25]: https://en.wikipedia.org/wiki/Okapi_BM25
+`
+
+func TestPreprocessREADME_StripsNoise(t *testing.T) {
+	got := preprocessREADME(sampleREADME, 1000)
+
+	for _, noise := range []string{"<img", "<p", "shields.io", "badge.svg", "readme-md-generator",
+		"wikipedia", "Table of Contents", "MIT", "[bm25]"} {
+		if strings.Contains(got, noise) {
+			t.Errorf("output still contains %q:\n%s", noise, got)
+		}
+	}
+	for _, keep := range []string{"**FastRAG** is a retrieval framework.", "## Features",
+		"Hybrid search over BM25 and vectors", "中文分词支持", "## Architecture", "pip install fastrag"} {
+		if !strings.Contains(got, keep) {
+			t.Errorf("output missing %q:\n%s", keep, got)
+		}
+	}
+	if strings.Index(got, "## Installation") > strings.Index(got, "## Features") {
+		t.Error("sections should stay in document order")
+	}
+}
+
+func TestPreprocessREADME_PrioritizesUnderBudget(t *testing.T) {
+	// Budget for lead + features + architecture only.
+	got := preprocessREADME(sampleREADME, 45)
+
+	if !strings.Contains(got, "## Features") || !strings.Contains(got, "## Architecture") {
+		t.Errorf("high-priority sections dropped:\n%s", got)
+	}
+	if strings.Contains(got, "pip install") || strings.Contains(got, "From source") {
+		t.Errorf("install boilerplate kept over budget:\n%s", got)
+	}
+	if n := estimateTokens(got); n > 50 {
+		t.Errorf("tokens = %d, over budget", n)
+	}
+}
+
+func TestPreprocessREADME_TruncatesOnRuneBoundary(t *testing.T) {
+	readme := "# 项目\n\n" + strings.Repeat("这是一个很长的中文介绍。", 500)
+	got := preprocessREADME(readme, 200)
+
+	if !utf8.ValidString(got) {
+		t.Fatal("output is not valid UTF-8")
+	}
+	if n := estimateTokens(got); n > 210 {
+		t.Errorf("tokens = %d, want about 200", n)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"中文", 2},
+		{"Go 语言", 3},
+	}
+	for _, tt := range tests {
+		if got := estimateTokens(tt.in); got != tt.want {
+			t.Errorf("estimateTokens(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package llm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Recorder modes.
+const (
+	RecordMode = "record" // forward requests and save the responses
+	ReplayMode = "replay" // answer from saved responses only, no network
+)
+
+// ErrNoRecording is returned in replay mode for a request that was never
+// recorded, e.g. after a prompt change.
+var ErrNoRecording = errors.New("没有匹配的录制响应")
+
+// recordingMeta is stored as recording.json in a fixture directory.
+const recordingMeta = "recording.json"
+
+// Recorder is an http.RoundTripper that records request/response pairs
+// to a fixture directory, or replays them without network access. A
+// replay runs on the clock of the recording so time-dependent prompt
+// fields (days since push, project age) render as they were recorded.
+//
+// Fixtures are keyed by method, path, query and request body; the host is
+// left out so recordings survive base URL changes. Credentials and other
+// headers are never stored.
+type Recorder struct {
+	dir        string
+	mode       string
+	base       http.RoundTripper
+	recordedAt time.Time
+}
+
+// NewRecorder opens dir for mode. Recording creates the directory and
+// stamps it with the current time; fixtures already there are kept.
+// Replaying requires an existing recording.
+func NewRecorder(dir, mode string) (*Recorder, error) {
+	r := &Recorder{dir: dir, mode: mode, base: http.DefaultTransport}
+	meta := filepath.Join(dir, recordingMeta)
+
+	var m struct {
+		RecordedAt time.Time `json:"recorded_at"`
+	}
+	data, err := os.ReadFile(meta)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", meta, err)
+		}
+		r.recordedAt = m.RecordedAt
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("reading %s: %w", meta, err)
+	}
+
+	switch mode {
+	case ReplayMode:
+		if r.recordedAt.IsZero() {
+			return nil, fmt.Errorf("%s 不是录制目录（缺少 %s）", dir, recordingMeta)
+		}
+	case RecordMode:
+		r.recordedAt = time.Now().UTC().Truncate(time.Second)
+		m.RecordedAt = r.recordedAt
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating recording dir: %w", err)
+		}
+		if err := writeFileAtomic(meta, mustIndent(m)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown recorder mode %q (use %s or %s)", mode, RecordMode, ReplayMode)
+	}
+	return r, nil
+}
+
+// Replaying reports whether r answers from fixtures only.
+func (r *Recorder) Replaying() bool { return r.mode == ReplayMode }
+
+// Now returns the time the recording was started when replaying, and the
+// wall clock while recording: a recording run is a live run.
+func (r *Recorder) Now() time.Time {
+	if r.Replaying() {
+		return r.recordedAt
+	}
+	return time.Now().UTC()
+}
+
+// fixture is one recorded exchange. Bodies that are JSON are stored as
+// JSON so fixtures stay readable and diffable; others as text.
+type fixture struct {
+	Request struct {
+		Method string          `json:"method"`
+		Path   string          `json:"path"`
+		Body   json.RawMessage `json:"body,omitempty"`
+		Text   string          `json:"text,omitempty"`
+	} `json:"request"`
+	Response struct {
+		Status      int             `json:"status"`
+		ContentType string          `json:"content_type,omitempty"`
+		Body        json.RawMessage `json:"body,omitempty"`
+		Text        string          `json:"text,omitempty"`
+	} `json:"response"`
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	body = compactJSON(body)
+	path := requestPath(req)
+	file := filepath.Join(r.dir, fixtureKey(req.Method, path, body)+".json")
+
+	if r.Replaying() {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("%w: %s %s（请用 --record 重新录制）", ErrNoRecording, req.Method, path)
+			}
+			return nil, fmt.Errorf("reading fixture: %w", err)
+		}
+		var f fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("parsing fixture %s: %w", file, err)
+		}
+		respBody := []byte(f.Response.Text)
+		if len(f.Response.Body) > 0 {
+			respBody = f.Response.Body
+		}
+		return newResponse(req, f.Response.Status, f.Response.ContentType, respBody), nil
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	// Transient failures are retried; record only the final answer.
+	if retryableStatus(resp.StatusCode) {
+		return resp, nil
+	}
+
+	var f fixture
+	f.Request.Method = req.Method
+	f.Request.Path = path
+	f.Request.Body, f.Request.Text = splitBody(body)
+	f.Response.Status = resp.StatusCode
+	f.Response.ContentType = resp.Header.Get("Content-Type")
+	f.Response.Body, f.Response.Text = splitBody(compactJSON(respBody))
+	if err := writeFileAtomic(file, mustIndent(f)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func requestPath(req *http.Request) string {
+	if req.URL.RawQuery == "" {
+		return req.URL.Path
+	}
+	return req.URL.Path + "?" + req.URL.RawQuery
+}
+
+func fixtureKey(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// compactJSON strips insignificant whitespace from JSON bodies so
+// formatting differences don't change fixture keys.
+func compactJSON(b []byte) []byte {
+	var buf bytes.Buffer
+	if len(b) == 0 || json.Compact(&buf, b) != nil {
+		return b
+	}
+	return buf.Bytes()
+}
+
+func splitBody(b []byte) (json.RawMessage, string) {
+	if len(b) == 0 {
+		return nil, ""
+	}
+	if json.Valid(b) {
+		return json.RawMessage(b), ""
+	}
+	return nil, string(b)
+}
+
+func newResponse(req *http.Request, status int, contentType string, body []byte) *http.Response {
+	h := make(http.Header)
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func mustIndent(v any) []byte {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		panic(err) // only fixed, marshalable types are passed
+	}
+	return append(data, '\n')
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+	return nil
+}
+
+// Option configures NewClient, NewAnalyzer, NewEmbedder and
+// NewComparisonResolver.
+type Option func(*options)
+
+type options struct {
+	recorder *Recorder
+}
+
+// WithRecorder sends all LLM and GitHub HTTP traffic through rec. In
+// replay mode no API key is required, the recording's clock is used and
+// the Analyzer writes nothing to the data dir.
+func WithRecorder(rec *Recorder) Option {
+	return func(o *options) { o.recorder = rec }
+}
+
+func buildOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// transport is the base HTTP transport for outgoing requests.
+func (o options) transport() http.RoundTripper {
+	if o.recorder != nil {
+		return o.recorder
+	}
+	return http.DefaultTransport
+}
+
+// clock returns the time source: the recording's clock when replaying,
+// otherwise wall time.
+func (o options) clock() func() time.Time {
+	if o.offline() {
+		return o.recorder.Now
+	}
+	return func() time.Time { return time.Now().UTC() }
+}
+
+// offline reports whether no real endpoint will be contacted.
+func (o options) offline() bool {
+	return o.recorder != nil && o.recorder.Replaying()
+}
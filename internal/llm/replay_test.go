@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/llm/llmtest"
+)
+
+// replayAnalysis runs the analyzer over a fresh store with one project and
+// returns its analysis: the saved one when recording, the printed one when
+// replaying, which must leave the store untouched.
+func replayAnalysis(t *testing.T, baseURL string, rec *Recorder) *datastore.Analysis {
+	t.Helper()
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	desc := "An LLM framework for Go"
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	if err := store.SaveProject(&datastore.Project{
+		ID: "a__b", FullName: "a/b", Description: &desc, Topics: []string{"llm"},
+		Stars: 1200, FirstSeenAt: now, UpdatedAt: now,
+	}); err != nil {
+		t.Fatalf("SaveProject: %v", err)
+	}
+
+	a, err := NewAnalyzer(store, config.LLMConfig{
+		Provider:   "ollama",
+		BaseURL:    baseURL,
+		PromptsDir: "../../prompts",
+		RepairMax:  1,
+	}, "", testLogger(), WithRecorder(rec))
+	if err != nil {
+		t.Fatalf("NewAnalyzer: %v", err)
+	}
+	a.gh.BaseURL, _ = url.Parse(baseURL + "/")
+	var out bytes.Buffer
+	a.out = &out
+
+	afterRun := false
+	opts := RunOptions{AfterRun: func([]Generated) error { afterRun = true; return nil }}
+	if err := a.Run(context.Background(), opts); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	p, err := store.LoadProject("a__b")
+	if err != nil {
+		t.Fatalf("LoadProject: %v", err)
+	}
+	if !rec.Replaying() {
+		if p.Analysis == nil || !afterRun {
+			t.Fatalf("analysis = %v, after run = %v", p.Analysis, afterRun)
+		}
+		return p.Analysis
+	}
+
+	if p.Analysis != nil || p.TechStack != nil || afterRun {
+		t.Errorf("replay wrote to the store: %+v, after run = %v", p, afterRun)
+	}
+	if _, err := os.Stat(store.READMECachePath("a__b")); !os.IsNotExist(err) {
+		t.Errorf("replay wrote the README cache: %v", err)
+	}
+	header, body, ok := strings.Cut(out.String(), "\n")
+	if !ok || header != "===== analysis (a/b, zh) =====" {
+		t.Fatalf("output = %q", out.String())
+	}
+	var an datastore.Analysis
+	if err := json.NewDecoder(strings.NewReader(body)).Decode(&an); err != nil {
+		t.Fatalf("decoding printed analysis: %v", err)
+	}
+	return &an
+}
+
+func TestRecorder_RecordThenReplay(t *testing.T) {
+	srv := llmtest.NewServer(t, func(_ openai.ChatCompletionRequest, call int) string {
+		if call == 0 {
+			// No features: fails validation and triggers a repair.
+			return `{"summary":"Go 语言的 LLM 应用框架","positioning":"面向 Go 开发者的 LLM 应用框架。"}`
+		}
+		return `{"summary":"Go 语言的 LLM 应用框架","positioning":"面向 Go 开发者的 LLM 应用框架。",` +
+			`"features":[{"name":"链式调用","desc":"组合模型、工具与记忆"}],"tech_stack":"Go",` +
+			`"comparison":[{"project":"langchain-ai/langchain","diff":"Go 原生实现"}]}`
+	})
+	readme := base64.StdEncoding.EncodeToString([]byte("# b\n\nAn LLM framework for Go.\n"))
+	srv.SetGitHub("/repos/a/b/readme", `{"content":"`+readme+`","encoding":"base64"}`)
+	srv.SetGitHub("/repos/langchain-ai/langchain", `{"full_name":"langchain-ai/langchain","stargazers_count":100000}`)
+
+	dir := filepath.Join(t.TempDir(), "fixtures")
+	rec, err := NewRecorder(dir, RecordMode)
+	if err != nil {
+		t.Fatalf("NewRecorder(record): %v", err)
+	}
+	recorded := replayAnalysis(t, srv.URL, rec)
+	if recorded.Validation == nil || recorded.Validation.Repairs != 1 {
+		t.Fatalf("recorded validation = %+v, want one repair", recorded.Validation)
+	}
+	if len(recorded.Comparison) != 1 || recorded.Comparison[0].Status != datastore.ComparisonGitHub {
+		t.Errorf("recorded comparison = %+v, want resolved on GitHub", recorded.Comparison)
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("chat requests = %d, want 2", got)
+	}
+	if time.Since(recorded.GeneratedAt) > time.Minute {
+		t.Errorf("GeneratedAt = %v, want the wall clock while recording", recorded.GeneratedAt)
+	}
+
+	// Replay with the server gone: every response comes from fixtures.
+	srv.Close()
+	rep, err := NewRecorder(dir, ReplayMode)
+	if err != nil {
+		t.Fatalf("NewRecorder(replay): %v", err)
+	}
+	replayed := replayAnalysis(t, srv.URL, rep)
+	if !replayed.GeneratedAt.Equal(rep.Now()) {
+		t.Errorf("GeneratedAt = %v, want recording clock %v", replayed.GeneratedAt, rep.Now())
+	}
+	recorded.Version, replayed.Version = "", ""
+	recorded.GeneratedAt, replayed.GeneratedAt = time.Time{}, time.Time{}
+	if !reflect.DeepEqual(recorded, replayed) {
+		t.Errorf("replayed analysis differs:\n got  %+v\n want %+v", replayed, recorded)
+	}
+}
+
+func TestRecorder_ReplayMiss(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewRecorder(dir, ReplayMode); err == nil {
+		t.Fatal("replaying a directory without recording.json should fail")
+	}
+	if _, err := NewRecorder(dir, RecordMode); err != nil {
+		t.Fatalf("NewRecorder(record): %v", err)
+	}
+	rec, err := NewRecorder(dir, ReplayMode)
+	if err != nil {
+		t.Fatalf("NewRecorder(replay): %v", err)
+	}
+
+	c, err := NewClient(config.LLMConfig{Provider: "deepseek"}, testLogger(), WithRecorder(rec))
+	if err != nil {
+		t.Fatalf("NewClient without API key in replay mode: %v", err)
+	}
+	_, err = c.Embed(context.Background(), []string{"x"})
+	if !errors.Is(err, ErrNoRecording) {
+		t.Errorf("err = %v, want ErrNoRecording", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("replay wrote files: %v", entries)
+	}
+}
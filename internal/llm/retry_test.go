@@ -0,0 +1,224 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// chatResponse renders a minimal chat completion body with the given content.
+func chatResponse(content string) string {
+	c, _ := json.Marshal(content)
+	return `{"choices":[{"message":{"role":"assistant","content":` + string(c) + `}}],"usage":{"total_tokens":10}}`
+}
+
+// noSleep records requested delays instead of waiting.
+func noSleep(delays *[]time.Duration) func(context.Context, time.Duration) error {
+	return func(_ context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return nil
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, maxDelay := time.Second, 10*time.Second
+
+	for attempt := 0; attempt < 6; attempt++ {
+		want := min(base<<attempt, maxDelay)
+		d := backoff(attempt, base, maxDelay, 0)
+		if d < want/2 || d > want {
+			t.Errorf("attempt %d: delay %v outside [%v, %v]", attempt, d, want/2, want)
+		}
+	}
+
+	if d := backoff(0, base, maxDelay, 7*time.Second); d != 7*time.Second {
+		t.Errorf("Retry-After not honored: %v", d)
+	}
+	if d := backoff(0, base, maxDelay, time.Hour); d != maxDelay {
+		t.Errorf("Retry-After not capped: %v", d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{"garbage", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"429", &openai.APIError{HTTPStatusCode: 429}, true},
+		{"503", &openai.RequestError{HTTPStatusCode: 503}, true},
+		{"401", &openai.APIError{HTTPStatusCode: 401}, false},
+		{"malformed", fmt.Errorf("%w: x", errMalformedOutput), true},
+		{"deadline", fmt.Errorf("post: %w", context.DeadlineExceeded), true},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: isRetryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeProject_RetryHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"rate limited"}}`))
+		case 2:
+			_, _ = w.Write([]byte(chatResponse("not json")))
+		default:
+			_, _ = w.Write([]byte(chatResponse(`{"summary":"ok"}`)))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(config.LLMConfig{Provider: "ollama", BaseURL: srv.URL, RetryMax: 3}, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	var delays []time.Duration
+	c.sleep = noSleep(&delays)
+
+	a, err := c.AnalyzeProject(context.Background(), &datastore.Project{FullName: "a/b"}, testPrompt())
+	if err != nil {
+		t.Fatalf("AnalyzeProject: %v", err)
+	}
+	if a.Summary != "ok" {
+		t.Errorf("Summary = %q", a.Summary)
+	}
+	if len(delays) != 2 {
+		t.Fatalf("delays = %v, want 2 retries", delays)
+	}
+	if delays[0] != 3*time.Second {
+		t.Errorf("first delay = %v, want Retry-After 3s", delays[0])
+	}
+}
+
+func TestAnalyzeProject_NonRetryableStopsEarly(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"bad key"}}`))
+	}))
+	defer srv.Close()
+
+	c, _ := NewClient(config.LLMConfig{Provider: "ollama", BaseURL: srv.URL, RetryMax: 3}, testLogger())
+	var delays []time.Duration
+	c.sleep = noSleep(&delays)
+
+	if _, err := c.AnalyzeProject(context.Background(), &datastore.Project{FullName: "a/b"}, testPrompt()); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls.Load() != 1 || len(delays) != 0 {
+		t.Errorf("calls = %d, delays = %v; want a single attempt", calls.Load(), delays)
+	}
+}
+
+func TestAnalyzeProject_Failover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(chatResponse(`{"summary":"备用"}`)))
+	}))
+	defer up.Close()
+
+	cfg := config.LLMConfig{
+		Provider: "deepseek",
+		APIKey:   "k",
+		BaseURL:  down.URL,
+		RetryMax: 1,
+		Fallbacks: []config.LLMFallbackConfig{
+			{Provider: "qwen", Model: "qwen-max", BaseURL: up.URL, APIKey: "qk"},
+		},
+	}
+	c, err := NewClient(cfg, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	var delays []time.Duration
+	c.sleep = noSleep(&delays)
+
+	a, err := c.AnalyzeProject(context.Background(), &datastore.Project{FullName: "a/b"}, testPrompt())
+	if err != nil {
+		t.Fatalf("AnalyzeProject: %v", err)
+	}
+	if a.Model != "qwen-max" {
+		t.Errorf("Model = %q, want qwen-max", a.Model)
+	}
+	if len(delays) != 1 {
+		t.Errorf("delays = %v, want 1 retry on primary", delays)
+	}
+}
+
+func TestNewClient_InvalidFallback(t *testing.T) {
+	cfg := config.LLMConfig{
+		Provider:  "deepseek",
+		APIKey:    "k",
+		Fallbacks: []config.LLMFallbackConfig{{Provider: "nope"}},
+	}
+	if _, err := NewClient(cfg, testLogger()); err == nil {
+		t.Fatal("expected error for unknown fallback provider")
+	}
+}
+
+func TestNewClient_FallbackAPIKey(t *testing.T) {
+	cfg := config.LLMConfig{
+		Provider:  "deepseek",
+		APIKey:    "k",
+		Fallbacks: []config.LLMFallbackConfig{{Provider: "qwen", Model: "qwen-max"}},
+	}
+	if _, err := NewClient(cfg, testLogger()); err == nil || !strings.Contains(err.Error(), "llm.fallbacks[0]") {
+		t.Fatalf("err = %v, want the primary key refused for another provider", err)
+	}
+
+	// Same provider reuses the key; keyless providers need none.
+	cfg.Fallbacks = []config.LLMFallbackConfig{{Model: "deepseek-reasoner"}, {Provider: "ollama", Model: "qwen2.5"}}
+	c, err := NewClient(cfg, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if len(c.fallbacks) != 2 || c.fallbacks[0].cfg.APIKey != "k" || c.fallbacks[1].cfg.APIKey != "" {
+		t.Errorf("fallbacks = %+v", c.fallbacks)
+	}
+}
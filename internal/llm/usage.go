@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// UsageLedger appends every API call to data/usage/{month}.jsonl, priced
+// with the table in effect at the time of the call.
+type UsageLedger struct {
+	store   *datastore.Store
+	command string
+	prices  PriceTable
+	log     *zap.Logger
+	now     func() time.Time
+}
+
+// NewUsageLedger creates a ledger that attributes calls to command.
+func NewUsageLedger(store *datastore.Store, command string, prices PriceTable, log *zap.Logger) *UsageLedger {
+	return &UsageLedger{
+		store:   store,
+		command: command,
+		prices:  prices,
+		log:     log,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Record appends u to the ledger. Failures are logged, not returned: a
+// ledger problem must not abort a run whose tokens are already spent. It
+// is safe for concurrent use.
+func (l *UsageLedger) Record(u Usage) {
+	cost, priced := l.prices.Cost(u.Model, u.PromptTokens, u.CompletionTokens)
+	r := &datastore.UsageRecord{
+		Time:             l.now(),
+		Command:          l.command,
+		Project:          u.Project,
+		Provider:         u.Provider,
+		Model:            u.Model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+		CostCNY:          cost,
+		Unpriced:         !priced,
+	}
+	if err := l.store.AppendUsage(r); err != nil {
+		l.log.Warn("写入用量记录失败", zap.String("model", u.Model), zap.Error(err))
+	}
+}
+
+// UsageLine aggregates ledger records sharing a key.
+type UsageLine struct {
+	Key              string
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	CostCNY          float64
+}
+
+func (l *UsageLine) add(r *datastore.UsageRecord) {
+	l.Calls++
+	l.PromptTokens += r.PromptTokens
+	l.CompletionTokens += r.CompletionTokens
+	l.TotalTokens += r.TotalTokens
+	l.CostCNY += r.CostCNY
+}
+
+// UsageReport breaks a month of ledger records down by model, command and
+// project. Lines are ordered by cost, then tokens, highest first.
+type UsageReport struct {
+	Month     string
+	Total     UsageLine
+	ByModel   []UsageLine
+	ByCommand []UsageLine
+	ByProject []UsageLine // calls without a project (e.g. embedding batches) are left out
+	Unpriced  []string    // models recorded without a price
+}
+
+// BuildUsageReport aggregates the ledger records of one month.
+func BuildUsageReport(month string, records []*datastore.UsageRecord) *UsageReport {
+	rep := &UsageReport{Month: month, Total: UsageLine{Key: month}}
+	models := make(map[string]*UsageLine)
+	commands := make(map[string]*UsageLine)
+	projects := make(map[string]*UsageLine)
+	unpriced := make(map[string]bool)
+
+	for _, r := range records {
+		rep.Total.add(r)
+		lineFor(models, r.Model).add(r)
+		lineFor(commands, r.Command).add(r)
+		if r.Project != "" {
+			lineFor(projects, r.Project).add(r)
+		}
+		if r.Unpriced {
+			unpriced[r.Model] = true
+		}
+	}
+
+	rep.ByModel = sortedLines(models)
+	rep.ByCommand = sortedLines(commands)
+	rep.ByProject = sortedLines(projects)
+	for m := range unpriced {
+		rep.Unpriced = append(rep.Unpriced, m)
+	}
+	sort.Strings(rep.Unpriced)
+	return rep
+}
+
+func lineFor(m map[string]*UsageLine, key string) *UsageLine {
+	l, ok := m[key]
+	if !ok {
+		l = &UsageLine{Key: key}
+		m[key] = l
+	}
+	return l
+}
+
+func sortedLines(m map[string]*UsageLine) []UsageLine {
+	lines := make([]UsageLine, 0, len(m))
+	for _, l := range m {
+		lines = append(lines, *l)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].CostCNY != lines[j].CostCNY {
+			return lines[i].CostCNY > lines[j].CostCNY
+		}
+		if lines[i].TotalTokens != lines[j].TotalTokens {
+			return lines[i].TotalTokens > lines[j].TotalTokens
+		}
+		return lines[i].Key < lines[j].Key
+	})
+	return lines
+}
@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func TestUsageLedger_Record(t *testing.T) {
+	store := datastore.NewStore(t.TempDir(), testLogger())
+	prices := NewPriceTable([]config.LLMPrice{{Model: "deepseek-chat", Input: 2, Output: 8}})
+	l := NewUsageLedger(store, "analyze", prices, testLogger())
+	l.now = func() time.Time { return time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC) }
+
+	l.Record(Usage{Project: "a/b", Provider: "deepseek", Model: "deepseek-chat", PromptTokens: 1_000_000, CompletionTokens: 500_000, TotalTokens: 1_500_000})
+	l.Record(Usage{Project: "a/b", Provider: "ollama", Model: "qwen2.5:7b", PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})
+
+	recs, err := store.LoadUsage("2026-10")
+	if err != nil {
+		t.Fatalf("LoadUsage: %v", err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("records = %d, want 2", len(recs))
+	}
+	if r := recs[0]; r.Command != "analyze" || r.CostCNY != 6 || r.Unpriced {
+		t.Errorf("priced record = %+v, want analyze at 6 CNY", r)
+	}
+	if r := recs[1]; r.CostCNY != 0 || !r.Unpriced {
+		t.Errorf("unpriced record = %+v", r)
+	}
+}
+
+func TestBuildUsageReport(t *testing.T) {
+	recs := []*datastore.UsageRecord{
+		{Command: "analyze", Project: "a/b", Model: "deepseek-chat", PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150, CostCNY: 0.5},
+		{Command: "analyze", Project: "a/b", Model: "deepseek-chat", PromptTokens: 200, CompletionTokens: 10, TotalTokens: 210, CostCNY: 0.25},
+		{Command: "analyze", Project: "c/d", Model: "qwen-plus", PromptTokens: 300, TotalTokens: 300, CostCNY: 1},
+		{Command: "embed", Model: "nomic-embed-text", PromptTokens: 40, TotalTokens: 40, Unpriced: true},
+	}
+	rep := BuildUsageReport("2026-10", recs)
+
+	if rep.Total.Calls != 4 || rep.Total.TotalTokens != 700 || math.Abs(rep.Total.CostCNY-1.75) > 1e-9 {
+		t.Errorf("total = %+v", rep.Total)
+	}
+	if len(rep.ByModel) != 3 || rep.ByModel[0].Key != "qwen-plus" || rep.ByModel[1].Calls != 2 {
+		t.Errorf("by model = %+v, want qwen-plus first, then deepseek-chat with 2 calls", rep.ByModel)
+	}
+	if len(rep.ByCommand) != 2 || rep.ByCommand[0].Key != "analyze" || rep.ByCommand[1].Key != "embed" {
+		t.Errorf("by command = %+v", rep.ByCommand)
+	}
+	if len(rep.ByProject) != 2 || rep.ByProject[0].Key != "c/d" || rep.ByProject[1].PromptTokens != 300 {
+		t.Errorf("by project = %+v, want embed batch left out", rep.ByProject)
+	}
+	if len(rep.Unpriced) != 1 || rep.Unpriced[0] != "nomic-embed-text" {
+		t.Errorf("unpriced = %v", rep.Unpriced)
+	}
+}
@@ -0,0 +1,223 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// Field limits, matching the comments on datastore.Analysis and the
+// "200字以内" instructions in the prompts.
+const (
+	maxSummaryChars  = 50
+	maxLongTextChars = 200
+	maxCompNameChars = 60
+	minChineseRatio  = 0.3
+	maxEnglishHan    = 0.25 // han share above which text is not English
+)
+
+// localeRules are the per-locale length limits and language check.
+type localeRules struct {
+	maxSummary  int
+	maxLongText int
+	language    string            // shown in issue messages, e.g. 中文
+	matches     func(string) bool // nil = no language check
+}
+
+// validationRules by locale. English limits are in characters, about
+// 20 and 150 words; unknown locales get them without a language check.
+var validationRules = map[string]localeRules{
+	datastore.DefaultLocale: {maxSummaryChars, maxLongTextChars, "中文", isChinese},
+	"en":                    {150, 1200, "英文", isEnglish},
+}
+
+func rulesFor(locale string) localeRules {
+	if r, ok := validationRules[locale]; ok {
+		return r
+	}
+	return localeRules{maxSummary: 150, maxLongText: 1200}
+}
+
+// Validation levels. Errors trigger a repair round-trip; warnings are
+// only recorded for reviewers.
+const (
+	levelError   = "error"
+	levelWarning = "warning"
+)
+
+// ValidateAnalysis checks an analysis against the field limits of its
+// locale. p is the analyzed project, used to catch self-comparisons.
+func ValidateAnalysis(p *datastore.Project, a *datastore.Analysis) []datastore.ValidationIssue {
+	v := validator{rules: rulesFor(a.EffectiveLocale())}
+
+	v.text("summary", a.Summary, true, v.rules.maxSummary, levelError)
+	v.text("positioning", a.Positioning, true, v.rules.maxLongText, levelWarning)
+	v.text("advantages", a.Advantages, false, v.rules.maxLongText, levelWarning)
+	v.text("use_cases", a.UseCases, false, v.rules.maxLongText, levelWarning)
+	v.text("ecosystem", a.Ecosystem, false, v.rules.maxLongText, levelWarning)
+	if strings.TrimSpace(a.TechStack) == "" {
+		v.add("tech_stack", levelWarning, "为空")
+	}
+
+	if len(a.Features) == 0 {
+		v.add("features", levelError, "至少需要一个功能点")
+	}
+	for i, f := range a.Features {
+		field := fmt.Sprintf("features[%d]", i)
+		if strings.TrimSpace(f.Name) == "" || strings.TrimSpace(f.Desc) == "" {
+			v.add(field, levelWarning, "name 或 desc 为空")
+		} else if v.rules.matches != nil && !v.rules.matches(f.Desc) {
+			v.add(field+".desc", levelWarning, "缺少"+v.rules.language+"描述")
+		}
+	}
+
+	self := strings.ToLower(p.FullName)
+	repo := self
+	if i := strings.LastIndex(self, "/"); i >= 0 {
+		repo = self[i+1:]
+	}
+	seen := make(map[string]bool)
+	for i, c := range a.Comparison {
+		field := fmt.Sprintf("comparison[%d]", i)
+		name := strings.ToLower(strings.TrimSpace(c.Project))
+		switch {
+		case name == self || name == repo:
+			v.add(field, levelError, fmt.Sprintf("与项目自身对比（%s）", c.Project))
+		case seen[name]:
+			v.add(field, levelWarning, fmt.Sprintf("重复的对比项目 %s", c.Project))
+		case utf8.RuneCountInString(name) > maxCompNameChars || hasHan(name):
+			v.add(field, levelWarning, fmt.Sprintf("%q 不像项目名称", c.Project))
+		}
+		seen[name] = true
+		if strings.TrimSpace(c.Diff) == "" {
+			v.add(field+".diff", levelWarning, "缺少差异说明")
+		}
+	}
+
+	return v.issues
+}
+
+type validator struct {
+	rules  localeRules
+	issues []datastore.ValidationIssue
+}
+
+func (v *validator) add(field, level, msg string) {
+	v.issues = append(v.issues, datastore.ValidationIssue{Field: field, Level: level, Message: msg})
+}
+
+// text checks a free-text field. A missing required field or one not in
+// the locale's language is an error; exceeding max is reported at lengthLevel.
+func (v *validator) text(field, s string, required bool, max int, lengthLevel string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		if required {
+			v.add(field, levelError, "为空")
+		} else {
+			v.add(field, levelWarning, "为空")
+		}
+		return
+	}
+	if n := utf8.RuneCountInString(s); n > max {
+		v.add(field, lengthLevel, fmt.Sprintf("超过 %d 字（当前 %d 字）", max, n))
+	}
+	if v.rules.matches != nil && !v.rules.matches(s) {
+		level := levelWarning
+		if required {
+			level = levelError
+		}
+		v.add(field, level, "不是"+v.rules.language+"（或"+v.rules.language+"比例过低）")
+	}
+}
+
+// isChinese reports whether s is predominantly Chinese. Latin words count
+// as one unit each so English technical terms do not dominate.
+func isChinese(s string) bool {
+	han, words := countHanWords(s)
+	if han == 0 {
+		return false
+	}
+	return float64(han)/float64(han+words) >= minChineseRatio
+}
+
+// isEnglish reports whether s is English: it has Latin words and Chinese
+// characters are at most a small share, e.g. a quoted project name.
+func isEnglish(s string) bool {
+	han, words := countHanWords(s)
+	if words == 0 {
+		return false
+	}
+	return float64(han)/float64(han+words) <= maxEnglishHan
+}
+
+// countHanWords counts the Chinese characters and the runs of ASCII
+// letters (Latin words) in s.
+func countHanWords(s string) (han, words int) {
+	inWord := false
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+			inWord = false
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	return han, words
+}
+
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// countErrors returns the number of error-level issues.
+func countErrors(issues []datastore.ValidationIssue) int {
+	n := 0
+	for _, is := range issues {
+		if is.Level == levelError {
+			n++
+		}
+	}
+	return n
+}
+
+// repairMessages continues the conversation with the model's previous
+// reply and a targeted list of the errors to fix. Outside the default
+// locale the request is in English so the model keeps writing English;
+// the issue messages themselves stay Chinese for reviewers.
+func repairMessages(previous string, issues []datastore.ValidationIssue, locale string) []openai.ChatCompletionMessage {
+	intro, outro := "你上一次输出的 JSON 存在以下问题：\n", "\n请只修正上述问题，其余字段保持不变，重新输出完整的 JSON 对象。"
+	if locale != datastore.DefaultLocale {
+		intro = "Your previous JSON output has these problems (field: issue):\n"
+		outro = "\nFix only these problems, keep every other field unchanged and output the complete JSON object again, in English."
+	}
+
+	var b strings.Builder
+	b.WriteString(intro)
+	for _, is := range issues {
+		if is.Level == levelError {
+			fmt.Fprintf(&b, "- %s: %s\n", is.Field, is.Message)
+		}
+	}
+	b.WriteString(outro)
+
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleAssistant, Content: previous},
+		{Role: openai.ChatMessageRoleUser, Content: b.String()},
+	}
+}
@@ -0,0 +1,194 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func TestIsChinese(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"LangChain 的 Go 语言实现", true},
+		{"基于 RAG 的知识库问答系统", true},
+		{"A framework for building LLM apps", false},
+		{"A framework for building LLM apps 的", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isChinese(tt.in); got != tt.want {
+			t.Errorf("isChinese(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsEnglish(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"A framework for building LLM apps", true},
+		{"A Go port of LangChain for backend services, known in China as 链式", true},
+		{"LangChain 的 Go 语言实现", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isEnglish(tt.in); got != tt.want {
+			t.Errorf("isEnglish(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidateAnalysis_English(t *testing.T) {
+	p := &datastore.Project{FullName: "tmc/langchaingo"}
+	a := &datastore.Analysis{
+		Locale:      "en",
+		Summary:     "A LangChain port for Go services that embed LLM features",
+		Positioning: "Building blocks for LLM applications written in Go",
+		Features:    []datastore.Feature{{Name: "Chains", Desc: "Compose multiple calls"}},
+		TechStack:   "Go",
+	}
+	for _, is := range ValidateAnalysis(p, a) {
+		if is.Level == levelError {
+			t.Errorf("valid en analysis has error %+v", is)
+		}
+	}
+
+	a.Summary = "Go 语言的 LLM 应用框架"
+	issues := ValidateAnalysis(p, a)
+	if countErrors(issues) != 1 || issues[0].Field != "summary" || !strings.Contains(issues[0].Message, "英文") {
+		t.Errorf("Chinese summary in en analysis: issues = %+v", issues)
+	}
+}
+
+func validAnalysis() *datastore.Analysis {
+	return &datastore.Analysis{
+		Summary:     "面向 Go 开发者的 LLM 应用框架",
+		Positioning: "为 Go 服务端提供构建大模型应用的基础组件",
+		Features:    []datastore.Feature{{Name: "Chains", Desc: "组合多个调用步骤"}},
+		Advantages:  "原生 Go 实现，部署简单",
+		TechStack:   "Go",
+		UseCases:    "后端服务集成大模型能力",
+		Ecosystem:   "支持 OpenAI 与本地模型",
+		Comparison:  []datastore.ComparisonEntry{{Project: "langchain-ai/langchain", Diff: "Python 实现"}},
+	}
+}
+
+func TestValidateAnalysis(t *testing.T) {
+	p := &datastore.Project{FullName: "tmc/langchaingo"}
+
+	if issues := ValidateAnalysis(p, validAnalysis()); len(issues) != 0 {
+		t.Errorf("valid analysis has issues: %+v", issues)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(a *datastore.Analysis)
+		field  string
+		level  string
+	}{
+		{"summary too long", func(a *datastore.Analysis) { a.Summary = strings.Repeat("很", 51) }, "summary", levelError},
+		{"summary english", func(a *datastore.Analysis) { a.Summary = "An LLM framework for Go" }, "summary", levelError},
+		{"summary empty", func(a *datastore.Analysis) { a.Summary = "" }, "summary", levelError},
+		{"positioning long", func(a *datastore.Analysis) { a.Positioning = strings.Repeat("长", 201) }, "positioning", levelWarning},
+		{"no features", func(a *datastore.Analysis) { a.Features = nil }, "features", levelError},
+		{"english feature", func(a *datastore.Analysis) { a.Features[0].Desc = "Compose calls" }, "features[0].desc", levelWarning},
+		{"self comparison", func(a *datastore.Analysis) { a.Comparison[0].Project = "langchaingo" }, "comparison[0]", levelError},
+		{"not a project", func(a *datastore.Analysis) { a.Comparison[0].Project = "其他框架" }, "comparison[0]", levelWarning},
+		{"empty ecosystem", func(a *datastore.Analysis) { a.Ecosystem = "" }, "ecosystem", levelWarning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := validAnalysis()
+			tt.mutate(a)
+			issues := ValidateAnalysis(p, a)
+			for _, is := range issues {
+				if is.Field == tt.field && is.Level == tt.level {
+					return
+				}
+			}
+			t.Errorf("want %s %s, got %+v", tt.level, tt.field, issues)
+		})
+	}
+}
+
+func TestAnalyzeProject_RepairsInvalidOutput(t *testing.T) {
+	bad := validAnalysis()
+	bad.Summary = "A Go port of LangChain"
+	good := validAnalysis()
+
+	var calls atomic.Int32
+	var repairReq openai.ChatCompletionRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := io.ReadAll(r.Body)
+		reply := bad
+		if calls.Add(1) == 2 {
+			_ = json.Unmarshal(body, &repairReq)
+			reply = good
+		}
+		out, _ := json.Marshal(reply)
+		_, _ = w.Write([]byte(chatResponse(string(out))))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(config.LLMConfig{Provider: "ollama", BaseURL: srv.URL, RepairMax: 1}, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	a, err := c.AnalyzeProject(context.Background(), &datastore.Project{FullName: "tmc/langchaingo"}, testPrompt())
+	if err != nil {
+		t.Fatalf("AnalyzeProject: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("calls = %d, want 2", calls.Load())
+	}
+	if a.Summary != good.Summary {
+		t.Errorf("Summary = %q, want repaired", a.Summary)
+	}
+	if a.Validation == nil || a.Validation.Repairs != 1 || len(a.Validation.Issues) != 0 {
+		t.Errorf("Validation = %+v", a.Validation)
+	}
+	if a.TokenUsage == nil || *a.TokenUsage != 20 {
+		t.Errorf("TokenUsage = %v, want both calls counted", a.TokenUsage)
+	}
+
+	msgs := repairReq.Messages
+	if len(msgs) != 4 || msgs[2].Role != openai.ChatMessageRoleAssistant || !strings.Contains(msgs[3].Content, "summary") {
+		t.Errorf("repair messages = %+v", msgs)
+	}
+}
+
+func TestAnalyzeProject_RecordsUnrepairedIssues(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(chatResponse(`{"summary":"English only"}`)))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(config.LLMConfig{Provider: "ollama", BaseURL: srv.URL}, testLogger())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	a, err := c.AnalyzeProject(context.Background(), &datastore.Project{FullName: "a/b"}, testPrompt())
+	if err != nil {
+		t.Fatalf("AnalyzeProject: %v", err)
+	}
+	if a.Status != "draft" || a.Validation == nil || countErrors(a.Validation.Issues) == 0 {
+		t.Errorf("want draft with recorded errors, got %+v", a.Validation)
+	}
+}
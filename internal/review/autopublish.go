@@ -0,0 +1,130 @@
+package review
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// AutoReviewer is the reviewer recorded for drafts the auto-publish
+// policy publishes. Once a person approves such an analysis again it is
+// no longer listed for spot-checking.
+const AutoReviewer = "auto-publish"
+
+// Policy decides which freshly generated drafts are low-risk enough to be
+// published without waiting for a reviewer.
+type Policy struct {
+	MaxRank          int // 0 = any rank
+	AllowWarnings    bool
+	ReplacePublished bool
+	Filter           *Filter // nil = no extra conditions
+}
+
+// NewPolicy builds the policy from review.auto_publish.
+func NewPolicy(cfg config.AutoPublishConfig) (*Policy, error) {
+	p := &Policy{
+		MaxRank:          cfg.MaxRank,
+		AllowWarnings:    cfg.AllowWarnings,
+		ReplacePublished: cfg.ReplacePublished,
+	}
+	if cfg.Filter != "" {
+		f, err := ParseFilter(cfg.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("review.auto_publish.filter: %w", err)
+		}
+		p.Filter = f
+	}
+	return p, nil
+}
+
+// Check reports whether the item's draft may be published automatically,
+// and if not, the first condition it failed.
+func (pol *Policy) Check(it Item, now time.Time) (bool, string) {
+	p, a := it.Project, it.Analysis()
+	switch {
+	case a == nil || a.Status != "draft":
+		return false, "没有待审核草稿"
+	case a.ReviewFeedback != "":
+		return false, "按审核意见重新生成，需要人工确认"
+	}
+
+	switch state := ValidationState(a.Validation); {
+	case state == ValidationError:
+		return false, "校验有错误"
+	case state == ValidationWarning && !pol.AllowWarnings:
+		return false, "校验有警告"
+	}
+	for _, c := range a.Comparison {
+		if c.Status != datastore.ComparisonTracked && c.Status != datastore.ComparisonGitHub {
+			return false, fmt.Sprintf("对比项目 %s 未解析", c.Project)
+		}
+	}
+	if pol.MaxRank > 0 && (p.Rank == nil || *p.Rank > pol.MaxRank) {
+		return false, fmt.Sprintf("排名不在前 %d", pol.MaxRank)
+	}
+	if live := p.PublishedAnalysis(it.Locale); live != nil && live != a && !pol.ReplacePublished {
+		return false, "已有发布版本"
+	}
+	if pol.Filter != nil && !pol.Filter.Match(it, now) {
+		return false, "不满足过滤条件 " + pol.Filter.String()
+	}
+	return true, ""
+}
+
+// AutoPublish publishes the drafts among items that pass the policy,
+// marks them auto-published and logs each decision as AutoReviewer. The
+// rest stay drafts. It returns the items published.
+func AutoPublish(store *datastore.Store, pol *Policy, items []Item, now time.Time, log *zap.Logger) ([]Item, error) {
+	var published []Item
+	for _, it := range items {
+		ok, why := pol.Check(it, now)
+		if !ok {
+			log.Debug("未自动发布，保留草稿",
+				zap.String("project", it.Project.FullName),
+				zap.String("locale", it.Locale),
+				zap.String("reason", why),
+			)
+			continue
+		}
+
+		// SetAnalysisStatus saves the pending draft, so mark it first, and
+		// unmark it again if it was not published.
+		draft := it.Analysis()
+		draft.AutoPublished = true
+		rv := datastore.Review{Reviewer: AutoReviewer, Reason: "自动发布策略", Time: now}
+		a, _, err := store.SetAnalysisStatus(it.Project, it.Locale, "published", rv)
+		if err != nil {
+			draft.AutoPublished = false
+			return published, fmt.Errorf("auto-publishing %s: %w", it.Project.FullName, err)
+		}
+		published = append(published, it)
+		log.Info("分析已自动发布",
+			zap.String("project", it.Project.FullName),
+			zap.String("locale", it.Locale),
+			zap.String("version", a.Version),
+		)
+	}
+	return published, nil
+}
+
+// AutoPublished lists the published analyses the policy published that
+// no reviewer has confirmed or taken down since, in project order.
+func AutoPublished(projects []*datastore.Project, locale string) []Item {
+	var items []Item
+	for _, p := range projects {
+		locales := p.Locales()
+		if locale != "" {
+			locales = []string{datastore.NormalizeLocale(locale)}
+		}
+		for _, l := range locales {
+			if a := p.PublishedAnalysis(l); a != nil && a.AutoPublished && a.ReviewedBy == AutoReviewer {
+				items = append(items, Item{Project: p, Locale: l})
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,120 @@
+package review
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/config"
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	rank := func(n int) *int { return &n }
+	resolved := []datastore.ComparisonEntry{{Project: "x", Status: datastore.ComparisonGitHub}}
+	warning := &datastore.Validation{Issues: []datastore.ValidationIssue{{Field: "summary", Level: "warning"}}}
+
+	tests := []struct {
+		name   string
+		cfg    config.AutoPublishConfig
+		edit   func(p *datastore.Project, a *datastore.Analysis)
+		wantOK bool
+	}{
+		{"passes", config.AutoPublishConfig{MaxRank: 50}, func(p *datastore.Project, a *datastore.Analysis) {}, true},
+		{"rank too low", config.AutoPublishConfig{MaxRank: 5}, func(p *datastore.Project, a *datastore.Analysis) {}, false},
+		{"unranked", config.AutoPublishConfig{MaxRank: 50}, func(p *datastore.Project, a *datastore.Analysis) { p.Rank = nil }, false},
+		{"any rank", config.AutoPublishConfig{}, func(p *datastore.Project, a *datastore.Analysis) { p.Rank = nil }, true},
+		{"warning", config.AutoPublishConfig{}, func(p *datastore.Project, a *datastore.Analysis) { a.Validation = warning }, false},
+		{"warning allowed", config.AutoPublishConfig{AllowWarnings: true}, func(p *datastore.Project, a *datastore.Analysis) { a.Validation = warning }, true},
+		{"unresolved comparison", config.AutoPublishConfig{}, func(p *datastore.Project, a *datastore.Analysis) {
+			a.Comparison = append(a.Comparison, datastore.ComparisonEntry{Project: "y", Status: datastore.ComparisonSuspicious})
+		}, false},
+		{"regenerated from feedback", config.AutoPublishConfig{}, func(p *datastore.Project, a *datastore.Analysis) { a.ReviewFeedback = "不准确" }, false},
+		{"filter", config.AutoPublishConfig{Filter: "category=rag"}, func(p *datastore.Project, a *datastore.Analysis) {}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &datastore.Project{ID: "o__a", FullName: "o/a", Rank: rank(10)}
+			a := &datastore.Analysis{Status: "draft", Comparison: resolved}
+			p.AttachAnalysis(a)
+			tt.edit(p, a)
+
+			pol, err := NewPolicy(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewPolicy: %v", err)
+			}
+			ok, why := pol.Check(Item{Project: p, Locale: "zh"}, testNow)
+			if ok != tt.wantOK {
+				t.Errorf("Check = %v (%s), want %v", ok, why, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAutoPublish(t *testing.T) {
+	store, items := newStore(t, "a", "b")
+	// b already has a published version; its new draft must wait.
+	b := items[1].Project
+	b.Analysis.Status = "published"
+	b.AttachAnalysis(&datastore.Analysis{Status: "draft", Summary: "b 的新摘要", GeneratedAt: testNow})
+	if err := store.SaveProject(b); err != nil {
+		t.Fatalf("SaveProject: %v", err)
+	}
+
+	pol, _ := NewPolicy(config.AutoPublishConfig{})
+	published, err := AutoPublish(store, pol, reload(t, store), testNow, zap.NewNop())
+	if err != nil {
+		t.Fatalf("AutoPublish: %v", err)
+	}
+	if len(published) != 1 || published[0].Project.ID != "o__a" {
+		t.Fatalf("published = %v, want only o__a", published)
+	}
+
+	p, _ := store.LoadProject("o__a")
+	if a := p.Analysis; a.Status != "published" || !a.AutoPublished || a.ReviewedBy != AutoReviewer {
+		t.Errorf("o__a = %s auto=%v by %q", a.Status, a.AutoPublished, a.ReviewedBy)
+	}
+	if r, _ := store.LatestReview("o__a", "zh"); r == nil || r.Reviewer != AutoReviewer || r.Action != datastore.ReviewPublish {
+		t.Errorf("review log = %+v", r)
+	}
+	if got := status(t, store, "o__b"); got != "published" {
+		t.Errorf("b live = %s, want the old published version", got)
+	}
+
+	projects, _ := store.ListProjects()
+	if got := AutoPublished(projects, ""); len(got) != 1 || got[0].Project.ID != "o__a" {
+		t.Fatalf("AutoPublished = %v, want o__a", got)
+	}
+
+	// A reviewer confirming it takes it off the spot-check list.
+	p = projects[0]
+	if _, _, err := store.SetAnalysisStatus(p, "zh", "published", datastore.Review{Reviewer: "alice", Time: testNow}); err != nil {
+		t.Fatalf("SetAnalysisStatus: %v", err)
+	}
+	if got := AutoPublished(projects, ""); len(got) != 0 {
+		t.Errorf("AutoPublished after confirmation = %v, want none", got)
+	}
+}
+
+func TestAutoPublish_FailureLeavesDraftUnmarked(t *testing.T) {
+	store, items := newStore(t, "a")
+	draft := items[0].Analysis()
+	// A directory in place of the version file makes saving it fail.
+	path := filepath.Join(store.DataDir(), "analyses", "o__a", draft.Version+".json")
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(path, "blocked"), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+
+	pol, _ := NewPolicy(config.AutoPublishConfig{})
+	if _, err := AutoPublish(store, pol, items, testNow, zap.NewNop()); err == nil {
+		t.Fatal("AutoPublish should fail when the version cannot be saved")
+	}
+	if draft.AutoPublished {
+		t.Error("a draft that was not published should not stay marked auto-published")
+	}
+}
@@ -0,0 +1,108 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// documentExt is the temp file extension, for editor syntax highlighting.
+const documentExt = ".yaml"
+
+// document is the part of an analysis a reviewer may change, in the
+// order shown in the edited YAML. The format is private to this file:
+// the Editor flow only marshals, parses and applies documents.
+type document struct {
+	Summary     string               `yaml:"summary"`
+	Positioning string               `yaml:"positioning"`
+	Features    []documentFeature    `yaml:"features"`
+	Advantages  string               `yaml:"advantages"`
+	TechStack   string               `yaml:"tech_stack"`
+	UseCases    string               `yaml:"use_cases"`
+	Comparison  []documentComparison `yaml:"comparison"`
+	Ecosystem   string               `yaml:"ecosystem"`
+}
+
+type documentFeature struct {
+	Name string `yaml:"name"`
+	Desc string `yaml:"desc"`
+}
+
+type documentComparison struct {
+	Project string `yaml:"project"`
+	Diff    string `yaml:"diff"`
+}
+
+// marshalDocument renders the editable fields of a as commented YAML.
+func marshalDocument(p *datastore.Project, a *datastore.Analysis) ([]byte, error) {
+	doc := document{
+		Summary:     a.Summary,
+		Positioning: a.Positioning,
+		Advantages:  a.Advantages,
+		TechStack:   a.TechStack,
+		UseCases:    a.UseCases,
+		Ecosystem:   a.Ecosystem,
+	}
+	for _, f := range a.Features {
+		doc.Features = append(doc.Features, documentFeature{f.Name, f.Desc})
+	}
+	for _, c := range a.Comparison {
+		doc.Comparison = append(doc.Comparison, documentComparison{c.Project, c.Diff})
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s 的分析（%s，版本 %s，状态 %s）\n", p.FullName, a.EffectiveLocale(), a.Version, a.Status)
+	buf.WriteString("# 修改后保存并退出；不保存直接退出则放弃。多行文本用 |，保存前会校验长度限制。\n")
+	buf.WriteString("# 改名的对比项目需要 tishi analysis resolve 重新解析。\n\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("encoding analysis: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding analysis: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseDocument reads an edited document.
+func parseDocument(data []byte) (document, error) {
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return document{}, err
+	}
+	return doc, nil
+}
+
+// applyDocument copies edited fields into a, keeping the resolution of
+// comparison entries whose project name is unchanged.
+func applyDocument(a *datastore.Analysis, doc document) {
+	resolved := make(map[string]datastore.ComparisonEntry, len(a.Comparison))
+	for _, c := range a.Comparison {
+		resolved[c.Project] = c
+	}
+
+	a.Summary = strings.TrimSpace(doc.Summary)
+	a.Positioning = strings.TrimSpace(doc.Positioning)
+	a.Advantages = strings.TrimSpace(doc.Advantages)
+	a.TechStack = strings.TrimSpace(doc.TechStack)
+	a.UseCases = strings.TrimSpace(doc.UseCases)
+	a.Ecosystem = strings.TrimSpace(doc.Ecosystem)
+
+	a.Features = nil
+	for _, f := range doc.Features {
+		a.Features = append(a.Features, datastore.Feature{Name: strings.TrimSpace(f.Name), Desc: strings.TrimSpace(f.Desc)})
+	}
+	a.Comparison = nil
+	for _, c := range doc.Comparison {
+		entry := datastore.ComparisonEntry{Project: strings.TrimSpace(c.Project), Diff: strings.TrimSpace(c.Diff)}
+		if old, ok := resolved[entry.Project]; ok {
+			entry.ProjectID, entry.Repo, entry.Status, entry.Note = old.ProjectID, old.Repo, old.Status, old.Note
+		}
+		a.Comparison = append(a.Comparison, entry)
+	}
+}
@@ -0,0 +1,22 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func TestApplyDocument_KeepsResolution(t *testing.T) {
+	a := &datastore.Analysis{Comparison: []datastore.ComparisonEntry{
+		{Project: "langchain", Diff: "旧", Repo: "langchain-ai/langchain", Status: datastore.ComparisonGitHub},
+		{Project: "foo", Diff: "x", Status: datastore.ComparisonUnresolved},
+	}}
+	applyDocument(a, document{Summary: "s", Comparison: []documentComparison{{"langchain", "新"}, {"bar", "y"}}})
+
+	if c := a.Comparison[0]; c.Diff != "新" || c.Repo != "langchain-ai/langchain" || c.Status != datastore.ComparisonGitHub {
+		t.Errorf("unchanged name lost resolution: %+v", c)
+	}
+	if c := a.Comparison[1]; c.Project != "bar" || c.Status != "" {
+		t.Errorf("renamed entry kept stale resolution: %+v", c)
+	}
+}
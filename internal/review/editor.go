@@ -0,0 +1,294 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/llm"
+)
+
+// AskFunc shows a question and returns the key the reviewer chose.
+type AskFunc func(question string) (rune, error)
+
+// Editor edits analyses as documents (see document.go) in an external
+// editor, validates the result and shows a field diff before saving.
+type Editor struct {
+	Command string    // shell command, e.g. vim or "code -w"
+	Out     io.Writer // diff, validation and prompts
+	Now     func() time.Time
+
+	// Wrap runs the editor process, e.g. leaving raw terminal mode for
+	// its duration. Nil runs it directly.
+	Wrap func(run func() error) error
+
+	run func(path string) error // replaced in tests
+}
+
+// DefaultEditor returns the reviewer's editor: $VISUAL, then $EDITOR,
+// then vi.
+func DefaultEditor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := os.Getenv(env); e != "" {
+			return e
+		}
+	}
+	return "vi"
+}
+
+// Edit lets the reviewer change a, the analysis of p. The edited document
+// is validated against the field limits and the changes are shown; a is
+// only modified once the reviewer confirms, and is then marked as human
+// edited with updated validation, keeping the comparison resolver's
+// findings. It reports whether a changed.
+func (e *Editor) Edit(p *datastore.Project, a *datastore.Analysis, ask AskFunc) (bool, error) {
+	original, err := marshalDocument(p, a)
+	if err != nil {
+		return false, err
+	}
+
+	text := original
+	for {
+		edited, err := e.open(text)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(bytes.TrimSpace(edited), bytes.TrimSpace(original)) {
+			fmt.Fprintln(e.Out, "未修改。")
+			return false, nil
+		}
+		text = edited
+
+		doc, err := parseDocument(edited)
+		if err != nil {
+			fmt.Fprintf(e.Out, "✗ 无法解析编辑结果：%v\n", err)
+			key, err := ask("[e]继续编辑 [n]放弃 > ")
+			if err != nil || key != 'e' {
+				return false, err
+			}
+			continue
+		}
+
+		candidate := *a
+		applyDocument(&candidate, doc)
+		changes := Diff(a, &candidate)
+		if len(changes) == 0 {
+			fmt.Fprintln(e.Out, "未修改。")
+			return false, nil
+		}
+		issues := append(llm.ValidateAnalysis(p, &candidate), comparisonIssues(a, &candidate)...)
+		writeDiff(e.Out, changes)
+		writeIssues(e.Out, issues)
+
+		key, err := ask("[y]保存 [e]继续编辑 [n]放弃 > ")
+		if err != nil {
+			return false, err
+		}
+		switch key {
+		case 'y':
+			now := e.now()
+			candidate.HumanEdited = true
+			candidate.EditedAt = &now
+			candidate.Validation = nil
+			repairs := 0
+			if a.Validation != nil {
+				repairs = a.Validation.Repairs
+			}
+			if len(issues) > 0 || repairs > 0 {
+				candidate.Validation = &datastore.Validation{Issues: issues, Repairs: repairs}
+			}
+			*a = candidate
+			return true, nil
+		case 'e':
+			continue
+		default:
+			fmt.Fprintln(e.Out, "已放弃修改。")
+			return false, nil
+		}
+	}
+}
+
+func (e *Editor) now() time.Time {
+	if e.Now != nil {
+		return e.Now()
+	}
+	return time.Now().UTC()
+}
+
+// open writes text to a temp file, runs the editor on it and returns the
+// saved content.
+func (e *Editor) open(text []byte) ([]byte, error) {
+	f, err := os.CreateTemp("", "tishi-review-*"+documentExt)
+	if err != nil {
+		return nil, fmt.Errorf("creating temp file: %w", err)
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	_, err = f.Write(text)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("writing temp file: %w", err)
+	}
+
+	run := e.run
+	if run == nil {
+		run = e.runCommand
+	}
+	wrap := e.Wrap
+	if wrap == nil {
+		wrap = func(run func() error) error { return run() }
+	}
+	if err := wrap(func() error { return run(path) }); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading edited file: %w", err)
+	}
+	return data, nil
+}
+
+func (e *Editor) runCommand(path string) error {
+	// Run through the shell so editors with arguments ("code -w") work.
+	cmd := exec.Command("sh", "-c", e.Command+` "$1"`, "sh", path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running editor %q: %w", e.Command, err)
+	}
+	return nil
+}
+
+// comparisonIssues carries the comparison resolver's warnings on old over
+// to edited, re-indexed, for entries whose project name is unchanged.
+// Renamed and added entries have not been resolved, which is a warning
+// too until tishi analysis resolve replaces them all.
+func comparisonIssues(old, edited *datastore.Analysis) []datastore.ValidationIssue {
+	field := func(i int) string { return fmt.Sprintf("comparison[%d].project", i) }
+	byProject := make(map[string][]datastore.ValidationIssue)
+	known := make(map[string]bool, len(old.Comparison))
+	for i, c := range old.Comparison {
+		known[c.Project] = true
+		if old.Validation == nil {
+			continue
+		}
+		for _, is := range old.Validation.Issues {
+			if is.Field == field(i) {
+				byProject[c.Project] = append(byProject[c.Project], is)
+			}
+		}
+	}
+
+	var issues []datastore.ValidationIssue
+	for j, c := range edited.Comparison {
+		if !known[c.Project] {
+			issues = append(issues, datastore.ValidationIssue{
+				Field:   field(j),
+				Level:   "warning",
+				Message: fmt.Sprintf("%s: 尚未解析，运行 tishi analysis resolve", c.Project),
+			})
+			continue
+		}
+		for _, is := range byProject[c.Project] {
+			is.Field = field(j)
+			issues = append(issues, is)
+		}
+	}
+	return issues
+}
+
+// Change is one edited analysis field. List fields hold one line per
+// feature or comparison entry.
+type Change struct {
+	Field string
+	Old   []string
+	New   []string
+}
+
+// Diff returns the fields that differ between two analyses, in document
+// order.
+func Diff(old, new *datastore.Analysis) []Change {
+	var changes []Change
+	add := func(field string, o, n []string) {
+		if !slices.Equal(o, n) {
+			changes = append(changes, Change{field, o, n})
+		}
+	}
+	text := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return []string{s}
+	}
+	features := func(fs []datastore.Feature) []string {
+		var out []string
+		for _, f := range fs {
+			out = append(out, f.Name+"："+f.Desc)
+		}
+		return out
+	}
+	comparison := func(cs []datastore.ComparisonEntry) []string {
+		var out []string
+		for _, c := range cs {
+			out = append(out, c.Project+"："+c.Diff)
+		}
+		return out
+	}
+
+	add("summary", text(old.Summary), text(new.Summary))
+	add("positioning", text(old.Positioning), text(new.Positioning))
+	add("features", features(old.Features), features(new.Features))
+	add("advantages", text(old.Advantages), text(new.Advantages))
+	add("tech_stack", text(old.TechStack), text(new.TechStack))
+	add("use_cases", text(old.UseCases), text(new.UseCases))
+	add("comparison", comparison(old.Comparison), comparison(new.Comparison))
+	add("ecosystem", text(old.Ecosystem), text(new.Ecosystem))
+	return changes
+}
+
+// writeDiff prints each changed field with removed lines as - and added
+// lines as +; list entries present on both sides are left out.
+func writeDiff(w io.Writer, changes []Change) {
+	fmt.Fprintln(w, "──── 修改 ────")
+	for _, c := range changes {
+		fmt.Fprintf(w, "~ %s\n", c.Field)
+		printed := false
+		for _, l := range c.Old {
+			if !slices.Contains(c.New, l) {
+				fmt.Fprintf(w, "  - %s\n", l)
+				printed = true
+			}
+		}
+		for _, l := range c.New {
+			if !slices.Contains(c.Old, l) {
+				fmt.Fprintf(w, "  + %s\n", l)
+				printed = true
+			}
+		}
+		if !printed {
+			fmt.Fprintln(w, "  （仅顺序变化）")
+		}
+	}
+}
+
+func writeIssues(w io.Writer, issues []datastore.ValidationIssue) {
+	if len(issues) == 0 {
+		fmt.Fprintln(w, "✓ 校验通过")
+		return
+	}
+	fmt.Fprintln(w, "──── 校验 ────")
+	for _, is := range issues {
+		mark := "!"
+		if is.Level == "error" {
+			mark = "✗"
+		}
+		fmt.Fprintf(w, "  %s %s: %s\n", mark, is.Field, is.Message)
+	}
+}
@@ -0,0 +1,178 @@
+package review
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// rewrite returns an editor run hook that replaces pairs[2i] with
+// pairs[2i+1] in the edited file on the i-th run.
+func rewrite(t *testing.T, pairs ...string) func(path string) error {
+	t.Helper()
+	runs := 0
+	return func(path string) error {
+		i := 2 * runs
+		runs++
+		if i+1 >= len(pairs) {
+			t.Fatalf("editor opened %d times, want %d", runs, len(pairs)/2)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(strings.Replace(string(data), pairs[i], pairs[i+1], 1)), 0o644)
+	}
+}
+
+// answers returns an AskFunc replying with keys in order.
+func answers(t *testing.T, keys string) AskFunc {
+	rs := []rune(keys)
+	return func(string) (rune, error) {
+		if len(rs) == 0 {
+			t.Fatal("unexpected question")
+		}
+		r := rs[0]
+		rs = rs[1:]
+		return r, nil
+	}
+}
+
+func editFixture() (*datastore.Project, *datastore.Analysis) {
+	a := &datastore.Analysis{
+		Version:     "20261019T090000Z",
+		Status:      "draft",
+		Summary:     "Go 语言的 LLM 框架",
+		Positioning: "面向 Go 开发者。\n第二段说明。",
+		Features:    []datastore.Feature{{Name: "链式调用", Desc: "组合模型与工具"}},
+		Advantages:  "接口简洁",
+		TechStack:   "Go",
+		UseCases:    "构建 LLM 应用",
+		Ecosystem:   "兼容 OpenAI 接口",
+		Comparison: []datastore.ComparisonEntry{
+			{Project: "langchain", Diff: "Python 实现", Repo: "langchain-ai/langchain", Status: datastore.ComparisonGitHub},
+		},
+		Validation: &datastore.Validation{Repairs: 1},
+	}
+	p := &datastore.Project{ID: "o__go", FullName: "o/go"}
+	p.AttachAnalysis(a)
+	return p, a
+}
+
+func newEditor(out *bytes.Buffer, run func(string) error) *Editor {
+	return &Editor{Out: out, Now: func() time.Time { return testNow }, run: run}
+}
+
+func TestEditor_Save(t *testing.T) {
+	p, a := editFixture()
+	var out bytes.Buffer
+	e := newEditor(&out, rewrite(t, "Go 语言的 LLM 框架", "Go 语言的 LLM 应用开发框架"))
+
+	changed, err := e.Edit(p, a, answers(t, "y"))
+	if err != nil || !changed {
+		t.Fatalf("Edit = %v, %v; want saved", changed, err)
+	}
+	if a.Summary != "Go 语言的 LLM 应用开发框架" || !a.HumanEdited || a.EditedAt == nil || !a.EditedAt.Equal(testNow) {
+		t.Errorf("analysis = %q edited=%v at=%v", a.Summary, a.HumanEdited, a.EditedAt)
+	}
+	if a.Positioning != "面向 Go 开发者。\n第二段说明。" {
+		t.Errorf("multi-line positioning not round-tripped: %q", a.Positioning)
+	}
+	if c := a.Comparison[0]; c.Repo != "langchain-ai/langchain" || c.Status != datastore.ComparisonGitHub {
+		t.Errorf("comparison lost resolution: %+v", c)
+	}
+	if a.Validation == nil || a.Validation.Repairs != 1 || len(a.Validation.Issues) != 0 {
+		t.Errorf("validation = %+v, want repairs kept and no issues", a.Validation)
+	}
+	for _, want := range []string{"~ summary", "- Go 语言的 LLM 框架", "+ Go 语言的 LLM 应用开发框架", "✓ 校验通过"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "~ positioning") {
+		t.Errorf("unchanged field in diff:\n%s", out.String())
+	}
+}
+
+func TestEditor_KeepsResolverIssues(t *testing.T) {
+	p, a := editFixture()
+	a.Comparison = append(a.Comparison, datastore.ComparisonEntry{Project: "foo", Diff: "未知项目", Status: datastore.ComparisonUnresolved, Note: "GitHub 上找不到"})
+	a.Validation.Issues = []datastore.ValidationIssue{{Field: "comparison[1].project", Level: "warning", Message: "foo: GitHub 上找不到"}}
+	var out bytes.Buffer
+	// langchain is dropped, so foo's warning moves to index 0, and the
+	// added llama-index is unresolved.
+	e := newEditor(&out, rewrite(t, "  - project: langchain\n    diff: Python 实现\n", "",
+		"diff: 未知项目\n", "diff: 未知项目\n  - project: llama-index\n    diff: 检索增强\n"))
+
+	// The first edit drops langchain; e reopens it to add llama-index.
+	if _, err := e.Edit(p, a, answers(t, "ey")); err != nil {
+		t.Fatalf("Edit: %v", err)
+	}
+	if len(a.Comparison) != 2 || a.Comparison[0].Project != "foo" || a.Comparison[1].Project != "llama-index" {
+		t.Fatalf("comparison = %+v", a.Comparison)
+	}
+	want := []datastore.ValidationIssue{
+		{Field: "comparison[0].project", Level: "warning", Message: "foo: GitHub 上找不到"},
+		{Field: "comparison[1].project", Level: "warning", Message: "llama-index: 尚未解析，运行 tishi analysis resolve"},
+	}
+	if a.Validation == nil || !slices.Equal(a.Validation.Issues, want) {
+		t.Errorf("validation = %+v, want %+v", a.Validation, want)
+	}
+	if got := ValidationState(a.Validation); got != ValidationWarning {
+		t.Errorf("ValidationState = %q, want warning", got)
+	}
+}
+
+func TestEditor_ParseErrorThenFix(t *testing.T) {
+	p, a := editFixture()
+	var out bytes.Buffer
+	e := newEditor(&out, rewrite(t,
+		"tech_stack: Go", "tech_stack: [Go",
+		"tech_stack: [Go", "tech_stack: Go, Python",
+	))
+
+	changed, err := e.Edit(p, a, answers(t, "ey"))
+	if err != nil || !changed {
+		t.Fatalf("Edit = %v, %v; want saved after re-edit", changed, err)
+	}
+	if a.TechStack != "Go, Python" {
+		t.Errorf("tech_stack = %q", a.TechStack)
+	}
+	if !strings.Contains(out.String(), "无法解析编辑结果") {
+		t.Errorf("parse error not reported:\n%s", out.String())
+	}
+}
+
+func TestEditor_ValidationShownAndDiscarded(t *testing.T) {
+	p, a := editFixture()
+	var out bytes.Buffer
+	long := strings.Repeat("很长的摘要", 20)
+	e := newEditor(&out, rewrite(t, "Go 语言的 LLM 框架", long))
+
+	changed, err := e.Edit(p, a, answers(t, "n"))
+	if err != nil || changed {
+		t.Fatalf("Edit = %v, %v; want discarded", changed, err)
+	}
+	if a.Summary != "Go 语言的 LLM 框架" || a.HumanEdited {
+		t.Errorf("discarded edit changed the analysis: %q edited=%v", a.Summary, a.HumanEdited)
+	}
+	if !strings.Contains(out.String(), "✗ summary") {
+		t.Errorf("length error not shown:\n%s", out.String())
+	}
+}
+
+func TestEditor_Unchanged(t *testing.T) {
+	p, a := editFixture()
+	var out bytes.Buffer
+	e := newEditor(&out, rewrite(t, "not in the document", ""))
+
+	changed, err := e.Edit(p, a, answers(t, ""))
+	if err != nil || changed {
+		t.Fatalf("Edit = %v, %v; want unchanged", changed, err)
+	}
+}
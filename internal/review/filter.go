@@ -0,0 +1,255 @@
+package review
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+	"github.com/zbb88888/tishi/internal/timespan"
+)
+
+// Filter selects drafts for bulk review. An expression is a list of terms
+// separated by spaces, all of which must match:
+//
+//	category=rag,agent   primary category is one of the slugs
+//	model=deepseek-chat  model that generated the draft
+//	language!=python     GitHub primary language is not Python
+//	score>=60 stars<500  numeric comparisons (= != < <= > >=)
+//	age<30d              repository created under 30 days ago (h, d, w)
+//	archived, !archived  archived on GitHub or not (also archived=false)
+//	validation=ok        no findings; warning = warnings only; error = errors
+//
+// String values compare case-insensitively and = / != accept a comma list.
+type Filter struct {
+	expr  string
+	terms []term
+}
+
+type term struct {
+	field  string
+	op     string
+	values []string      // string, bool and validation fields
+	num    float64       // number fields
+	dur    time.Duration // age
+}
+
+// Field kinds.
+const (
+	kindString = iota
+	kindNumber
+	kindAge
+	kindBool
+	kindValidation
+)
+
+var filterFields = map[string]int{
+	"category":   kindString,
+	"model":      kindString,
+	"language":   kindString,
+	"score":      kindNumber,
+	"stars":      kindNumber,
+	"age":        kindAge,
+	"archived":   kindBool,
+	"validation": kindValidation,
+}
+
+// Validation states a draft can be filtered on.
+const (
+	ValidationOK      = "ok"
+	ValidationWarning = "warning"
+	ValidationError   = "error"
+)
+
+// ParseFilter parses a filter expression.
+func ParseFilter(expr string) (*Filter, error) {
+	f := &Filter{expr: strings.TrimSpace(expr)}
+	for _, s := range strings.Fields(expr) {
+		t, err := parseTerm(s)
+		if err != nil {
+			return nil, err
+		}
+		f.terms = append(f.terms, t)
+	}
+	if len(f.terms) == 0 {
+		return nil, fmt.Errorf("过滤条件为空")
+	}
+	return f, nil
+}
+
+// String returns the expression as given.
+func (f *Filter) String() string {
+	return f.expr
+}
+
+func parseTerm(s string) (term, error) {
+	// Bare boolean fields: archived, !archived.
+	if name := strings.TrimPrefix(s, "!"); filterFields[name] == kindBool {
+		op := "="
+		if name != s {
+			op = "!="
+		}
+		return term{field: name, op: op, values: []string{"true"}}, nil
+	}
+
+	i := strings.IndexAny(s, "=!<>")
+	if i <= 0 {
+		return term{}, fmt.Errorf("无法解析过滤条件 %q，应为 字段 运算符 值，如 score>=60", s)
+	}
+	field := strings.ToLower(s[:i])
+	op := s[i : i+1]
+	if i+1 < len(s) && s[i+1] == '=' {
+		op += "="
+	}
+	if op == "!" {
+		return term{}, fmt.Errorf("无法解析过滤条件 %q：! 后需要 =", s)
+	}
+	raw := s[i+len(op):]
+	if raw == "" {
+		return term{}, fmt.Errorf("过滤条件 %q 缺少值", s)
+	}
+
+	kind, ok := filterFields[field]
+	if !ok {
+		return term{}, fmt.Errorf("未知的过滤字段 %q，可用: category, model, language, score, stars, age, archived, validation", field)
+	}
+	t := term{field: field, op: op}
+	equality := op == "=" || op == "!="
+
+	switch kind {
+	case kindNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return term{}, fmt.Errorf("过滤条件 %q 的值不是数字", s)
+		}
+		t.num = n
+		return t, nil
+	case kindAge:
+		d, err := timespan.Parse(raw)
+		if err != nil {
+			return term{}, fmt.Errorf("过滤条件 %q: %w", s, err)
+		}
+		t.dur = d
+		return t, nil
+	}
+
+	if !equality {
+		return term{}, fmt.Errorf("字段 %s 只支持 = 和 !=", field)
+	}
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			t.values = append(t.values, v)
+		}
+	}
+	switch kind {
+	case kindBool:
+		if len(t.values) != 1 || (t.values[0] != "true" && t.values[0] != "false") {
+			return term{}, fmt.Errorf("字段 %s 的值应为 true 或 false", field)
+		}
+	case kindValidation:
+		for _, v := range t.values {
+			if v != ValidationOK && v != ValidationWarning && v != ValidationError {
+				return term{}, fmt.Errorf("validation 的值应为 ok、warning 或 error，不是 %q", v)
+			}
+		}
+	}
+	return t, nil
+}
+
+// Match reports whether every term matches the item's project and draft.
+// Projects without a creation date never match an age term.
+func (f *Filter) Match(it Item, now time.Time) bool {
+	for _, t := range f.terms {
+		if !t.match(it, now) {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the items that match, in order.
+func (f *Filter) Select(items []Item, now time.Time) []Item {
+	var out []Item
+	for _, it := range items {
+		if it.Analysis() != nil && f.Match(it, now) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+func (t term) match(it Item, now time.Time) bool {
+	p, a := it.Project, it.Analysis()
+	switch t.field {
+	case "category":
+		return t.matchString(deref(p.Category))
+	case "model":
+		return t.matchString(a.Model)
+	case "language":
+		return t.matchString(deref(p.Language))
+	case "score":
+		return compare(p.Score, t.op, t.num)
+	case "stars":
+		return compare(float64(p.Stars), t.op, t.num)
+	case "age":
+		if p.CreatedAtGH == nil {
+			return false
+		}
+		return compare(float64(now.Sub(*p.CreatedAtGH)), t.op, float64(t.dur))
+	case "archived":
+		return t.matchString(strconv.FormatBool(p.IsArchived))
+	case "validation":
+		return t.matchString(ValidationState(a.Validation))
+	}
+	return false
+}
+
+func (t term) matchString(v string) bool {
+	in := slices.Contains(t.values, strings.ToLower(v))
+	if t.op == "!=" {
+		return !in
+	}
+	return in
+}
+
+func compare(v float64, op string, want float64) bool {
+	switch op {
+	case "=":
+		return v == want
+	case "!=":
+		return v != want
+	case "<":
+		return v < want
+	case "<=":
+		return v <= want
+	case ">":
+		return v > want
+	case ">=":
+		return v >= want
+	}
+	return false
+}
+
+// ValidationState summarizes validation findings as ok, warning or error.
+func ValidationState(v *datastore.Validation) string {
+	state := ValidationOK
+	if v == nil {
+		return state
+	}
+	for _, is := range v.Issues {
+		if is.Level == ValidationError {
+			return ValidationError
+		}
+		state = ValidationWarning
+	}
+	return state
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
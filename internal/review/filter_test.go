@@ -0,0 +1,86 @@
+package review
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+func filterItems() []Item {
+	str := func(s string) *string { return &s }
+	created := testNow.Add(-10 * 24 * time.Hour)
+	projects := []*datastore.Project{
+		{ID: "o__rag", FullName: "o/rag", Category: str("rag"), Language: str("Python"), Score: 72, Stars: 300, CreatedAtGH: &created},
+		{ID: "o__agent", FullName: "o/agent", Category: str("agent"), Language: str("Go"), Score: 55, Stars: 900},
+		{ID: "o__old", FullName: "o/old", Category: str("rag"), Score: 40, IsArchived: true},
+	}
+	models := []string{"deepseek-chat", "deepseek-chat", "qwen-plus"}
+	validations := []*datastore.Validation{
+		nil,
+		{Issues: []datastore.ValidationIssue{{Field: "summary", Level: "warning"}}},
+		{Issues: []datastore.ValidationIssue{{Field: "summary", Level: "warning"}, {Field: "features", Level: "error"}}},
+	}
+	var items []Item
+	for i, p := range projects {
+		p.AttachAnalysis(&datastore.Analysis{Status: "draft", Model: models[i], Validation: validations[i]})
+		items = append(items, Item{Project: p, Locale: "zh"})
+	}
+	return items
+}
+
+func TestFilter_Select(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"category=rag", "o__rag,o__old"},
+		{"category=RAG,agent model=deepseek-chat", "o__rag,o__agent"},
+		{"model=deepseek-chat validation=ok category=rag", "o__rag"},
+		{"validation!=error", "o__rag,o__agent"},
+		{"validation=warning", "o__agent"},
+		{"score>=55 score<72", "o__agent"},
+		{"stars>300", "o__agent"},
+		{"language!=python", "o__agent,o__old"},
+		{"age<30d", "o__rag"},
+		{"age>1w", "o__rag"},
+		{"archived", "o__old"},
+		{"!archived", "o__rag,o__agent"},
+		{"archived=false category=rag", "o__rag"},
+	}
+	items := filterItems()
+	for _, tt := range tests {
+		f, err := ParseFilter(tt.expr)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, it := range f.Select(items, testNow) {
+			got = append(got, it.Project.ID)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("%q selected %v, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseFilter_Errors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"stars",
+		"owner=o",
+		"score>=high",
+		"age<30",
+		"category>rag",
+		"archived=maybe",
+		"validation=fine",
+		"score=",
+		"model!deepseek",
+	} {
+		if _, err := ParseFilter(expr); err == nil {
+			t.Errorf("ParseFilter(%q) succeeded, want error", expr)
+		}
+	}
+}
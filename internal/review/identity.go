@@ -0,0 +1,28 @@
+package review
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+)
+
+// Reviewer returns the name recorded for review decisions: the configured
+// review.reviewer, else git's user.name, else the OS user.
+func Reviewer(configured string) string {
+	if name := strings.TrimSpace(configured); name != "" {
+		return name
+	}
+	if out, err := exec.Command("git", "config", "user.name").Output(); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			return name
+		}
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,42 @@
+// Package review implements the interactive review of LLM analyses: the
+// queue of pending drafts, a resumable session and a single-key terminal UI.
+package review
+
+import (
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// Item is one analysis awaiting review: a project's pending draft in a
+// locale.
+type Item struct {
+	Project *datastore.Project
+	Locale  string
+}
+
+// Analysis returns the draft under review, or nil once it was decided.
+func (it Item) Analysis() *datastore.Analysis {
+	return it.Project.PendingAnalysis(it.Locale)
+}
+
+// Key identifies the item across runs, e.g. owner__repo/zh.
+func (it Item) Key() string {
+	return it.Project.ID + "/" + it.Locale
+}
+
+// Pending lists the drafts awaiting review in project order. An empty
+// locale selects every locale a project has.
+func Pending(projects []*datastore.Project, locale string) []Item {
+	var items []Item
+	for _, p := range projects {
+		locales := p.Locales()
+		if locale != "" {
+			locales = []string{datastore.NormalizeLocale(locale)}
+		}
+		for _, l := range locales {
+			if p.PendingAnalysis(l) != nil {
+				items = append(items, Item{Project: p, Locale: l})
+			}
+		}
+	}
+	return items
+}
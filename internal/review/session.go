@@ -0,0 +1,124 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Session is the state of an interactive review kept between runs in
+// data/cache/review-session.json. Approved and rejected drafts drop out
+// of the queue on their own; the session remembers which drafts were
+// skipped so a resumed review starts with the ones not yet seen.
+type Session struct {
+	StartedAt time.Time         `json:"started_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Approved  int               `json:"approved"`
+	Rejected  int               `json:"rejected"`
+	Skipped   map[string]string `json:"skipped,omitempty"` // item key -> skipped draft version
+
+	path string
+}
+
+// SessionPath returns the session file under dataDir.
+func SessionPath(dataDir string) string {
+	return filepath.Join(dataDir, "cache", "review-session.json")
+}
+
+// LoadSession reads the session at path, or starts a new one at now when
+// there is none.
+func LoadSession(path string, now time.Time) (*Session, error) {
+	s := &Session{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.StartedAt, s.UpdatedAt = now, now
+			return s, nil
+		}
+		return nil, fmt.Errorf("reading review session: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing review session %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Resumed reports whether the session was loaded from an earlier run.
+func (s *Session) Resumed() bool {
+	return s.Approved+s.Rejected+len(s.Skipped) > 0
+}
+
+// Order puts drafts not seen in this session first and previously skipped
+// ones last, each group in queue order. A skipped draft that has since
+// been regenerated counts as unseen.
+func (s *Session) Order(items []Item) []Item {
+	var fresh, skipped []Item
+	for _, it := range items {
+		if s.skipped(it) {
+			skipped = append(skipped, it)
+		} else {
+			fresh = append(fresh, it)
+		}
+	}
+	return append(fresh, skipped...)
+}
+
+func (s *Session) skipped(it Item) bool {
+	v, ok := s.Skipped[it.Key()]
+	a := it.Analysis()
+	return ok && a != nil && a.Version == v
+}
+
+// skip records that it was passed over.
+func (s *Session) skip(it Item) {
+	if s.Skipped == nil {
+		s.Skipped = make(map[string]string)
+	}
+	if a := it.Analysis(); a != nil {
+		s.Skipped[it.Key()] = a.Version
+	}
+}
+
+// decided records an approval or rejection of it.
+func (s *Session) decided(it Item, status string) {
+	delete(s.Skipped, it.Key())
+	switch status {
+	case "published":
+		s.Approved++
+	case "rejected":
+		s.Rejected++
+	}
+}
+
+// Save writes the session atomically.
+func (s *Session) Save(now time.Time) error {
+	s.UpdatedAt = now
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating cache dir: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling review session: %w", err)
+	}
+	data = append(data, '\n')
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+	return nil
+}
+
+// Clear removes the session file once the queue is done.
+func (s *Session) Clear() error {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing review session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package review
+
+import (
+	"errors"
+	"os"
+)
+
+// RawMode is not supported on this platform; the review UI falls back to
+// line-buffered input where each key is followed by Enter.
+func RawMode(f *os.File) (restore func() error, err error) {
+	return nil, errors.New("当前平台不支持单键输入")
+}
@@ -0,0 +1,398 @@
+package review
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// Keys besides printable characters.
+const (
+	keyCtrlC = 0x03
+	keyCtrlD = 0x04
+	keyEsc   = 0x1b
+	keyLeft  = -1 // arrow keys, decoded from escape sequences
+	keyRight = -2
+)
+
+// defaultReadmeLines is how much of the cached README is shown per draft.
+const defaultReadmeLines = 20
+
+// UI pages through drafts one at a time and acts on single keys:
+// a approve, r reject, s skip, b back, e edit, q quit. It reads keys from
+// In, which is either a terminal in raw mode or line-buffered input where
+// each key is followed by Enter. Rejecting asks for an optional reason.
+type UI struct {
+	Store    *datastore.Store
+	Session  *Session
+	In       io.Reader
+	Out      io.Writer
+	Log      *zap.Logger
+	Reviewer string // recorded with every decision and edit
+
+	// Clear clears the screen before each draft; set for terminals.
+	Clear bool
+	// Raw reports that In is a terminal in raw mode, so typed reasons are
+	// echoed by the UI instead of the terminal.
+	Raw bool
+	// Editor edits the draft on the e key; nil disables it.
+	Editor *Editor
+	// ReadmeLines caps the README excerpt; 0 uses the default.
+	ReadmeLines int
+	// Now is the review time; nil uses the wall clock.
+	Now func() time.Time
+}
+
+func (u *UI) now() time.Time {
+	if u.Now != nil {
+		return u.Now()
+	}
+	return time.Now().UTC()
+}
+
+// Run reviews items until the queue is empty or the reviewer quits. The
+// session is saved after every decision, so an interrupted review resumes
+// where it stopped; it is cleared once nothing was left skipped.
+func (u *UI) Run(items []Item) error {
+	queue := u.Session.Order(items)
+	keys := bufio.NewReader(u.In)
+	msg := ""
+	if u.Session.Resumed() {
+		msg = fmt.Sprintf("继续上次的审核（已批准 %d，已拒绝 %d，跳过的草稿排在最后）",
+			u.Session.Approved, u.Session.Rejected)
+	}
+
+	for i := 0; i < len(queue); {
+		it := queue[i]
+		if it.Analysis() == nil {
+			queue = append(queue[:i], queue[i+1:]...)
+			continue
+		}
+		u.render(it, i+1, len(queue), msg)
+		msg = ""
+
+		key, err := readKey(keys)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return u.quit()
+			}
+			return fmt.Errorf("reading key: %w", err)
+		}
+
+		switch key {
+		case 'a', 'r':
+			status, reason := "published", ""
+			if key == 'r' {
+				status = "rejected"
+				fmt.Fprint(u.Out, "\n拒绝原因（可留空，回车确认，Esc 取消）> ")
+				reason, err = readLine(keys, u.Out, u.Raw)
+				if errors.Is(err, errCanceled) {
+					msg = "已取消拒绝"
+					continue
+				}
+				if err != nil && !errors.Is(err, io.EOF) {
+					return fmt.Errorf("reading reason: %w", err)
+				}
+			}
+			if err := u.decide(it, status, reason); err != nil {
+				msg = "✗ " + err.Error()
+				continue
+			}
+			queue = append(queue[:i], queue[i+1:]...)
+		case 's', ' ', keyRight:
+			u.Session.skip(it)
+			if err := u.Session.Save(u.now()); err != nil {
+				return err
+			}
+			i++
+		case 'b', keyLeft:
+			if i > 0 {
+				i--
+			} else {
+				msg = "已经是第一个草稿"
+			}
+		case 'e':
+			msg = u.edit(it, keys)
+		case 'q', keyCtrlC, keyCtrlD:
+			return u.quit()
+		default:
+			msg = "按键无效：a 批准 / r 拒绝 / s 跳过 / b 上一个 / e 编辑 / q 退出"
+		}
+	}
+
+	u.summary()
+	if len(u.Session.Skipped) == 0 {
+		return u.Session.Clear()
+	}
+	fmt.Fprintf(u.Out, "还有 %d 个跳过的草稿，下次运行 tishi review -i 时排在最后。\n", len(u.Session.Skipped))
+	return u.Session.Save(u.now())
+}
+
+func (u *UI) quit() error {
+	u.summary()
+	fmt.Fprintln(u.Out, "进度已保存，再次运行 tishi review -i 继续。")
+	return u.Session.Save(u.now())
+}
+
+func (u *UI) summary() {
+	fmt.Fprintf(u.Out, "\n本次审核：批准 %d，拒绝 %d，跳过 %d。\n",
+		u.Session.Approved, u.Session.Rejected, len(u.Session.Skipped))
+}
+
+// decide publishes or rejects the draft and records it in the session.
+func (u *UI) decide(it Item, status, reason string) error {
+	p := it.Project
+	a, oldStatus, err := u.Store.SetAnalysisStatus(p, it.Locale, status, u.review(reason))
+	if err != nil {
+		return err
+	}
+	u.Log.Info("分析状态已更新",
+		zap.String("project", p.FullName),
+		zap.String("locale", it.Locale),
+		zap.String("version", a.Version),
+		zap.String("from", oldStatus),
+		zap.String("to", status),
+		zap.String("reviewer", u.Reviewer),
+		zap.String("reason", reason),
+	)
+	u.Session.decided(it, status)
+	return u.Session.Save(u.now())
+}
+
+// edit runs the editor on the draft and saves it when changed. It
+// returns the status line to show.
+func (u *UI) edit(it Item, keys *bufio.Reader) string {
+	if u.Editor == nil {
+		return "未配置编辑器"
+	}
+	ask := func(question string) (rune, error) {
+		fmt.Fprint(u.Out, question)
+		key, err := readKey(keys)
+		fmt.Fprintln(u.Out)
+		return key, err
+	}
+	a := it.Analysis()
+	changed, err := u.Editor.Edit(it.Project, a, ask)
+	switch {
+	case err != nil:
+		return "✗ 编辑失败：" + err.Error()
+	case !changed:
+		return "未修改"
+	}
+
+	if err := u.Store.SaveEditedAnalysis(it.Project, a, u.review("")); err != nil {
+		return "✗ " + err.Error()
+	}
+	return "✓ 已保存修改"
+}
+
+func (u *UI) review(reason string) datastore.Review {
+	return datastore.Review{Reviewer: u.Reviewer, Reason: reason, Time: u.now()}
+}
+
+// render shows one draft: project facts, every analysis field,
+// validation findings and a README excerpt.
+func (u *UI) render(it Item, pos, total int, msg string) {
+	w := u.Out
+	p, a := it.Project, it.Analysis()
+	if u.Clear {
+		fmt.Fprint(w, "\x1b[H\x1b[2J")
+	}
+
+	tag := "draft"
+	if a == p.DraftFor(it.Locale) {
+		tag = "draft*" // a published version is still live
+	}
+	if a.HumanEdited {
+		tag += " 已人工修改"
+	}
+	fmt.Fprintf(w, "[%d/%d] %s  [%s] %s  ★ %d  %s", pos, total, p.FullName, it.Locale, tag, p.Stars, a.Model)
+	if a.PromptName != "" {
+		fmt.Fprintf(w, "  prompt %s/%s", a.PromptName, a.PromptVersion)
+	}
+	fmt.Fprintln(w)
+	if p.Description != nil && *p.Description != "" {
+		fmt.Fprintf(w, "描述：%s\n", *p.Description)
+	}
+	if len(p.Topics) > 0 {
+		fmt.Fprintf(w, "Topics：%s\n", strings.Join(p.Topics, ", "))
+	}
+
+	section(w, "分析")
+	if a.ReviewFeedback != "" {
+		fmt.Fprintf(w, "按审核意见重新生成：%s\n", a.ReviewFeedback)
+	}
+	field(w, "摘要", a.Summary)
+	field(w, "定位", a.Positioning)
+	if len(a.Features) > 0 {
+		fmt.Fprintln(w, "功能：")
+		for _, f := range a.Features {
+			fmt.Fprintf(w, "  - %s：%s\n", f.Name, f.Desc)
+		}
+	}
+	field(w, "优势", a.Advantages)
+	field(w, "技术栈", a.TechStack)
+	field(w, "场景", a.UseCases)
+	if len(a.Comparison) > 0 {
+		fmt.Fprintln(w, "对比：")
+		for _, c := range a.Comparison {
+			status := ""
+			if c.Status != "" {
+				status = " (" + c.Status + ")"
+			}
+			fmt.Fprintf(w, "  - %s%s：%s\n", c.Project, status, c.Diff)
+		}
+	}
+	field(w, "生态", a.Ecosystem)
+
+	if v := a.Validation; v != nil && (len(v.Issues) > 0 || v.Repairs > 0) {
+		section(w, "校验")
+		for _, is := range v.Issues {
+			mark := "!"
+			if is.Level == "error" {
+				mark = "✗"
+			}
+			fmt.Fprintf(w, "  %s %s: %s\n", mark, is.Field, is.Message)
+		}
+		if v.Repairs > 0 {
+			fmt.Fprintf(w, "  (已自动修复 %d 次)\n", v.Repairs)
+		}
+	}
+
+	section(w, "README 摘录")
+	fmt.Fprintln(w, u.readmeExcerpt(p.ID))
+
+	fmt.Fprintln(w, strings.Repeat("─", 60))
+	if msg != "" {
+		fmt.Fprintln(w, msg)
+	}
+	keys := "[a]批准 [r]拒绝 [s]跳过 [b]上一个 [q]退出"
+	if u.Editor != nil {
+		keys = "[a]批准 [r]拒绝 [s]跳过 [b]上一个 [e]编辑 [q]退出"
+	}
+	fmt.Fprintf(w, "%s > ", keys)
+}
+
+// readmeExcerpt returns the first lines of the README last sent to the LLM.
+func (u *UI) readmeExcerpt(projectID string) string {
+	data, err := os.ReadFile(u.Store.READMECachePath(projectID))
+	if err != nil {
+		return "（没有 README 缓存，运行 tishi analyze 后生成）"
+	}
+	limit := u.ReadmeLines
+	if limit <= 0 {
+		limit = defaultReadmeLines
+	}
+	var lines []string
+	for _, l := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if len(lines) == limit {
+			lines = append(lines, "……")
+			break
+		}
+		lines = append(lines, l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func section(w io.Writer, title string) {
+	fmt.Fprintf(w, "──── %s ────\n", title)
+}
+
+func field(w io.Writer, label, value string) {
+	if strings.TrimSpace(value) == "" {
+		value = "（空）"
+	}
+	fmt.Fprintf(w, "%s：%s\n", label, value)
+}
+
+// errCanceled is returned by readLine when the reviewer presses Esc or
+// Ctrl-C.
+var errCanceled = errors.New("canceled")
+
+// readLine reads a line of text after a key. Line-buffered input still
+// holds the end of the key's line, which is dropped first. In raw mode
+// typed characters are echoed to w and Backspace works.
+func readLine(r *bufio.Reader, w io.Writer, raw bool) (string, error) {
+	if !raw {
+		if _, err := r.ReadString('\n'); err != nil {
+			return "", err
+		}
+		line, err := r.ReadString('\n')
+		return strings.TrimSpace(line), err
+	}
+
+	var buf []rune
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return strings.TrimSpace(string(buf)), err
+		}
+		switch {
+		case c == '\r' || c == '\n':
+			fmt.Fprintln(w)
+			return strings.TrimSpace(string(buf)), nil
+		case c == keyEsc || c == keyCtrlC:
+			fmt.Fprintln(w)
+			return "", errCanceled
+		case c == 0x7f || c == '\b':
+			if len(buf) == 0 {
+				continue
+			}
+			last := buf[len(buf)-1]
+			buf = buf[:len(buf)-1]
+			// CJK characters take two columns.
+			if utf8.RuneLen(last) >= 3 {
+				fmt.Fprint(w, "\b\b  \b\b")
+			} else {
+				fmt.Fprint(w, "\b \b")
+			}
+		case c >= ' ':
+			buf = append(buf, c)
+			fmt.Fprint(w, string(c))
+		}
+	}
+}
+
+// readKey returns the next key, skipping line endings left by
+// line-buffered input and decoding arrow-key escape sequences.
+func readKey(r *bufio.Reader) (rune, error) {
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case '\r', '\n':
+			continue
+		case keyEsc:
+			// Terminals send escape sequences in one write; a lone Esc
+			// must not block waiting for more input.
+			if r.Buffered() < 2 {
+				return c, nil
+			}
+			if next, _ := r.Peek(2); next[0] == '[' {
+				_, _ = r.Discard(2)
+				switch next[1] {
+				case 'C':
+					return keyRight, nil
+				case 'D':
+					return keyLeft, nil
+				}
+				continue
+			}
+		}
+		return c, nil
+	}
+}
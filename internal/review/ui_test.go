@@ -0,0 +1,193 @@
+package review
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+var testNow = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+// newStore saves one Chinese draft per name and returns the store and
+// the pending items in order.
+func newStore(t *testing.T, names ...string) (*datastore.Store, []Item) {
+	t.Helper()
+	store := datastore.NewStore(t.TempDir(), zap.NewNop())
+	for _, n := range names {
+		p := &datastore.Project{ID: "o__" + n, FullName: "o/" + n, FirstSeenAt: testNow, UpdatedAt: testNow}
+		p.AttachAnalysis(&datastore.Analysis{
+			Status:      "draft",
+			Model:       "deepseek-chat",
+			Summary:     n + " 的摘要",
+			Features:    []datastore.Feature{{Name: "功能", Desc: "说明"}},
+			GeneratedAt: testNow,
+		})
+		if err := store.SaveAnalysisVersion(p.ID, p.Analysis); err != nil {
+			t.Fatalf("SaveAnalysisVersion: %v", err)
+		}
+		if err := store.SaveProject(p); err != nil {
+			t.Fatalf("SaveProject: %v", err)
+		}
+	}
+	return store, reload(t, store)
+}
+
+func reload(t *testing.T, store *datastore.Store) []Item {
+	t.Helper()
+	projects, err := store.ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects: %v", err)
+	}
+	return Pending(projects, "")
+}
+
+func newUI(t *testing.T, store *datastore.Store, keys string) (*UI, *bytes.Buffer) {
+	t.Helper()
+	session, err := LoadSession(SessionPath(store.DataDir()), testNow)
+	if err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	var out bytes.Buffer
+	return &UI{
+		Store:    store,
+		Session:  session,
+		In:       strings.NewReader(keys),
+		Out:      &out,
+		Log:      zap.NewNop(),
+		Reviewer: "alice",
+		Now:      func() time.Time { return testNow },
+	}, &out
+}
+
+func status(t *testing.T, store *datastore.Store, id string) string {
+	t.Helper()
+	p, err := store.LoadProject(id)
+	if err != nil {
+		t.Fatalf("LoadProject: %v", err)
+	}
+	return p.Analysis.Status
+}
+
+func TestUI_DecideSkipAndResume(t *testing.T) {
+	store, items := newStore(t, "a", "b", "c")
+	if len(items) != 3 {
+		t.Fatalf("pending = %d, want 3", len(items))
+	}
+
+	// Line-buffered input: approve a, skip b, reject c with a reason.
+	ui, out := newUI(t, store, "a\ns\nr\n摘要与 README 不符\n")
+	if err := ui.Run(items); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := status(t, store, "o__a"); got != "published" {
+		t.Errorf("a = %s, want published", got)
+	}
+	if got := status(t, store, "o__b"); got != "draft" {
+		t.Errorf("b = %s, want draft (skipped)", got)
+	}
+	if got := status(t, store, "o__c"); got != "rejected" {
+		t.Errorf("c = %s, want rejected", got)
+	}
+	r, err := store.LatestReview("o__c", "zh")
+	if err != nil || r == nil || r.Reviewer != "alice" || r.Action != datastore.ReviewReject || r.Reason != "摘要与 README 不符" {
+		t.Errorf("review log for c = %+v, %v", r, err)
+	}
+	if !strings.Contains(out.String(), "[1/3] o/a") || !strings.Contains(out.String(), "摘要：a 的摘要") {
+		t.Errorf("output missing draft page:\n%s", out.String())
+	}
+
+	// The skipped draft survives in the session and is reviewed next time.
+	if _, err := os.Stat(SessionPath(store.DataDir())); err != nil {
+		t.Fatalf("session not kept after skipping: %v", err)
+	}
+	ui, out = newUI(t, store, "a")
+	if !ui.Session.Resumed() {
+		t.Error("session should be resumed")
+	}
+	if err := ui.Run(reload(t, store)); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := status(t, store, "o__b"); got != "published" {
+		t.Errorf("b = %s, want published", got)
+	}
+	if !strings.Contains(out.String(), "继续上次的审核") {
+		t.Errorf("resume not announced:\n%s", out.String())
+	}
+	if _, err := os.Stat(SessionPath(store.DataDir())); !os.IsNotExist(err) {
+		t.Errorf("session should be cleared when nothing is left, stat err = %v", err)
+	}
+}
+
+func TestUI_QuitKeepsOrder(t *testing.T) {
+	store, items := newStore(t, "a", "b", "c")
+
+	// Skip a, then quit on b.
+	ui, _ := newUI(t, store, "sq")
+	if err := ui.Run(items); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	session, err := LoadSession(SessionPath(store.DataDir()), testNow)
+	if err != nil {
+		t.Fatalf("LoadSession: %v", err)
+	}
+	var order []string
+	for _, it := range session.Order(reload(t, store)) {
+		order = append(order, it.Project.ID)
+	}
+	if strings.Join(order, ",") != "o__b,o__c,o__a" {
+		t.Errorf("resumed order = %v, want unseen first and skipped a last", order)
+	}
+}
+
+func TestUI_BackAndEdit(t *testing.T) {
+	store, items := newStore(t, "a", "b")
+
+	// a is skipped, the left arrow goes back to it, it is edited and the
+	// edit confirmed with y; the right arrow moves on to b, approved.
+	ui, out := newUI(t, store, "s\x1b[Dey\x1b[Ca")
+	ui.Editor = &Editor{Out: ui.Out, Now: ui.Now, run: rewrite(t, "a 的摘要", "人工修改的摘要")}
+	if err := ui.Run(items); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	p, _ := store.LoadProject("o__a")
+	if a := p.Analysis; a.Summary != "人工修改的摘要" || a.Status != "draft" || !a.HumanEdited {
+		t.Errorf("a = %q (%s, edited=%v), want human-edited draft", a.Summary, a.Status, a.HumanEdited)
+	}
+	v, err := store.LoadAnalysisVersion("o__a", "zh", p.Analysis.Version)
+	if err != nil || v.Summary != "人工修改的摘要" {
+		t.Errorf("version file not updated: %v %+v", err, v)
+	}
+	if got := status(t, store, "o__b"); got != "published" {
+		t.Errorf("b = %s, want published", got)
+	}
+	if !strings.Contains(out.String(), "✓ 已保存修改") {
+		t.Errorf("edit not confirmed:\n%s", out.String())
+	}
+	if p.Analysis.EditedBy != "alice" {
+		t.Errorf("edited_by = %q, want alice", p.Analysis.EditedBy)
+	}
+}
+
+func TestReadLine_Raw(t *testing.T) {
+	var out bytes.Buffer
+	line, err := readLine(bufio.NewReader(strings.NewReader("不对x\x7f\r")), &out, true)
+	if err != nil || line != "不对" {
+		t.Errorf("readLine = %q, %v; want 不对", line, err)
+	}
+	if !strings.HasPrefix(out.String(), "不对x\b \b") {
+		t.Errorf("echo = %q", out.String())
+	}
+
+	if _, err := readLine(bufio.NewReader(strings.NewReader("ab\x1b")), &out, true); !errors.Is(err, errCanceled) {
+		t.Errorf("Esc: err = %v, want errCanceled", err)
+	}
+}
@@ -114,6 +114,10 @@ func (s *Scraper) enrichAndSave(
 		if existing.Analysis != nil {
 			proj.Analysis = existing.Analysis
 		}
+		proj.Draft = existing.Draft
+		proj.Localized = existing.Localized
+		proj.TechStack = existing.TechStack
+		proj.Similar = existing.Similar
 		// Merge weekly stars from existing if we only have daily now
 		if s.since == "daily" && existing.Trending != nil && existing.Trending.WeeklyStars != nil {
 			proj.Trending.WeeklyStars = existing.Trending.WeeklyStars
@@ -0,0 +1,384 @@
+// Package techstack detects a repository's languages, frameworks and key
+// dependencies from its top-level file tree and dependency manifests, so
+// the analysis prompt can ground the tech-stack section in facts.
+package techstack
+
+import (
+	"bufio"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zbb88888/tishi/internal/datastore"
+)
+
+// MaxDependencies caps TechStack.Dependencies.
+const MaxDependencies = 40
+
+// Manifests are the files Detect understands, in the order they are read.
+var Manifests = []string{"go.mod", "pyproject.toml", "requirements.txt", "package.json", "Cargo.toml"}
+
+// manifestLanguage is the language implied by each manifest.
+var manifestLanguage = map[string]string{
+	"go.mod":           "Go",
+	"pyproject.toml":   "Python",
+	"requirements.txt": "Python",
+	"setup.py":         "Python",
+	"package.json":     "JavaScript",
+	"tsconfig.json":    "TypeScript",
+	"Cargo.toml":       "Rust",
+	"pom.xml":          "Java",
+	"build.gradle":     "Java",
+	"build.gradle.kts": "Kotlin",
+	"CMakeLists.txt":   "C++",
+	"Gemfile":          "Ruby",
+	"Package.swift":    "Swift",
+	"mix.exs":          "Elixir",
+	"composer.json":    "PHP",
+}
+
+// frameworks maps normalized dependency names to display names of
+// libraries worth calling out. Entries ending in "/" match as prefixes.
+var frameworks = map[string]string{
+	// Python ML / LLM
+	"torch": "PyTorch", "tensorflow": "TensorFlow", "jax": "JAX", "keras": "Keras",
+	"transformers": "Transformers", "diffusers": "Diffusers", "accelerate": "Accelerate",
+	"peft": "PEFT", "trl": "TRL", "deepspeed": "DeepSpeed", "vllm": "vLLM", "sentence-transformers": "Sentence Transformers",
+	"langchain": "LangChain", "langchain-core": "LangChain", "langgraph": "LangGraph",
+	"llama-index": "LlamaIndex", "llama-index-core": "LlamaIndex", "dspy": "DSPy", "autogen": "AutoGen",
+	"pyautogen": "AutoGen", "crewai": "CrewAI", "openai": "OpenAI SDK", "anthropic": "Anthropic SDK",
+	"litellm": "LiteLLM", "onnxruntime": "ONNX Runtime", "scikit-learn": "scikit-learn",
+	"faiss-cpu": "FAISS", "faiss-gpu": "FAISS", "chromadb": "Chroma", "pymilvus": "Milvus",
+	"qdrant-client": "Qdrant", "ray": "Ray", "mlflow": "MLflow", "wandb": "Weights & Biases",
+	"fastapi": "FastAPI", "flask": "Flask", "django": "Django", "gradio": "Gradio", "streamlit": "Streamlit",
+	"pydantic": "Pydantic",
+	// Go
+	"github.com/gin-gonic/gin": "Gin", "github.com/labstack/echo/v4": "Echo", "github.com/gofiber/fiber/v2": "Fiber",
+	"github.com/spf13/cobra": "Cobra", "google.golang.org/grpc": "gRPC", "github.com/tmc/langchaingo": "LangChainGo",
+	"github.com/sashabaranov/go-openai": "go-openai", "github.com/ollama/ollama": "Ollama",
+	// JavaScript / TypeScript
+	"react": "React", "next": "Next.js", "vue": "Vue", "svelte": "Svelte", "@sveltejs/kit": "SvelteKit",
+	"express": "Express", "@nestjs/core": "NestJS", "electron": "Electron", "tailwindcss": "Tailwind CSS",
+	"@langchain/": "LangChain.js", "ai": "Vercel AI SDK",
+	"@anthropic-ai/sdk": "Anthropic SDK", "@modelcontextprotocol/sdk": "MCP SDK",
+	"@tensorflow/tfjs": "TensorFlow.js", "onnxruntime-web": "ONNX Runtime", "@xenova/transformers": "Transformers.js",
+	"@huggingface/transformers": "Transformers.js",
+	// Rust
+	"tokio": "Tokio", "axum": "Axum", "actix-web": "Actix Web", "candle-core": "Candle", "burn": "Burn",
+	"tch": "tch (LibTorch)", "ort": "ONNX Runtime", "tauri": "Tauri", "pyo3": "PyO3",
+}
+
+// Detect builds a TechStack from the top-level file names and the contents
+// of any manifests that were fetched (keyed by file name). primary is the
+// GitHub-reported language, listed first when known.
+func Detect(files []string, manifests map[string]string, primary string, now time.Time) *datastore.TechStack {
+	langs := newOrderedSet()
+	if primary != "" {
+		langs.add(primary)
+	}
+	for _, f := range files {
+		if l, ok := manifestLanguage[f]; ok {
+			langs.add(l)
+		}
+	}
+
+	deps := newOrderedSet()
+	var parsed []string
+	for _, name := range Manifests {
+		content, ok := manifests[name]
+		if !ok {
+			continue
+		}
+		parsed = append(parsed, name)
+
+		var names []string
+		switch name {
+		case "go.mod":
+			names = ParseGoMod(content)
+		case "pyproject.toml":
+			names = ParsePyproject(content)
+		case "requirements.txt":
+			names = ParseRequirements(content)
+		case "package.json":
+			var ts bool
+			names, ts = ParsePackageJSON(content)
+			if ts {
+				langs.add("TypeScript")
+			}
+		case "Cargo.toml":
+			names = ParseCargo(content)
+		}
+		for _, n := range names {
+			deps.add(n)
+		}
+	}
+
+	fw := newOrderedSet()
+	for _, d := range deps.items {
+		if name := frameworkName(d); name != "" {
+			fw.add(name)
+		}
+	}
+
+	depList := deps.items
+	if len(depList) > MaxDependencies {
+		depList = depList[:MaxDependencies]
+	}
+
+	return &datastore.TechStack{
+		Languages:    langs.items,
+		Frameworks:   fw.items,
+		Dependencies: depList,
+		Manifests:    parsed,
+		DetectedAt:   now,
+	}
+}
+
+func frameworkName(dep string) string {
+	if name, ok := frameworks[dep]; ok {
+		return name
+	}
+	for prefix, name := range frameworks {
+		if strings.HasSuffix(prefix, "/") && strings.HasPrefix(dep, prefix) {
+			return name
+		}
+	}
+	return ""
+}
+
+// ParseGoMod returns the direct requirements of a go.mod file.
+func ParseGoMod(content string) []string {
+	var out []string
+	inBlock := false
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case strings.HasPrefix(line, "require ("):
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimPrefix(line, "require ")
+		case !inBlock:
+			continue
+		}
+		if strings.Contains(line, "// indirect") {
+			continue
+		}
+		if f := strings.Fields(line); len(f) >= 2 && !strings.HasPrefix(f[0], "//") {
+			out = append(out, f[0])
+		}
+	}
+	return out
+}
+
+// ParseRequirements returns package names from a requirements.txt file.
+func ParseRequirements(content string) []string {
+	var out []string
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		if name := pythonName(line); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// ParsePyproject returns dependencies from [project] dependencies and
+// [tool.poetry.dependencies]. It understands the subset of TOML these
+// sections use in practice.
+func ParsePyproject(content string) []string {
+	var out []string
+	section := ""
+	inArray := false
+
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if inArray {
+			for _, s := range quotedStrings(line) {
+				out = append(out, pythonName(s))
+			}
+			if closesArray(line) {
+				inArray = false
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch section {
+		case "project":
+			if key == "dependencies" && strings.HasPrefix(value, "[") {
+				for _, s := range quotedStrings(value) {
+					out = append(out, pythonName(s))
+				}
+				inArray = !closesArray(strings.TrimPrefix(value, "["))
+			}
+		case "tool.poetry.dependencies":
+			if key != "python" {
+				out = append(out, normalizePython(strings.Trim(key, `"'`)))
+			}
+		}
+	}
+	return compact(out)
+}
+
+// ParsePackageJSON returns dependencies and peerDependencies, and whether
+// the project uses TypeScript.
+func ParsePackageJSON(content string) ([]string, bool) {
+	var pkg struct {
+		Dependencies     map[string]string `json:"dependencies"`
+		PeerDependencies map[string]string `json:"peerDependencies"`
+		DevDependencies  map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return nil, false
+	}
+
+	var out []string
+	for _, m := range []map[string]string{pkg.Dependencies, pkg.PeerDependencies} {
+		for name := range m {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+
+	_, ts := pkg.DevDependencies["typescript"]
+	if _, ok := pkg.Dependencies["typescript"]; ok {
+		ts = true
+	}
+	return compact(out), ts
+}
+
+// ParseCargo returns crate names from [dependencies] and
+// [workspace.dependencies].
+func ParseCargo(content string) []string {
+	var out []string
+	section := ""
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			// [dependencies.foo] declares crate foo.
+			if name, ok := strings.CutPrefix(section, "dependencies."); ok {
+				out = append(out, name)
+			}
+			continue
+		}
+		if section != "dependencies" && section != "workspace.dependencies" {
+			continue
+		}
+		if key, _, ok := strings.Cut(line, "="); ok {
+			out = append(out, strings.Trim(strings.TrimSpace(key), `"`))
+		}
+	}
+	return compact(out)
+}
+
+// pythonName extracts the normalized distribution name from a requirement
+// specifier such as "torch>=2.1; python_version>'3.8'".
+func pythonName(spec string) string {
+	end := strings.IndexAny(spec, "=<>!~[;@ (")
+	if end >= 0 {
+		spec = spec[:end]
+	}
+	return normalizePython(spec)
+}
+
+// normalizePython applies PEP 503 normalization.
+func normalizePython(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer("_", "-", ".", "-").Replace(name)
+}
+
+func quotedStrings(s string) []string {
+	var out []string
+	for {
+		i := strings.IndexAny(s, `"'`)
+		if i < 0 {
+			return out
+		}
+		q := s[i]
+		j := strings.IndexByte(s[i+1:], q)
+		if j < 0 {
+			return out
+		}
+		out = append(out, s[i+1:i+1+j])
+		s = s[i+j+2:]
+	}
+}
+
+// closesArray reports whether a TOML array line contains its closing
+// bracket outside of strings (extras like "torch[cuda]" do not count).
+func closesArray(line string) bool {
+	inQuote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case inQuote != 0:
+			if c == inQuote {
+				inQuote = 0
+			}
+		case c == '"' || c == '\'':
+			inQuote = c
+		case c == ']':
+			return true
+		}
+	}
+	return false
+}
+
+func compact(names []string) []string {
+	out := names[:0]
+	for _, n := range names {
+		if n != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// orderedSet keeps first-seen order while dropping duplicates.
+type orderedSet struct {
+	seen  map[string]bool
+	items []string
+}
+
+func newOrderedSet() *orderedSet {
+	return &orderedSet{seen: make(map[string]bool)}
+}
+
+func (s *orderedSet) add(v string) {
+	if v == "" || s.seen[v] {
+		return
+	}
+	s.seen[v] = true
+	s.items = append(s.items, v)
+}
@@ -0,0 +1,141 @@
+package techstack
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseGoMod(t *testing.T) {
+	content := `module example.com/x
+
+go 1.24
+
+require github.com/spf13/cobra v1.8.0
+
+require (
+	github.com/gin-gonic/gin v1.10.0
+	golang.org/x/sys v0.20.0 // indirect
+	// a comment
+	github.com/tmc/langchaingo v0.1.12
+)
+`
+	want := []string{"github.com/spf13/cobra", "github.com/gin-gonic/gin", "github.com/tmc/langchaingo"}
+	if got := ParseGoMod(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseRequirements(t *testing.T) {
+	content := `# core
+torch>=2.1
+Transformers==4.40.0  # pinned
+sentence_transformers
+-r dev.txt
+git+https://github.com/a/b.git
+uvicorn[standard]; python_version > "3.8"
+`
+	want := []string{"torch", "transformers", "sentence-transformers", "uvicorn"}
+	if got := ParseRequirements(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParsePyproject(t *testing.T) {
+	pep621 := `[project]
+name = "demo"
+dependencies = [
+    "torch[cuda]>=2.0",
+    "langchain-core",
+    'llama_index>=0.10',
+]
+
+[project.optional-dependencies]
+dev = ["pytest"]
+`
+	want := []string{"torch", "langchain-core", "llama-index"}
+	if got := ParsePyproject(pep621); !reflect.DeepEqual(got, want) {
+		t.Errorf("PEP 621: got %v, want %v", got, want)
+	}
+
+	poetry := `[tool.poetry.dependencies]
+python = "^3.10"
+fastapi = "^0.110"
+openai = { version = "^1.0", optional = true }
+
+[tool.poetry.group.dev.dependencies]
+pytest = "*"
+`
+	want = []string{"fastapi", "openai"}
+	if got := ParsePyproject(poetry); !reflect.DeepEqual(got, want) {
+		t.Errorf("poetry: got %v, want %v", got, want)
+	}
+
+	inline := `[project]
+dependencies = ["gradio", "vllm"]
+`
+	if got := ParsePyproject(inline); !reflect.DeepEqual(got, []string{"gradio", "vllm"}) {
+		t.Errorf("inline: got %v", got)
+	}
+}
+
+func TestParsePackageJSON(t *testing.T) {
+	content := `{
+  "dependencies": {"react": "^18", "@langchain/openai": "^0.3"},
+  "peerDependencies": {"ai": "^3"},
+  "devDependencies": {"typescript": "^5", "vitest": "^1"}
+}`
+	names, ts := ParsePackageJSON(content)
+	want := []string{"@langchain/openai", "ai", "react"}
+	if !reflect.DeepEqual(names, want) || !ts {
+		t.Errorf("got %v ts=%v, want %v ts=true", names, ts, want)
+	}
+
+	if names, _ := ParsePackageJSON("not json"); names != nil {
+		t.Errorf("invalid JSON: got %v", names)
+	}
+}
+
+func TestParseCargo(t *testing.T) {
+	content := `[package]
+name = "demo"
+
+[dependencies]
+tokio = { version = "1", features = ["full"] }
+candle-core = "0.6"
+
+[dependencies.axum]
+version = "0.7"
+
+[dev-dependencies]
+criterion = "0.5"
+`
+	want := []string{"tokio", "candle-core", "axum"}
+	if got := ParseCargo(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	files := []string{"README.md", "pyproject.toml", "package.json", "tsconfig.json", "Dockerfile"}
+	manifests := map[string]string{
+		"pyproject.toml": "[project]\ndependencies = [\"torch\", \"transformers\", \"numpy\"]\n",
+		"package.json":   `{"dependencies": {"next": "14", "react": "18"}}`,
+	}
+
+	ts := Detect(files, manifests, "Python", now)
+
+	if want := []string{"Python", "JavaScript", "TypeScript"}; !reflect.DeepEqual(ts.Languages, want) {
+		t.Errorf("Languages = %v, want %v", ts.Languages, want)
+	}
+	if want := []string{"PyTorch", "Transformers", "Next.js", "React"}; !reflect.DeepEqual(ts.Frameworks, want) {
+		t.Errorf("Frameworks = %v, want %v", ts.Frameworks, want)
+	}
+	if want := []string{"torch", "transformers", "numpy", "next", "react"}; !reflect.DeepEqual(ts.Dependencies, want) {
+		t.Errorf("Dependencies = %v, want %v", ts.Dependencies, want)
+	}
+	if want := []string{"pyproject.toml", "package.json"}; !reflect.DeepEqual(ts.Manifests, want) {
+		t.Errorf("Manifests = %v, want %v", ts.Manifests, want)
+	}
+}
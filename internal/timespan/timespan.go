@@ -0,0 +1,27 @@
+// Package timespan parses the short durations used on the command line and
+// in filters: a positive whole number of hours, days or weeks, e.g. 3d.
+package timespan
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var units = map[byte]time.Duration{'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+
+// Parse parses a duration such as 12h, 30d or 2w.
+func Parse(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("时长为空")
+	}
+	mult, ok := units[s[len(s)-1]]
+	if !ok {
+		return 0, fmt.Errorf("时长 %q 需要单位 h、d 或 w，如 3d", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("无效的时长 %q，应为正整数加 h、d 或 w，如 3d", s)
+	}
+	return time.Duration(n) * mult, nil
+}
@@ -29,10 +29,16 @@ export interface Feature {
 export interface ComparisonEntry {
     project: string;
     diff: string;
+    project_id?: string;  // tracked project the name resolved to
+    repo?: string;        // GitHub owner/repo
+    status?: 'tracked' | 'github' | 'suspicious' | 'unresolved';
+    note?: string;
 }
 
 export interface Analysis {
-    status: string;       // draft | published | rejected
+    version?: string;     // e.g. 20261018T120000Z
+    locale?: string;      // empty = zh
+    status: string;       // draft | published | rejected | superseded
     model: string;
     summary: string;
     positioning?: string;
@@ -44,7 +50,47 @@ export interface Analysis {
     ecosystem?: string;
     generated_at: string;
     reviewed_at?: string;
+    reviewed_by?: string;   // reviewer of the last decision
+    review_note?: string;   // reason or comment given with it
     token_usage?: number;
+    prompt_tokens?: number;
+    completion_tokens?: number;
+    prompt_name?: string;     // prompt variant, e.g. default / agent
+    prompt_version?: string;
+    input_hash?: string;
+    tech_stack_hash?: string; // detected tech stack the prompt contained
+    stale?: boolean;      // published, but project inputs changed since
+    stale_reason?: string;
+    validation?: Validation;
+    human_edited?: boolean; // changed by a reviewer with tishi review --edit
+    edited_at?: string;
+    edited_by?: string;
+    auto_published?: boolean; // published by review.auto_publish, not a reviewer
+    review_feedback?: string; // rejection reason this generation was asked to address
+}
+
+export interface ValidationIssue {
+    field: string;
+    level: string;        // error | warning
+    message: string;
+}
+
+export interface Validation {
+    issues?: ValidationIssue[];
+    repairs?: number;
+}
+
+export interface TechStack {
+    languages?: string[];
+    frameworks?: string[];
+    dependencies?: string[];
+    manifests?: string[];     // e.g. go.mod, package.json
+    detected_at: string;
+}
+
+export interface LocalizedAnalysis {
+    analysis?: Analysis;
+    draft?: Analysis;
 }
 
 export interface CategoryMatch {
@@ -52,6 +98,12 @@ export interface CategoryMatch {
     confidence: number;
 }
 
+export interface SimilarProject {
+    project_id: string;
+    full_name: string;
+    score: number;        // cosine similarity, 0-1
+}
+
 export interface Project {
     id: string;           // owner__repo
     full_name: string;    // owner/repo
@@ -71,8 +123,12 @@ export interface Project {
     rank?: number;
     category?: string;    // primary category slug
     trending?: Trending;
+    tech_stack?: TechStack;   // detected from file tree and manifests
     analysis?: Analysis;
+    draft?: Analysis;     // unreviewed version pending next to a published one
     categories?: CategoryMatch[];
+    localized?: Record<string, LocalizedAnalysis>;  // non-zh analyses, e.g. en
+    similar?: SimilarProject[];  // nearest projects by embedding, best first
     first_seen_at: string;
     updated_at: string;
 }
@@ -113,11 +169,31 @@ export interface Post {
     slug: string;
     title: string;
     content: string;       // Markdown
-    post_type: string;     // weekly | monthly | spotlight
+    post_type: string;     // weekly | monthly | spotlight | newcomers
+    locale?: string;       // empty = zh
     cover_image_url?: string;
     published_at?: string;
     created_at: string;
     updated_at?: string;
+    narrative?: Narrative; // weekly | monthly, zh only
+}
+
+export interface NarrativeHighlight {
+    project: string;       // owner/repo
+    take: string;
+}
+
+export interface Narrative {
+    status: string;        // draft | published | rejected; rendered into content, post unpublished until published
+    model: string;
+    intro: string;
+    highlights?: NarrativeHighlight[];
+    generated_at: string;
+    reviewed_at?: string;
+    reviewed_by?: string;
+    review_note?: string;
+    validation?: Validation;
+    review_feedback?: string;
 }
 
 export interface CategoryKeywords {
@@ -180,13 +256,13 @@ export function getAllProjects(): Project[] {
         .filter((p): p is Project => p !== null);
 }
 
-/** 获取所有已发布文章，按发布时间倒序。 */
-export function getPublishedPosts(): Post[] {
+/** 获取指定语言（默认中文）的已发布文章，按发布时间倒序。 */
+export function getPublishedPosts(locale = 'zh'): Post[] {
     const dir = path.join(DATA_DIR, 'posts');
     return safeReadDir(dir)
         .filter(f => f.endsWith('.json'))
         .map(f => safeReadJSON<Post>(path.join(dir, f)))
-        .filter((p): p is Post => p !== null && p.published_at != null)
+        .filter((p): p is Post => p !== null && p.published_at != null && (p.locale || 'zh') === locale)
         .sort((a, b) => (b.published_at || '').localeCompare(a.published_at || ''));
 }
 